@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"gocpu/pkg/compiler"
 )
@@ -16,14 +17,28 @@ return x;
 func main() {
 	src := testSource
 	baseDir := "."
-	if len(os.Args) > 1 {
-		data, err := os.ReadFile(os.Args[1])
+	showFrameSizes := false
+	zeroLocals := false
+	alignStructs := false
+	for _, arg := range os.Args[1:] {
+		switch arg {
+		case "-frame-sizes":
+			showFrameSizes = true
+			continue
+		case "-zero-locals":
+			zeroLocals = true
+			continue
+		case "-align-structs":
+			alignStructs = true
+			continue
+		}
+		data, err := os.ReadFile(arg)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "read error:", err)
 			os.Exit(1)
 		}
 		src = string(data)
-		baseDir = filepath.Dir(os.Args[1])
+		baseDir = filepath.Dir(arg)
 	}
 
 	// Preprocess
@@ -64,7 +79,11 @@ func main() {
 
 	// code Generation
 	syms := compiler.NewSymbolTable()
-	asm, err := compiler.Generate(stmts, syms)
+	asm, frameSizes, err := compiler.GenerateWithStats(stmts, syms, compiler.Options{
+		ZeroLocals:   zeroLocals,
+		AlignStructs: alignStructs,
+		Warn:         func(w compiler.Warning) { fmt.Fprintln(os.Stderr, w) },
+	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "codegen error:", err)
 		os.Exit(1)
@@ -74,4 +93,17 @@ func main() {
 	fmt.Print(asm)
 	fmt.Println()
 	fmt.Print(syms)
+
+	if showFrameSizes {
+		names := make([]string, 0, len(frameSizes))
+		for name := range frameSizes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Println()
+		fmt.Println("Frame Sizes")
+		for _, name := range names {
+			fmt.Printf("  %-20s %5d bytes\n", name, frameSizes[name])
+		}
+	}
 }
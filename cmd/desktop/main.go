@@ -22,7 +22,7 @@ import (
 
 type Game struct {
 	vm          *cpu.CPU
-	graphicsImg *ebiten.Image // reused 128×128 bitmap canvas
+	graphicsImg *ebiten.Image // reused bitmap canvas, sized to vm.GraphicsSize()
 }
 
 func loadImage(fileName string) (*image.RGBA, error) {
@@ -85,8 +85,9 @@ func (g *Game) Update() error {
 }
 
 func (g *Game) drawBitmap(screen *ebiten.Image) {
-	if g.graphicsImg == nil {
-		g.graphicsImg = ebiten.NewImage(128, 128)
+	w, h := g.vm.GraphicsSize()
+	if g.graphicsImg == nil || g.graphicsImg.Bounds().Dx() != w || g.graphicsImg.Bounds().Dy() != h {
+		g.graphicsImg = ebiten.NewImage(w, h)
 	}
 
 	// If the allocation STILL failed, don't try to draw this frame
@@ -97,7 +98,7 @@ func (g *Game) drawBitmap(screen *ebiten.Image) {
 	pixels := g.vm.GetFramebufferRGBA()
 	g.graphicsImg.WritePixels(pixels)
 
-	// Scale the 128×128 image to fill the 256×256 logical screen.
+	// Draw the bitmap at 2× scale; Layout sizes the screen to match.
 	op := &ebiten.DrawImageOptions{}
 	op.GeoM.Scale(2, 2)
 	screen.DrawImage(g.graphicsImg, op)
@@ -161,8 +162,9 @@ func startDiskSyncer(vm *cpu.CPU, interval time.Duration, stop <-chan struct{})
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	if g.vm.GraphicsEnabled {
-		// 128×128 bitmap rendered at 2× scale
-		return 256, 256
+		// Bitmap rendered at 2× scale (256×256 for the default 128×128)
+		w, h := g.vm.GraphicsSize()
+		return w * 2, h * 2
 	}
 	if g.vm.TextResolutionMode == 1 {
 		// Mode 1: 64 columns × 8px, 16 rows × 12px
@@ -225,11 +227,19 @@ func main() {
 	cpu.RegisterPeripheral(peripherals.CameraPeripheralType, func(c *cpu.CPU, slot uint8) cpu.Peripheral {
 		return peripherals.NewCameraPeripheral(c, slot, capFunc)
 	})
+	cpu.RegisterPeripheral(peripherals.BlitterType, func(c *cpu.CPU, slot uint8) cpu.Peripheral {
+		return peripherals.NewBlitter(c, slot)
+	})
+	cpu.RegisterPeripheral(peripherals.TonePeripheralType, func(c *cpu.CPU, slot uint8) cpu.Peripheral {
+		return peripherals.NewTonePeripheral(c, slot)
+	})
 
 	// 3. Initialize CPU (loads any previously saved VFS files from storagePath)
 	vm := cpu.NewCPU(storagePath)
 	vm.MountPeripheral(0, peripherals.NewMessageSender(vm, 0, dispatch))
 	vm.MountPeripheral(1, peripherals.NewCameraPeripheral(vm, 1, capFunc))
+	vm.MountPeripheral(2, peripherals.NewBlitter(vm, 2))
+	vm.MountPeripheral(3, peripherals.NewTonePeripheral(vm, 3))
 
 	if len(machineCode) > len(vm.Memory) {
 		log.Fatalf("Program too large for memory")
@@ -1,10 +1,12 @@
 // VFS MMIO Hardware Ports
-int* VFS_CMD    = 0xFF10; // Command trigger: 1=Read, 2=Write, 3=Size, 4=Delete, 5=List, 6=FreeSpace, 7=GetMeta
+int* VFS_CMD    = 0xFF10; // Command trigger: 1=Read, 2=Write, 3=Size, 4=Delete, 5=List, 6=FreeSpace, 7=GetMeta, 8=ExecWait, 9=Rename, 10=ReadAt, 11=Append
 int* VFS_NAME   = 0xFF11; // Pointer to null-terminated filename string
 int* VFS_BUF    = 0xFF12; // Pointer to data buffer
 int* VFS_SIZE   = 0xFF13; // Size in bytes (16-bit)
-int* VFS_STAT   = 0xFF14; // Status code: 0=Success, 1=NotFound, 2=Full, 3=InvalidName, 4=OutOfBounds, 5=DirEnd
+int* VFS_STAT   = 0xFF14; // Status code: 0=Success, 1=NotFound, 2=Full, 3=InvalidName, 4=OutOfBounds, 5=DirEnd, 6=Exists, 7=Protected
 int* VFS_SIZE_H = 0xFF15; // High word for free space calculation
+int* VFS_OFF_LO = 0xFF1A; // ReadAt byte offset, low word
+int* VFS_OFF_HI = 0xFF1B; // ReadAt byte offset, high word
 
 int CMD_EXEC_WAIT = 8;
 
@@ -60,3 +62,45 @@ int vfs_delete(int* filename) {
 
     return *VFS_STAT;
 }
+
+// Renames 'old_name' to 'new_name'. Fails with status 6 if a file
+// called 'new_name' already exists.
+// Returns 0 on success.
+int vfs_rename(int* old_name, int* new_name) {
+    *VFS_NAME = old_name;
+    *VFS_BUF  = new_name;
+    *VFS_CMD  = 9; // Trigger Rename Command
+
+    return *VFS_STAT;
+}
+
+// Reads up to 'length' bytes of 'filename', starting at byte 'offset',
+// into 'buffer'. Offsets above 64KB are not reachable from here; set
+// VFS_OFF_HI directly for those.
+// Returns the number of bytes read (0 at end of file), or -1 on error.
+int vfs_read_at(int* filename, int* buffer, int offset, int length) {
+    *VFS_NAME   = filename;
+    *VFS_BUF    = buffer;
+    *VFS_OFF_LO = offset;
+    *VFS_OFF_HI = 0;
+    *VFS_SIZE   = length;
+    *VFS_CMD    = 10; // Trigger ReadAt Command
+
+    if (*VFS_STAT != 0) {
+        return -1;
+    }
+    return *VFS_SIZE;
+}
+
+// Appends 'length' bytes from 'buffer' to the end of 'filename',
+// creating the file if it does not exist.
+// Returns 0 on success, or an error code (2=Full, 3=InvalidName, 4=OutOfBounds).
+int vfs_append(int* filename, int* buffer, int length) {
+    *VFS_NAME = filename;
+    *VFS_BUF  = buffer;
+    *VFS_SIZE = length;
+
+    *VFS_CMD  = 11; // Trigger Append Command
+
+    return *VFS_STAT;
+}
@@ -3,6 +3,7 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"gocpu/pkg/asm"
@@ -19,12 +20,26 @@ func main() {
 	runProgram := flag.Bool("run", false, "run the generated binary file on the virtual CPU")
 	runBinPath := flag.String("run-bin", "", "run an existing binary file on the virtual CPU")
 	storagePath := flag.String("storage", "", "storage path for VFS")
+	disasmPath := flag.String("disasm", "", "print the disassembly of an existing binary file")
+	symbolsPath := flag.String("symbols", "", "write a JSON map of global and function addresses (C input only)")
+	coverage := flag.Bool("coverage", false, "after running, print the disassembly with executed instructions marked")
 	flag.Parse()
 
 	if *runProgram && *runBinPath != "" {
 		fmt.Fprintln(os.Stderr, "use either -run or -run-bin, not both")
 		os.Exit(2)
 	}
+	if *disasmPath != "" {
+		if err := disassembleBinary(*disasmPath); err != nil {
+			fmt.Fprintf(os.Stderr, "disassembly failed for %q: %v\n", *disasmPath, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *symbolsPath != "" && !strings.HasSuffix(*inPath, ".c") {
+		fmt.Fprintln(os.Stderr, "-symbols requires a .c file given with -in")
+		os.Exit(2)
+	}
 
 	assembledOutput := ""
 	if *inPath != "" {
@@ -36,11 +51,18 @@ func main() {
 
 		var code []byte
 		if strings.HasSuffix(*inPath, ".c") {
-			_, code, err = compiler.Compile(string(source), filepath.Dir(*inPath))
+			var symbols *compiler.SymbolMap
+			_, code, symbols, err = compiler.CompileWithSymbols(string(source), filepath.Dir(*inPath))
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "compilation failed: %v\n", err)
 				os.Exit(1)
 			}
+			if *symbolsPath != "" {
+				if err := writeSymbols(*symbolsPath, symbols); err != nil {
+					fmt.Fprintf(os.Stderr, "failed to write symbol map %q: %v\n", *symbolsPath, err)
+					os.Exit(1)
+				}
+			}
 		} else {
 			code, _, err = asm.Assemble(string(source))
 			if err != nil {
@@ -83,7 +105,7 @@ func main() {
 		return
 	}
 
-	if err := runBinary(runTarget, *storagePath); err != nil {
+	if err := runBinary(runTarget, *storagePath, *coverage); err != nil {
 		fmt.Fprintf(os.Stderr, "run failed for %q: %v\n", runTarget, err)
 		os.Exit(1)
 	}
@@ -101,36 +123,65 @@ func writeBinary(path string, data []byte) error {
 	return os.WriteFile(path, data, 0o644)
 }
 
+func writeSymbols(path string, symbols *compiler.SymbolMap) error {
+	data, err := json.MarshalIndent(symbols, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
 func readBinary(path string) ([]byte, error) {
 	return os.ReadFile(path)
 }
 
-func runBinary(path string, storagePath string) error {
-	loadedBytes, err := readBinary(path)
+func disassembleBinary(path string) error {
+	code, err := readBinary(path)
 	if err != nil {
 		return err
 	}
+	text, err := asm.Disassemble(code)
+	if err != nil {
+		return err
+	}
+	fmt.Print(text)
+	return nil
+}
 
-	vm := cpu.NewCPU(storagePath)
-	if len(loadedBytes) > len(vm.Memory) {
-		return fmt.Errorf("program too large for memory: %d bytes > %d bytes", len(loadedBytes), len(vm.Memory))
+func runBinary(path string, storagePath string, coverage bool) error {
+	loadedBytes, err := readBinary(path)
+	if err != nil {
+		return err
 	}
 
-	copy(vm.Memory[:], loadedBytes)
-	vm.Run()
+	res, err := cpu.RunProgram(loadedBytes, cpu.Options{
+		StoragePath: storagePath,
+		Output:      os.Stdout,
+		Coverage:    coverage,
+	})
+	if err != nil {
+		return err
+	}
 
 	fmt.Printf(
 		"run complete (%s): PC=0x%04X SP=0x%04X Z=%t N=%t R0=0x%04X R1=0x%04X R2=0x%04X R3=0x%04X\n",
 		path,
-		vm.PC,
-		vm.SP,
-		vm.Z,
-		vm.N,
-		vm.Regs[cpu.RegA],
-		vm.Regs[cpu.RegB],
-		vm.Regs[cpu.RegC],
-		vm.Regs[cpu.RegD],
+		res.PC,
+		res.SP,
+		res.Z,
+		res.N,
+		res.Regs[cpu.RegA],
+		res.Regs[cpu.RegB],
+		res.Regs[cpu.RegC],
+		res.Regs[cpu.RegD],
 	)
+	if res.Fault != nil {
+		fmt.Printf("fault: %v\n", res.Fault)
+	}
+
+	if coverage {
+		fmt.Print(res.Coverage)
+	}
 
 	return nil
 }
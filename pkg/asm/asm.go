@@ -1,6 +1,7 @@
 package asm
 
 import (
+	"errors"
 	"fmt"
 	"gocpu/pkg/cpu"
 	"strconv"
@@ -8,63 +9,54 @@ import (
 	"unicode"
 )
 
-var zeroOperandOps = map[string]uint16{
-	"HLT":  cpu.OpHLT,
-	"NOP":  cpu.OpNOP,
-	"RET":  cpu.OpRET,
-	"EI":   cpu.OpEI,
-	"DI":   cpu.OpDI,
-	"RETI": cpu.OpRETI,
-	"WFI":  cpu.OpWFI,
-}
-
-var oneRegisterOps = map[string]uint16{
-	"NOT":  cpu.OpNOT,
-	"PUSH": cpu.OpPUSH,
-	"POP":  cpu.OpPOP,
-	"LDSP": cpu.OpLDSP,
-	"STSP": cpu.OpSTSP,
-}
-
-var twoRegisterOps = map[string]uint16{
-	"MOV":  cpu.OpMOV,
-	"LD":   cpu.OpLD,
-	"ST":   cpu.OpST,
-	"ADD":  cpu.OpADD,
-	"SUB":  cpu.OpSUB,
-	"AND":  cpu.OpAND,
-	"OR":   cpu.OpOR,
-	"XOR":  cpu.OpXOR,
-	"MUL":  cpu.OpMUL,
-	"DIV":  cpu.OpDIV,
-	"IDIV": cpu.OpIDIV,
-	"SHL":  cpu.OpSHL,
-	"SHR":  cpu.OpSHR,
-	"LDB":  cpu.OpLDB,
-	"STB":  cpu.OpSTB,
-}
-
-var threeRegisterOps = map[string]uint16{
-	"FILL": cpu.OpFILL,
-	"COPY": cpu.OpCOPY,
-}
+// ErrProgramTooLarge is returned, wrapped with the line where the limit was
+// crossed, when a program does not fit in the 64 KB address space.
+var ErrProgramTooLarge = errors.New("program too large")
+
+// Mnemonic tables, one per operand form, derived from cpu.Opcodes.
+var (
+	zeroOperandOps     = opsWithForm(cpu.FormNone)
+	oneRegisterOps     = opsWithForm(cpu.FormOneRegister)
+	twoRegisterOps     = opsWithForm(cpu.FormTwoRegister)
+	threeRegisterOps   = opsWithForm(cpu.FormThreeRegister)
+	regAndImmediateOps = opsWithForm(cpu.FormRegImmediate)
+	immediateOnlyOps   = opsWithForm(cpu.FormImmediate)
+
+	// indexedOps take a register and a [Rb + imm] memory operand, register
+	// first for loads and memory first for stores, followed by the
+	// displacement word.
+	indexedOps = opsWithForm(cpu.FormIndexed)
+
+	// twoImmediateOps take two address operands, each assembled as a word
+	// after the instruction.
+	twoImmediateOps = opsWithForm(cpu.FormTwoImmediate)
+)
 
-var regAndImmediateOps = map[string]uint16{
-	"LDI": cpu.OpLDI,
+// pseudoOpLengths lists pseudo-instructions that expand into several real
+// instructions, keyed by mnemonic with their total expanded byte length.
+var pseudoOpLengths = map[string]uint16{
+	"LDI32": 8, // LDI lo, LDI hi
 }
 
-var immediateOnlyOps = map[string]uint16{
-	"JMP":  cpu.OpJMP,
-	"JZ":   cpu.OpJZ,
-	"JNZ":  cpu.OpJNZ,
-	"JN":   cpu.OpJN,
-	"JC":   cpu.OpJC,
-	"JNC":  cpu.OpJNC,
-	"CALL": cpu.OpCALL,
+// opsWithForm returns the mnemonic to opcode map of every opcode with the
+// given operand form.
+func opsWithForm(form cpu.OperandForm) map[string]uint16 {
+	ops := make(map[string]uint16)
+	for opcode, info := range cpu.Opcodes {
+		if info.Form == form {
+			ops[info.Mnemonic] = opcode
+		}
+	}
+	return ops
 }
 
 type Assembler struct {
 	labels map[string]uint16
+	// constants holds NAME .EQU value definitions. Like labels they are
+	// case-insensitive, but they occupy no memory.
+	constants map[string]uint16
+	// here is the address of the line being assembled, the value of .HERE.
+	here uint16
 }
 
 type parsedLine struct {
@@ -76,7 +68,8 @@ type parsedLine struct {
 
 func NewAssembler() *Assembler {
 	return &Assembler{
-		labels: make(map[string]uint16),
+		labels:    make(map[string]uint16),
+		constants: make(map[string]uint16),
 	}
 }
 
@@ -94,6 +87,13 @@ func (a *Assembler) Assemble(code string) ([]byte, map[uint16]int, error) {
 	return a.pass2(lines)
 }
 
+// LabelAddress returns the address assigned to label by the last Assemble
+// call. Labels are case-insensitive.
+func (a *Assembler) LabelAddress(label string) (uint16, bool) {
+	addr, ok := a.labels[normalizeLabel(label)]
+	return addr, ok
+}
+
 func (a *Assembler) pass1(lines []string) error {
 	var address uint32
 
@@ -112,6 +112,9 @@ func (a *Assembler) pass1(lines []string) error {
 			if _, exists := a.labels[key]; exists {
 				return fmt.Errorf("duplicate label '%s' on line %d", lbl, lineNo)
 			}
+			if _, exists := a.constants[key]; exists {
+				return fmt.Errorf("label '%s' on line %d redefines a constant", lbl, lineNo)
+			}
 			a.labels[key] = uint16(address)
 		}
 
@@ -119,6 +122,29 @@ func (a *Assembler) pass1(lines []string) error {
 			continue
 		}
 
+		if p.mnemonic == ".EQU" {
+			// The value may only refer to constants and labels defined on
+			// earlier lines; the constant itself can be used anywhere.
+			name := p.operands[0]
+			if !isIdentifier(name) {
+				return fmt.Errorf("invalid constant name '%s' on line %d", name, lineNo)
+			}
+			key := normalizeLabel(name)
+			if _, exists := a.constants[key]; exists {
+				return fmt.Errorf("duplicate constant '%s' on line %d", name, lineNo)
+			}
+			if _, exists := a.labels[key]; exists {
+				return fmt.Errorf("constant '%s' on line %d redefines a label", name, lineNo)
+			}
+			a.here = uint16(address)
+			val, err := a.parseImmediate(p.operands[1], lineNo)
+			if err != nil {
+				return err
+			}
+			a.constants[key] = val
+			continue
+		}
+
 		if p.mnemonic == ".STRING" {
 			if len(p.operands) != 1 {
 				return fmt.Errorf(".STRING expects exactly one string operand on line %d", lineNo)
@@ -126,7 +152,7 @@ func (a *Assembler) pass1(lines []string) error {
 			// 1 byte per character + 1 null byte
 			length := uint32(len(p.operands[0]) + 1)
 			if address+length > 65536 {
-				return fmt.Errorf("program too large near line %d", lineNo)
+				return fmt.Errorf("%w near line %d", ErrProgramTooLarge, lineNo)
 			}
 			address += length
 			continue
@@ -138,9 +164,9 @@ func (a *Assembler) pass1(lines []string) error {
 			}
 			// Each pair of characters packs into one uint16 word (2 bytes), plus null word (2 bytes).
 			runes := []rune(p.operands[0])
-			length := uint32((len(runes)/2+1)*2 + 2)
+			length := uint32((len(runes)+1)/2*2 + 2)
 			if address+length > 65536 {
-				return fmt.Errorf("program too large near line %d", lineNo)
+				return fmt.Errorf("%w near line %d", ErrProgramTooLarge, lineNo)
 			}
 			address += length
 			continue
@@ -164,12 +190,48 @@ func (a *Assembler) pass1(lines []string) error {
 			continue
 		}
 
+		if p.mnemonic == ".ALIGN" {
+			n, _, err := parseAlign(p.operands, lineNo)
+			if err != nil {
+				return err
+			}
+			aligned := (address + n - 1) &^ (n - 1)
+			if aligned > 65536 {
+				return fmt.Errorf("%w near line %d", ErrProgramTooLarge, lineNo)
+			}
+			address = aligned
+			continue
+		}
+
+		if p.mnemonic == ".SPACE" || p.mnemonic == ".RESB" {
+			n, err := parseSpace(p.mnemonic, p.operands, lineNo)
+			if err != nil {
+				return err
+			}
+			if address+n > 65536 {
+				return fmt.Errorf("%w near line %d", ErrProgramTooLarge, lineNo)
+			}
+			address += n
+			continue
+		}
+
+		if p.mnemonic == ".BYTE" {
+			if len(p.operands) == 0 {
+				return fmt.Errorf(".BYTE expects at least one operand on line %d", lineNo)
+			}
+			if address+uint32(len(p.operands)) > 65536 {
+				return fmt.Errorf("%w near line %d", ErrProgramTooLarge, lineNo)
+			}
+			address += uint32(len(p.operands))
+			continue
+		}
+
 		if p.mnemonic == ".WORD" {
 			if len(p.operands) != 1 {
 				return fmt.Errorf(".WORD expects exactly one operand on line %d", lineNo)
 			}
 			if address+2 > 65536 {
-				return fmt.Errorf("program too large near line %d", lineNo)
+				return fmt.Errorf("%w near line %d", ErrProgramTooLarge, lineNo)
 			}
 			address += 2
 			continue
@@ -181,7 +243,7 @@ func (a *Assembler) pass1(lines []string) error {
 		}
 
 		if address+uint32(length) > 65536 {
-			return fmt.Errorf("program too large near line %d", lineNo)
+			return fmt.Errorf("%w near line %d", ErrProgramTooLarge, lineNo)
 		}
 		address += uint32(length)
 	}
@@ -200,20 +262,27 @@ func (a *Assembler) pass2(lines []string) ([]byte, map[uint16]int, error) {
 			return nil, nil, err
 		}
 
-		if p.mnemonic == "" {
+		// Pass 1 must have sized every earlier line exactly as it is emitted
+		// here; a mismatch would leave labels pointing at the wrong bytes.
+		for _, lbl := range p.labels {
+			if want := a.labels[normalizeLabel(lbl)]; want != uint16(len(program)) {
+				return nil, nil, fmt.Errorf("internal error: label '%s' on line %d is at 0x%04X but pass 1 placed it at 0x%04X", lbl, lineNo, len(program), want)
+			}
+		}
+
+		if p.mnemonic == "" || p.mnemonic == ".EQU" {
 			continue
 		}
 
 		sourceMap[uint16(len(program))] = lineNo
+		a.here = uint16(len(program))
 
 		if p.mnemonic == ".STRING" {
 			if len(p.operands) != 1 {
 				return nil, nil, fmt.Errorf(".STRING expects exactly one string operand on line %d", lineNo)
 			}
-			// Emit 1 byte per character + null byte
-			for _, r := range p.operands[0] {
-				program = append(program, byte(r))
-			}
+			// Emit 1 byte per character + null byte, matching the pass-1 length
+			program = append(program, p.operands[0]...)
 			program = append(program, 0x00)
 			continue
 		}
@@ -261,6 +330,53 @@ func (a *Assembler) pass2(lines []string) ([]byte, map[uint16]int, error) {
 			continue
 		}
 
+		if mnemonic == ".ALIGN" {
+			n, nopFill, err := parseAlign(ops, lineNo)
+			if err != nil {
+				return nil, nil, err
+			}
+			if rem := uint32(len(program)) & (n - 1); rem != 0 {
+				padding := n - rem
+				if nopFill {
+					// An odd leading byte can never be executed, so it stays zero.
+					if padding%2 != 0 {
+						program = append(program, 0x00)
+						padding--
+					}
+					nop := cpu.EncodeInstruction(cpu.OpNOP, 0, 0, 0)
+					for ; padding > 0; padding -= 2 {
+						program = append(program, byte(nop&0xFF), byte(nop>>8))
+					}
+				} else {
+					program = append(program, make([]byte, padding)...)
+				}
+			}
+			continue
+		}
+
+		if mnemonic == ".SPACE" || mnemonic == ".RESB" {
+			n, err := parseSpace(mnemonic, ops, lineNo)
+			if err != nil {
+				return nil, nil, err
+			}
+			program = append(program, make([]byte, n)...)
+			continue
+		}
+
+		if mnemonic == ".BYTE" {
+			if len(ops) == 0 {
+				return nil, nil, fmt.Errorf(".BYTE expects at least one operand on line %d", lineNo)
+			}
+			for _, op := range ops {
+				val, err := a.parseImmediate(op, lineNo)
+				if err != nil {
+					return nil, nil, err
+				}
+				program = append(program, byte(val)) // values above 0xFF are truncated
+			}
+			continue
+		}
+
 		if mnemonic == ".WORD" {
 			if len(ops) != 1 {
 				return nil, nil, fmt.Errorf(".WORD expects exactly one operand on line %d", lineNo)
@@ -273,6 +389,33 @@ func (a *Assembler) pass2(lines []string) ([]byte, map[uint16]int, error) {
 			continue
 		}
 
+		if mnemonic == "LDI32" {
+			if len(ops) != 3 {
+				return nil, nil, fmt.Errorf("LDI32 expects 3 operands on line %d", lineNo)
+			}
+			regLo, err := parseRegister(ops[0], lineNo)
+			if err != nil {
+				return nil, nil, err
+			}
+			regHi, err := parseRegister(ops[1], lineNo)
+			if err != nil {
+				return nil, nil, err
+			}
+			imm, err := a.parseImmediate32(ops[2], lineNo)
+			if err != nil {
+				return nil, nil, err
+			}
+			lo := uint16(imm & 0xFFFF)
+			hi := uint16(imm >> 16)
+			instr := cpu.EncodeInstruction(cpu.OpLDI, regLo, 0, 0)
+			program = append(program, byte(instr&0xFF), byte(instr>>8))
+			program = append(program, byte(lo&0xFF), byte(lo>>8))
+			instr = cpu.EncodeInstruction(cpu.OpLDI, regHi, 0, 0)
+			program = append(program, byte(instr&0xFF), byte(instr>>8))
+			program = append(program, byte(hi&0xFF), byte(hi>>8))
+			continue
+		}
+
 		if opcode, ok := zeroOperandOps[mnemonic]; ok {
 			if len(ops) != 0 {
 				return nil, nil, fmt.Errorf("%s expects 0 operands on line %d", mnemonic, lineNo)
@@ -351,6 +494,47 @@ func (a *Assembler) pass2(lines []string) ([]byte, map[uint16]int, error) {
 			continue
 		}
 
+		if opcode, ok := indexedOps[mnemonic]; ok {
+			if len(ops) != 2 {
+				return nil, nil, fmt.Errorf("%s expects 2 operands on line %d", mnemonic, lineNo)
+			}
+			regOp, memOp := ops[0], ops[1]
+			if opcode == cpu.OpSTX {
+				regOp, memOp = ops[1], ops[0]
+			}
+			reg, err := parseRegister(regOp, lineNo)
+			if err != nil {
+				return nil, nil, err
+			}
+			base, disp, err := a.parseIndexed(memOp, lineNo)
+			if err != nil {
+				return nil, nil, err
+			}
+			instr := cpu.EncodeInstruction(opcode, reg, base, 0)
+			if opcode == cpu.OpSTX {
+				instr = cpu.EncodeInstruction(opcode, base, reg, 0)
+			}
+			program = append(program, byte(instr&0xFF), byte(instr>>8))
+			program = append(program, byte(disp&0xFF), byte(disp>>8))
+			continue
+		}
+
+		if opcode, ok := twoImmediateOps[mnemonic]; ok {
+			if len(ops) != 2 {
+				return nil, nil, fmt.Errorf("%s expects 2 operands on line %d", mnemonic, lineNo)
+			}
+			instr := cpu.EncodeInstruction(opcode, 0, 0, 0)
+			program = append(program, byte(instr&0xFF), byte(instr>>8))
+			for _, op := range ops {
+				imm, err := a.parseImmediate(op, lineNo)
+				if err != nil {
+					return nil, nil, err
+				}
+				program = append(program, byte(imm&0xFF), byte(imm>>8))
+			}
+			continue
+		}
+
 		if opcode, ok := immediateOnlyOps[mnemonic]; ok {
 			if len(ops) != 1 {
 				return nil, nil, fmt.Errorf("%s expects 1 operand on line %d", mnemonic, lineNo)
@@ -454,7 +638,7 @@ func parseLine(raw string, lineNo int) (parsedLine, error) {
 	}
 
 	line = normalizeInstructionText(line)
-	fields := strings.Fields(line)
+	fields := splitFields(line)
 	if len(fields) == 0 {
 		return p, nil
 	}
@@ -464,6 +648,15 @@ func parseLine(raw string, lineNo int) (parsedLine, error) {
 		p.operands = fields[1:]
 	}
 
+	// NAME .EQU value
+	if len(fields) > 1 && strings.EqualFold(fields[1], ".EQU") {
+		if len(fields) != 3 {
+			return p, fmt.Errorf(".EQU expects a name and one value on line %d", lineNo)
+		}
+		p.mnemonic = ".EQU"
+		p.operands = []string{fields[0], fields[2]}
+	}
+
 	if strings.EqualFold(p.mnemonic, ".ORG") {
 		p.mnemonic = ".ORG"
 		if len(p.operands) != 1 {
@@ -475,25 +668,135 @@ func parseLine(raw string, lineNo int) (parsedLine, error) {
 }
 
 func stripComments(line string) string {
-	semicolon := strings.Index(line, ";")
-	doubleSlash := strings.Index(line, "//")
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\'':
+			i = charLiteralEnd(line, i) - 1
+		case line[i] == ';':
+			return line[:i]
+		case strings.HasPrefix(line[i:], "//"):
+			return line[:i]
+		}
+	}
+	return line
+}
 
-	cut := -1
-	if semicolon >= 0 {
-		cut = semicolon
+// normalizeInstructionText turns operand separators (commas and brackets)
+// into spaces and removes whitespace around + and -, so that an expression
+// such as ".HERE - table" stays a single operand.
+func normalizeInstructionText(line string) string {
+	out := make([]byte, 0, len(line))
+	blanks := 0      // trailing whitespace copied into out
+	joining := false // just after + or -, so drop whitespace
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; c {
+		case '\'':
+			end := charLiteralEnd(line, i)
+			out = append(out, line[i:end]...)
+			i = end - 1
+			blanks, joining = 0, false
+		case ',', '[', ']':
+			out = append(out, ' ')
+			blanks, joining = 0, false
+		case ' ', '\t':
+			if !joining {
+				out = append(out, c)
+				blanks++
+			}
+		case '+', '-':
+			// Glue "a - b" into a single operand.
+			out = append(out[:len(out)-blanks], c)
+			blanks, joining = 0, true
+		default:
+			out = append(out, c)
+			blanks, joining = 0, false
+		}
 	}
-	if doubleSlash >= 0 && (cut == -1 || doubleSlash < cut) {
-		cut = doubleSlash
+	return string(out)
+}
+
+// splitFields is strings.Fields, except that whitespace inside a character
+// literal such as ' ' does not split it.
+func splitFields(line string) []string {
+	var fields []string
+	start := -1
+	for i := 0; i < len(line); i++ {
+		if line[i] == ' ' || line[i] == '\t' {
+			if start >= 0 {
+				fields = append(fields, line[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		if line[i] == '\'' {
+			i = charLiteralEnd(line, i) - 1
+		}
 	}
-	if cut >= 0 {
-		return line[:cut]
+	if start >= 0 {
+		fields = append(fields, line[start:])
 	}
-	return line
+	return fields
 }
 
-func normalizeInstructionText(line string) string {
-	replacer := strings.NewReplacer(",", " ", "[", " ", "]", " ")
-	return replacer.Replace(line)
+// charLiteralEnd returns the index just past the character literal that opens
+// at line[start], or len(line) if it is never closed.
+func charLiteralEnd(line string, start int) int {
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '\'':
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+// parseAlign validates the operands of .ALIGN: a power of two no larger than
+// the address space, optionally followed by NOP to pad with NOP instructions
+// instead of zero bytes (which decode as HLT).
+func parseAlign(ops []string, lineNo int) (uint32, bool, error) {
+	if len(ops) != 1 && len(ops) != 2 {
+		return 0, false, fmt.Errorf(".ALIGN expects an alignment and an optional NOP on line %d", lineNo)
+	}
+	nopFill := false
+	if len(ops) == 2 {
+		if !strings.EqualFold(ops[1], "NOP") {
+			return 0, false, fmt.Errorf(".ALIGN fill must be NOP on line %d: %s", lineNo, ops[1])
+		}
+		nopFill = true
+	}
+	n, err := strconv.ParseUint(ops[0], 0, 32)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid .ALIGN value on line %d: %s", lineNo, ops[0])
+	}
+	if n == 0 || n&(n-1) != 0 {
+		return 0, false, fmt.Errorf(".ALIGN value must be a power of two on line %d: %s", lineNo, ops[0])
+	}
+	if n > 0x8000 {
+		return 0, false, fmt.Errorf(".ALIGN out of range on line %d: %s", lineNo, ops[0])
+	}
+	return uint32(n), nopFill, nil
+}
+
+// parseSpace validates the operand of .SPACE (or its alias .RESB, named by
+// directive), a constant byte count. Labels are rejected because their
+// values are not known when pass 1 needs the size.
+func parseSpace(directive string, ops []string, lineNo int) (uint32, error) {
+	if len(ops) != 1 {
+		return 0, fmt.Errorf("%s expects exactly one operand on line %d", directive, lineNo)
+	}
+	n, err := strconv.ParseUint(ops[0], 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s expects a constant byte count on line %d: %s", directive, lineNo, ops[0])
+	}
+	if n > 65536 {
+		return 0, fmt.Errorf("%s out of range on line %d: %s", directive, lineNo, ops[0])
+	}
+	return uint32(n), nil
 }
 
 func parseRegister(token string, lineNo int) (uint16, error) {
@@ -520,6 +823,10 @@ func parseRegister(token string, lineNo int) (uint16, error) {
 }
 
 func (a *Assembler) parseImmediate(token string, lineNo int) (uint16, error) {
+	if strings.HasPrefix(token, "'") {
+		return parseCharLiteral(token, lineNo)
+	}
+
 	if value, err := strconv.ParseUint(token, 0, 32); err == nil {
 		if value > 0xFFFF {
 			return 0, fmt.Errorf("immediate out of range on line %d: %s", lineNo, token)
@@ -527,9 +834,25 @@ func (a *Assembler) parseImmediate(token string, lineNo int) (uint16, error) {
 		return uint16(value), nil
 	}
 
-	label := normalizeLabel(token)
-	if addr, ok := a.labels[label]; ok {
-		return addr, nil
+	if val, ok := a.symbol(token); ok {
+		return val, nil
+	}
+
+	// Sums and differences such as label+4 or .HERE-table, evaluated left
+	// to right and wrapping at 16 bits.
+	if i := strings.LastIndexAny(token, "+-"); i > 0 {
+		left, err := a.parseImmediate(token[:i], lineNo)
+		if err != nil {
+			return 0, err
+		}
+		right, err := a.parseImmediate(token[i+1:], lineNo)
+		if err != nil {
+			return 0, err
+		}
+		if token[i] == '-' {
+			return left - right, nil
+		}
+		return left + right, nil
 	}
 
 	if isIdentifier(token) {
@@ -539,6 +862,86 @@ func (a *Assembler) parseImmediate(token string, lineNo int) (uint16, error) {
 	return 0, fmt.Errorf("invalid immediate '%s' on line %d", token, lineNo)
 }
 
+// parseIndexed splits a memory operand such as R2+8 or R2-label (the brackets
+// and spaces of "[R2 + 8]" are already gone) into its base register and
+// displacement. A bare register has displacement 0.
+func (a *Assembler) parseIndexed(token string, lineNo int) (uint16, uint16, error) {
+	i := strings.IndexAny(token, "+-")
+	if i < 0 {
+		base, err := parseRegister(token, lineNo)
+		return base, 0, err
+	}
+	base, err := parseRegister(token[:i], lineNo)
+	if err != nil {
+		return 0, 0, err
+	}
+	disp, err := a.parseImmediate("0"+token[i:], lineNo)
+	if err != nil {
+		return 0, 0, err
+	}
+	return base, disp, nil
+}
+
+// symbol resolves .HERE, a label or, failing that, an .EQU constant.
+func (a *Assembler) symbol(name string) (uint16, bool) {
+	if strings.EqualFold(name, ".HERE") {
+		return a.here, true
+	}
+	key := normalizeLabel(name)
+	if addr, ok := a.labels[key]; ok {
+		return addr, true
+	}
+	val, ok := a.constants[key]
+	return val, ok
+}
+
+// parseCharLiteral returns the byte value of a single-quoted character such as
+// 'A' or '\n'.
+func parseCharLiteral(token string, lineNo int) (uint16, error) {
+	if len(token) < 2 || !strings.HasSuffix(token, "'") {
+		return 0, fmt.Errorf("unterminated character literal %s on line %d", token, lineNo)
+	}
+	body := token[1 : len(token)-1]
+	if body == "" {
+		return 0, fmt.Errorf("empty character literal on line %d", lineNo)
+	}
+	if body[0] == '\\' {
+		if len(body) == 2 {
+			switch body[1] {
+			case 'n':
+				return '\n', nil
+			case 't':
+				return '\t', nil
+			case '0':
+				return 0, nil
+			case '\\', '\'':
+				return uint16(body[1]), nil
+			}
+		}
+		return 0, fmt.Errorf("invalid escape in character literal %s on line %d", token, lineNo)
+	}
+	if len(body) != 1 {
+		return 0, fmt.Errorf("character literal %s must contain exactly one character on line %d", token, lineNo)
+	}
+	return uint16(body[0]), nil
+}
+
+// parseImmediate32 parses a 32-bit literal or a label address (zero-extended).
+func (a *Assembler) parseImmediate32(token string, lineNo int) (uint32, error) {
+	if value, err := strconv.ParseUint(token, 0, 64); err == nil {
+		if value > 0xFFFFFFFF {
+			return 0, fmt.Errorf("immediate out of range on line %d: %s", lineNo, token)
+		}
+		return uint32(value), nil
+	}
+
+	val, err := a.parseImmediate(token, lineNo)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(val), nil
+}
+
 // instructionLength returns the byte length of an instruction.
 // All instructions are 2 bytes; instructions with an immediate are 4 bytes.
 func instructionLength(mnemonic string) (uint16, bool) {
@@ -562,6 +965,15 @@ func instructionLength(mnemonic string) (uint16, bool) {
 	if _, ok := immediateOnlyOps[mnemonic]; ok {
 		return 4, true
 	}
+	if _, ok := indexedOps[mnemonic]; ok {
+		return 4, true
+	}
+	if _, ok := twoImmediateOps[mnemonic]; ok {
+		return 6, true
+	}
+	if length, ok := pseudoOpLengths[mnemonic]; ok {
+		return length, true
+	}
 	return 0, false
 }
 
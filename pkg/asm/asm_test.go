@@ -1,8 +1,10 @@
 package asm
 
 import (
+	"errors"
 	"gocpu/pkg/cpu"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -51,6 +53,7 @@ func TestHelperFunctions(t *testing.T) {
 		{"NOP", 2, true},
 		{"LDI", 4, true},
 		{"JMP", 4, true},
+		{"LDI32", 8, true},
 		{"INVALID", 0, false},
 	}
 	for _, tc := range lenTests {
@@ -102,6 +105,11 @@ func TestParseLine(t *testing.T) {
 			parsedLine{lineNo: 1, mnemonic: ".PSTRING", operands: []string{"hi"}},
 			false,
 		},
+		{
+			".WORD .HERE - table + 2",
+			parsedLine{lineNo: 1, mnemonic: ".WORD", operands: []string{".HERE-table+2"}},
+			false,
+		},
 		// Invalid cases
 		{
 			"1LABEL: NOP",
@@ -186,6 +194,44 @@ func TestAssemble(t *testing.T) {
 			),
 			false,
 		},
+		{
+			"JMPR",
+			`
+			JMPR R2
+			`,
+			encodeWords(cpu.EncodeInstruction(cpu.OpJMPR, cpu.RegC, 0, 0)),
+			false,
+		},
+		{
+			"Saturating Arithmetic",
+			`
+			ADDS R0, R1
+			SUBS R1, R2
+			ADDUS R2, R3
+			SUBUS R3, R0
+			`,
+			encodeWords(
+				cpu.EncodeInstruction(cpu.OpADDS, cpu.RegA, cpu.RegB, 0),
+				cpu.EncodeInstruction(cpu.OpSUBS, cpu.RegB, cpu.RegC, 0),
+				cpu.EncodeInstruction(cpu.OpADDUS, cpu.RegC, cpu.RegD, 0),
+				cpu.EncodeInstruction(cpu.OpSUBUS, cpu.RegD, cpu.RegA, 0),
+			),
+			false,
+		},
+		{
+			"Byte ALU",
+			`
+			ADDB R0, R1
+			SUBB R1, R2
+			ANDB R2, R3
+			`,
+			encodeWords(
+				cpu.EncodeInstruction(cpu.OpADDB, cpu.RegA, cpu.RegB, 0),
+				cpu.EncodeInstruction(cpu.OpSUBB, cpu.RegB, cpu.RegC, 0),
+				cpu.EncodeInstruction(cpu.OpANDB, cpu.RegC, cpu.RegD, 0),
+			),
+			false,
+		},
 		{
 			".ORG",
 			`
@@ -393,6 +439,484 @@ func TestAssemble(t *testing.T) {
 			),
 			false,
 		},
+		{
+			"LDI32 expansion",
+			`
+			LDI32 R0, R1, 0x12345678
+			`,
+			encodeWords(
+				cpu.EncodeInstruction(cpu.OpLDI, cpu.RegA, 0, 0), 0x5678,
+				cpu.EncodeInstruction(cpu.OpLDI, cpu.RegB, 0, 0), 0x1234,
+			),
+			false,
+		},
+		{
+			"Char Literal Immediate",
+			`LDI R0, 'A'`,
+			encodeWords(cpu.EncodeInstruction(cpu.OpLDI, cpu.RegA, 0, 0), 65),
+			false,
+		},
+		{
+			"Escaped Char Literal",
+			`LDI R1, '\n' ; newline`,
+			encodeWords(cpu.EncodeInstruction(cpu.OpLDI, cpu.RegB, 0, 0), 10),
+			false,
+		},
+		{
+			"Char Literal Punctuation",
+			`
+			.WORD 'Z'
+			.WORD ' '
+			.WORD ','
+			.WORD ';'
+			.WORD '\''
+			.WORD '\0'
+			`,
+			encodeWords('Z', ' ', ',', ';', '\'', 0),
+			false,
+		},
+		{
+			"Empty Char Literal",
+			`LDI R0, ''`,
+			nil,
+			true,
+		},
+		{
+			"Multi Char Literal",
+			`LDI R0, 'AB'`,
+			nil,
+			true,
+		},
+		{
+			"Label Plus Offset",
+			`
+			LDI R0, TABLE+4
+			JMP table-2
+			TABLE:
+			.WORD 1
+			`,
+			encodeWords(
+				cpu.EncodeInstruction(cpu.OpLDI, cpu.RegA, 0, 0), 12,
+				cpu.EncodeInstruction(cpu.OpJMP, 0, 0, 0), 6,
+				1,
+			),
+			false,
+		},
+		{
+			"Label Offset Wraps",
+			`
+			START:
+			.WORD START-1
+			.WORD END+0x10
+			.ORG 0xFFFE
+			END:
+			`,
+			func() []byte {
+				b := make([]byte, 0xFFFE)
+				copy(b, encodeWords(0xFFFF, 0x000E))
+				return b
+			}(),
+			false,
+		},
+		{
+			"Undefined Label Plus Offset",
+			`LDI R0, missing+4`,
+			nil,
+			true,
+		},
+		{
+			"Binary Literals",
+			`
+			.WORD 0b1111
+			LDI R0, 0B10110100
+			`,
+			encodeWords(15, cpu.EncodeInstruction(cpu.OpLDI, cpu.RegA, 0, 0), 0xB4),
+			false,
+		},
+		{
+			"Binary Literal Out Of Range",
+			`.WORD 0b11111111111111111`,
+			nil,
+			true,
+		},
+		{
+			"Align From Odd Address",
+			`
+			.STRING ""
+			.ALIGN 2
+			DATA:
+			.WORD DATA
+			`,
+			[]byte{0x00, 0x00, 0x02, 0x00},
+			false,
+		},
+		{
+			"Align To 16",
+			`
+			.STRING "abcd"
+			.ALIGN 16
+			PAGE:
+			.WORD PAGE
+			.ALIGN 16
+			`,
+			append(append(append([]byte("abcd\x00"), make([]byte, 11)...), 0x10, 0x00), make([]byte, 14)...),
+			false,
+		},
+		{
+			"Align Already Aligned",
+			`
+			HLT
+			.ALIGN 2
+			HLT
+			`,
+			encodeWords(cpu.EncodeInstruction(cpu.OpHLT, 0, 0, 0), cpu.EncodeInstruction(cpu.OpHLT, 0, 0, 0)),
+			false,
+		},
+		{
+			"Align Zero",
+			`.ALIGN 0`,
+			nil,
+			true,
+		},
+		{
+			"Align Not Power Of Two",
+			`.ALIGN 6`,
+			nil,
+			true,
+		},
+		{
+			"Align Past End Of Memory",
+			`
+			.ORG 0xFFFF
+			.ALIGN 0x8000
+			HLT
+			`,
+			nil,
+			true,
+		},
+		{
+			"Align With NOP Fill",
+			`
+			HLT
+			.ALIGN 8, NOP
+			HLT
+			`,
+			encodeWords(
+				cpu.EncodeInstruction(cpu.OpHLT, 0, 0, 0),
+				cpu.EncodeInstruction(cpu.OpNOP, 0, 0, 0),
+				cpu.EncodeInstruction(cpu.OpNOP, 0, 0, 0),
+				cpu.EncodeInstruction(cpu.OpNOP, 0, 0, 0),
+				cpu.EncodeInstruction(cpu.OpHLT, 0, 0, 0),
+			),
+			false,
+		},
+		{
+			"Align NOP Fill From Odd Address",
+			`
+			.BYTE 7
+			.ALIGN 4, nop
+			`,
+			append([]byte{7, 0}, encodeWords(cpu.EncodeInstruction(cpu.OpNOP, 0, 0, 0))...),
+			false,
+		},
+		{
+			"Align Bad Fill",
+			`.ALIGN 4, HLT`,
+			nil,
+			true,
+		},
+		{
+			"Even PSTRING Then Label",
+			`
+			.PSTRING "ab"
+			END:
+			.WORD END
+			`,
+			[]byte{'a', 'b', 0, 0, 0x04, 0x00},
+			false,
+		},
+		{
+			"Org Align Word",
+			`
+			.ORG 0x10
+			.BYTE 1
+			.ALIGN 8
+			A:
+			.WORD A
+			.ORG 0x20
+			B:
+			.WORD B
+			`,
+			append(append(append(make([]byte, 0x10), 1), make([]byte, 7)...),
+				append([]byte{0x18, 0x00}, append(make([]byte, 6), 0x20, 0x00)...)...),
+			false,
+		},
+		{
+			"Flags Transfer",
+			`
+			LDF R3
+			STF R3
+			`,
+			encodeWords(
+				cpu.EncodeInstruction(cpu.OpLDF, cpu.RegD, 0, 0),
+				cpu.EncodeInstruction(cpu.OpSTF, cpu.RegD, 0, 0),
+			),
+			false,
+		},
+		{
+			"Single Byte",
+			`
+			.BYTE 'A'
+			NEXT:
+			.BYTE NEXT
+			`,
+			[]byte{'A', 0x01},
+			false,
+		},
+		{
+			"Byte List",
+			`
+			.BYTE 1, 2, 0x03, ','
+			.WORD 0x0504
+			`,
+			[]byte{1, 2, 3, ',', 0x04, 0x05},
+			false,
+		},
+		{
+			"Byte Truncated To Low 8 Bits",
+			`.BYTE 0x1FF, 256`,
+			[]byte{0xFF, 0x00},
+			false,
+		},
+		{
+			"Byte Without Operand",
+			`.BYTE`,
+			nil,
+			true,
+		},
+		{
+			"Space Reserves Zero Bytes",
+			`
+			.BYTE 0xFF
+			BUF:
+			.SPACE 10
+			AFTER:
+			.WORD AFTER
+			.WORD BUF
+			`,
+			append(append([]byte{0xFF}, make([]byte, 10)...), 0x0B, 0x00, 0x01, 0x00),
+			false,
+		},
+		{
+			"Resb Is Space",
+			`
+			.BYTE 0xFF
+			BUF:
+			.resb 3
+			.WORD BUF
+			`,
+			[]byte{0xFF, 0, 0, 0, 0x01, 0x00},
+			false,
+		},
+		{
+			"Space Rejects Labels",
+			`
+			N:
+			.SPACE N
+			`,
+			nil,
+			true,
+		},
+		{
+			"Space Past End Of Memory",
+			`
+			HLT
+			.SPACE 0xFFFF
+			`,
+			nil,
+			true,
+		},
+		{
+			"Swap",
+			`SWAP R0, R1`,
+			encodeWords(cpu.EncodeInstruction(cpu.OpSWAP, cpu.RegA, cpu.RegB, 0)),
+			false,
+		},
+		{
+			"Compare",
+			`CMP R2, R5`,
+			encodeWords(cpu.EncodeInstruction(cpu.OpCMP, cpu.RegC, 5, 0)),
+			false,
+		},
+		{
+			"Rotates",
+			`
+			ROL R0, R1
+			ror r3, r2
+			`,
+			encodeWords(
+				cpu.EncodeInstruction(cpu.OpROL, cpu.RegA, cpu.RegB, 0),
+				cpu.EncodeInstruction(cpu.OpROR, cpu.RegD, cpu.RegC, 0),
+			),
+			false,
+		},
+		{
+			"Carry Arithmetic",
+			`
+			ADC R0, R1
+			SBC R2, R3
+			`,
+			encodeWords(
+				cpu.EncodeInstruction(cpu.OpADC, cpu.RegA, cpu.RegB, 0),
+				cpu.EncodeInstruction(cpu.OpSBC, cpu.RegC, cpu.RegD, 0),
+			),
+			false,
+		},
+		{
+			"Overflow Branches",
+			`
+			start:
+			JV start
+			jnv 0x1234
+			`,
+			encodeWords(
+				cpu.EncodeInstruction(cpu.OpJV, 0, 0, 0), 0x0000,
+				cpu.EncodeInstruction(cpu.OpJNV, 0, 0, 0), 0x1234,
+			),
+			false,
+		},
+		{
+			"Indexed Load Store",
+			`
+			OFF .EQU 4
+			LDX R1, [R2 + 8]
+			ldx r0, [r3]
+			STX [R6 - 2], R5
+			STX [R1+OFF+1], R0
+			`,
+			encodeWords(
+				cpu.EncodeInstruction(cpu.OpLDX, cpu.RegB, cpu.RegC, 0), 8,
+				cpu.EncodeInstruction(cpu.OpLDX, cpu.RegA, cpu.RegD, 0), 0,
+				cpu.EncodeInstruction(cpu.OpSTX, 6, 5, 0), 0xFFFE,
+				cpu.EncodeInstruction(cpu.OpSTX, cpu.RegB, cpu.RegA, 0), 5,
+			),
+			false,
+		},
+		{
+			"Memory Move",
+			`
+			MOVM dst, 0x2000
+			dst: .WORD 0
+			`,
+			encodeWords(cpu.EncodeInstruction(cpu.OpMOVM, 0, 0, 0), 0x0006, 0x2000, 0),
+			false,
+		},
+		{
+			"Memory Move Missing Operand",
+			"MOVM 0x1000",
+			nil,
+			true,
+		},
+		{
+			"Indexed Bad Base",
+			"LDX R1, [8 + R2]",
+			nil,
+			true,
+		},
+		{
+			"EQU Constants",
+			`
+			PORT .EQU 0xFF00
+			LDI R0, port
+			.WORD PORT+1
+			.WORD LATE
+			LATE .EQU 'Z'
+			`,
+			encodeWords(
+				cpu.EncodeInstruction(cpu.OpLDI, cpu.RegA, 0, 0), 0xFF00,
+				0xFF01,
+				'Z',
+			),
+			false,
+		},
+		{
+			"EQU From Earlier Constant And Label",
+			`
+			START:
+			BASE .EQU START
+			NEXT .EQU BASE+2
+			.WORD NEXT
+			`,
+			encodeWords(2),
+			false,
+		},
+		{
+			"EQU Redefinition",
+			`
+			X .EQU 1
+			X .EQU 2
+			`,
+			nil,
+			true,
+		},
+		{
+			"EQU Clashes With Label",
+			`
+			X:
+			HLT
+			x .EQU 2
+			`,
+			nil,
+			true,
+		},
+		{
+			"EQU Value Forward Reference",
+			`
+			A .EQU B
+			B .EQU 1
+			`,
+			nil,
+			true,
+		},
+		{
+			"HERE Current Address",
+			`
+			NOP
+			.WORD .HERE
+			LDI R0, .here+2
+			`,
+			encodeWords(
+				cpu.EncodeInstruction(cpu.OpNOP, 0, 0, 0),
+				2,
+				cpu.EncodeInstruction(cpu.OpLDI, cpu.RegA, 0, 0), 6,
+			),
+			false,
+		},
+		{
+			"HERE Minus Label",
+			`
+			table:
+			.BYTE 1, 2, 3, 4, 5, 6
+			tablelen: .WORD .HERE - table
+			SIZE .EQU .HERE-table
+			.WORD SIZE
+			.WORD tablelen- table
+			`,
+			append([]byte{1, 2, 3, 4, 5, 6}, encodeWords(6, 8, 6)...),
+			false,
+		},
+		{
+			"LDI32 Invalid Operand Count",
+			`LDI32 R0, 0x12345678`,
+			nil,
+			true,
+		},
+		{
+			"LDI32 Out Of Range",
+			`LDI32 R0, R1, 0x100000000`,
+			nil,
+			true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -427,3 +951,44 @@ func TestStripComments(t *testing.T) {
 		}
 	}
 }
+
+func TestLDI32_Run(t *testing.T) {
+	code := `
+	LDI32 R0, R1, 0x12345678
+	HLT
+	`
+	program, _, err := Assemble(code)
+	if err != nil {
+		t.Fatalf("Assemble() error = %v", err)
+	}
+
+	vm := cpu.NewCPU()
+	copy(vm.Memory[:], program)
+	vm.Run()
+
+	if vm.Regs[cpu.RegA] != 0x5678 {
+		t.Errorf("R0 = 0x%04X, want 0x5678", vm.Regs[cpu.RegA])
+	}
+	if vm.Regs[cpu.RegB] != 0x1234 {
+		t.Errorf("R1 = 0x%04X, want 0x1234", vm.Regs[cpu.RegB])
+	}
+	// LDI32 expands to two 4-byte LDIs, so HLT sits at byte 8.
+	if vm.PC != 10 {
+		t.Errorf("PC = %d, want 10 (HLT at byte 8)", vm.PC)
+	}
+}
+
+func TestAssemble_ProgramTooLarge(t *testing.T) {
+	code := `
+	NOP
+	.SPACE 0x8000
+	.SPACE 0x8000
+	`
+	_, _, err := Assemble(code)
+	if !errors.Is(err, ErrProgramTooLarge) {
+		t.Fatalf("Assemble() error = %v, want ErrProgramTooLarge", err)
+	}
+	if !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("error %q does not name line 4", err)
+	}
+}
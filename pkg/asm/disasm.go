@@ -0,0 +1,27 @@
+package asm
+
+import (
+	"fmt"
+	"strings"
+
+	"gocpu/pkg/cpu"
+)
+
+// Disassemble decodes a program image back into assembly text, one
+// instruction per line with its address in a trailing comment. Words that
+// are not valid instructions (unknown opcodes, stray operand bits, data) are
+// emitted as `.WORD 0xXXXX`, so assembling the output reproduces code
+// byte for byte, apart from a trailing odd byte, which is padded to a word.
+func Disassemble(code []byte) (string, error) {
+	if len(code) > 0x10000 {
+		return "", fmt.Errorf("image of %d bytes does not fit in memory", len(code))
+	}
+
+	var sb strings.Builder
+	for pc := 0; pc < len(code); {
+		text, size := cpu.DisassembleAt(code, pc)
+		fmt.Fprintf(&sb, "    %-24s ; %04X\n", text, pc)
+		pc += size
+	}
+	return sb.String(), nil
+}
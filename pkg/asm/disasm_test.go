@@ -0,0 +1,177 @@
+package asm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gocpu/pkg/cpu"
+)
+
+func TestDisassemble_RoundTrip(t *testing.T) {
+	src := `
+    LDI R0, 5
+    LDI R1, data
+loop:
+    ADD R0, R1
+    LD R2, [R1]
+    LDX R3, [R1 + 6]
+    STX [R4 - 2], R0
+    MOVM data, 0x4000
+    STB [R1], R2
+    FILL R1, R2, R3
+    PUSH R0
+    POP R7
+    JNZ loop
+    CALL fn
+    HLT
+fn:
+    RET
+data:
+    .WORD 0xFFFF
+    .WORD 1234
+`
+	code, _, err := Assemble(src)
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+	text, err := Disassemble(code)
+	if err != nil {
+		t.Fatalf("Disassemble failed: %v", err)
+	}
+	for _, want := range []string{
+		"LDI R0, 0x0005",
+		"ADD R0, R1",
+		"LD R2, [R1]",
+		"LDX R3, [R1 + 0x0006]",
+		"STX [R4 + 0xFFFE], R0",
+		"MOVM 0x002E, 0x4000",
+		"STB [R1], R2",
+		"FILL R1, R2, R3",
+		"POP R7",
+		"JNZ 0x0008",
+		"RET",
+		".WORD 0xFFFF",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("disassembly missing %q:\n%s", want, text)
+		}
+	}
+
+	again, _, err := Assemble(text)
+	if err != nil {
+		t.Fatalf("reassembling disassembly failed: %v\n%s", err, text)
+	}
+	if !bytes.Equal(again, code) {
+		t.Errorf("round trip mismatch:\n got % X\nwant % X\n%s", again, code, text)
+	}
+}
+
+func TestDisassemble_InvalidWords(t *testing.T) {
+	code := []byte{
+		0xFF, 0xFF, // unknown opcode 0x3F
+		0x01, 0x04, // NOP with a stray bit set
+		0x00, 0x38, // JMP with its immediate cut off
+	}
+	text, err := Disassemble(code)
+	if err != nil {
+		t.Fatalf("Disassemble failed: %v", err)
+	}
+	for _, want := range []string{".WORD 0xFFFF", ".WORD 0x0401", ".WORD 0x3800"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("disassembly missing %q:\n%s", want, text)
+		}
+	}
+	again, _, err := Assemble(text)
+	if err != nil || !bytes.Equal(again, code) {
+		t.Errorf("round trip mismatch: % X (err %v)", again, err)
+	}
+}
+
+// TestDisassemble_DirectiveLayout checks that labels placed after .ORG,
+// .ALIGN, .WORD and .PSTRING land where the disassembler finds the code.
+func TestDisassemble_DirectiveLayout(t *testing.T) {
+	src := `
+    JMP main
+.ORG 0x10
+    .STRING "abc"
+    .ALIGN 8
+table:
+    .WORD main
+    .PSTRING "ab"
+    .ALIGN 8, NOP
+main:
+    LDI R0, table
+    HLT
+`
+	a := NewAssembler()
+	code, _, err := a.Assemble(src)
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+	if len(code) != 0x26 {
+		t.Errorf("image is %d bytes, expected %d", len(code), 0x26)
+	}
+	for label, want := range map[string]uint16{"table": 0x18, "main": 0x20} {
+		if got, _ := a.LabelAddress(label); got != want {
+			t.Errorf("%s = 0x%04X, expected 0x%04X", label, got, want)
+		}
+	}
+
+	text, err := Disassemble(code)
+	if err != nil {
+		t.Fatalf("Disassemble failed: %v", err)
+	}
+	for addr, want := range map[int]string{
+		0x0000: "JMP 0x0020",
+		0x0018: ".WORD 0x0020",
+		0x001E: "NOP",
+		0x0020: "LDI R0, 0x0018",
+		0x0024: "HLT",
+	} {
+		line := fmt.Sprintf("%-24s ; %04X", want, addr)
+		if !strings.Contains(text, line) {
+			t.Errorf("disassembly missing %q:\n%s", line, text)
+		}
+	}
+}
+
+// TestCoverageReport_Reassembles checks that the coverage report, which
+// shares the disassembler, prints syntax the assembler accepts.
+func TestCoverageReport_Reassembles(t *testing.T) {
+	src := `
+    LDI R0, 0x2000
+    LDI R1, 7
+    ST [R0], R1
+    LD R2, [R0]
+    STB [R0], R1
+    LDX R3, [R0 + 2]
+    HLT
+`
+	code, _, err := Assemble(src)
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+	res, err := cpu.RunProgram(code, cpu.Options{Coverage: true})
+	if err != nil {
+		t.Fatalf("RunProgram failed: %v", err)
+	}
+	for _, want := range []string{"ST [R0], R1", "LD R2, [R0]", "STB [R0], R1"} {
+		if !strings.Contains(res.Coverage, want) {
+			t.Errorf("coverage report missing %q:\n%s", want, res.Coverage)
+		}
+	}
+
+	// Strip the marks, addresses and summary, and assemble what is left.
+	var lines []string
+	for _, line := range strings.Split(res.Coverage, "\n") {
+		if len(line) > 8 && !strings.HasPrefix(line, "coverage:") {
+			lines = append(lines, line[8:])
+		}
+	}
+	again, _, err := Assemble(strings.Join(lines, "\n"))
+	if err != nil || !bytes.Equal(again, code) {
+		t.Errorf("reassembled report = % X (err %v), want % X", again, err, code)
+	}
+}
@@ -0,0 +1,85 @@
+package compiler
+
+import "testing"
+
+const alignSrc = `
+struct Rec { char tag; int value; };
+int main() {
+	struct Rec r;
+	r.tag = 7;
+	r.value = 1000;
+	return r.tag + r.value + sizeof(struct Rec);
+}
+`
+
+func TestAlignStructs_Layout(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        Options
+		valueOffset int
+		size        int
+	}{
+		{"packed", Options{}, 1, 3},
+		{"aligned", Options{AlignStructs: true}, 2, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Lex(alignSrc)
+			if err != nil {
+				t.Fatalf("Lex failed: %v", err)
+			}
+			stmts, err := Parse(tokens, alignSrc)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			syms := NewSymbolTable()
+			if _, err := GenerateWithOptions(stmts, syms, tt.opts); err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			def, ok := syms.GetStruct("Rec")
+			if !ok {
+				t.Fatal("GetStruct(Rec) not found")
+			}
+			if got := def.Fields["tag"].Offset; got != 0 {
+				t.Errorf("tag offset = %d, want 0", got)
+			}
+			if got := def.Fields["value"].Offset; got != tt.valueOffset {
+				t.Errorf("value offset = %d, want %d", got, tt.valueOffset)
+			}
+			if def.Size != tt.size {
+				t.Errorf("size = %d, want %d", def.Size, tt.size)
+			}
+
+			if got := runWith(t, alignSrc, tt.opts); got != uint16(1007+tt.size) {
+				t.Errorf("main() = %d, want %d", got, 1007+tt.size)
+			}
+		})
+	}
+}
+
+func TestAlignStructs_CharOnly(t *testing.T) {
+	src := `struct Pair { char a; char b; };
+	struct Outer { char c; struct Pair p; char *s; };
+	int main() { return 0; }`
+	tokens, err := Lex(src)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, src)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	syms := NewSymbolTable()
+	if _, err := GenerateWithOptions(stmts, syms, Options{AlignStructs: true}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	pair, _ := syms.GetStruct("Pair")
+	if pair.Size != 2 || pair.Fields["b"].Offset != 1 {
+		t.Errorf("Pair: size %d, b at %d; want 2 and 1 (chars stay packed)", pair.Size, pair.Fields["b"].Offset)
+	}
+	outer, _ := syms.GetStruct("Outer")
+	if outer.Fields["p"].Offset != 2 || outer.Fields["s"].Offset != 4 || outer.Size != 6 {
+		t.Errorf("Outer: p at %d, s at %d, size %d; want 2, 4, 6",
+			outer.Fields["p"].Offset, outer.Fields["s"].Offset, outer.Size)
+	}
+}
@@ -86,6 +86,19 @@ func (l *LogicalExpr) String() string {
 	return fmt.Sprintf("(%s %s %s)", l.Left, l.Op, l.Right)
 }
 
+// TernaryExpr represents Cond ? Then : Else.
+// Only the selected arm is evaluated.
+type TernaryExpr struct {
+	Cond Expr
+	Then Expr
+	Else Expr
+}
+
+func (*TernaryExpr) exprNode() {}
+func (t *TernaryExpr) String() string {
+	return fmt.Sprintf("(%s ? %s : %s)", t.Cond, t.Then, t.Else)
+}
+
 // UnaryExpr represents Op Right (e.g., &x, *p).
 type UnaryExpr struct {
 	Op    TokenType
@@ -95,19 +108,28 @@ type UnaryExpr struct {
 func (*UnaryExpr) exprNode()        {}
 func (u *UnaryExpr) String() string { return fmt.Sprintf("(%s %s)", u.Op, u.Right) }
 
-// PostfixExpr represents Left++ or Left--
+// PostfixExpr represents Left++ or Left--, or ++Left / --Left when Prefix is
+// set. The prefix form evaluates to the updated value, the postfix form to the
+// original one.
 type PostfixExpr struct {
-	Op   TokenType
-	Left Expr
+	Op     TokenType
+	Left   Expr
+	Prefix bool
 }
 
-func (*PostfixExpr) exprNode()        {}
-func (p *PostfixExpr) String() string { return fmt.Sprintf("(%s %s)", p.Left, p.Op) }
+func (*PostfixExpr) exprNode() {}
+func (p *PostfixExpr) String() string {
+	if p.Prefix {
+		return fmt.Sprintf("(%s %s)", p.Op, p.Left)
+	}
+	return fmt.Sprintf("(%s %s)", p.Left, p.Op)
+}
 
 // FunctionCall represents name(args)
 type FunctionCall struct {
 	Name string
 	Args []Expr
+	Line int // source line of the call, 0 if unknown
 }
 
 func (*FunctionCall) exprNode() {}
@@ -120,12 +142,16 @@ type CastExpr struct {
 	Type         TokenType // INT, CHAR, STRUCT
 	StructName   string    // if Type == STRUCT
 	PointerLevel int       // 0 for scalar, 1 for *, 2 for **, etc.
+	IsUnsigned   bool      // cast to unsigned (or an unsigned typedef)
 	Expr         Expr
 }
 
 func (*CastExpr) exprNode() {}
 func (c *CastExpr) String() string {
 	typeStr := c.Type.String()
+	if c.IsUnsigned {
+		typeStr = "unsigned " + typeStr
+	}
 	if c.Type == STRUCT {
 		typeStr += " " + c.StructName
 	}
@@ -135,6 +161,34 @@ func (c *CastExpr) String() string {
 	return fmt.Sprintf("Cast(%s, %s)", typeStr, c.Expr)
 }
 
+// SizeofExpr represents sizeof(type) or sizeof expr. It is folded to a
+// constant at code generation time; Expr is never evaluated.
+type SizeofExpr struct {
+	Type         TokenType // INT, CHAR, STRUCT; 0 when Expr is set
+	StructName   string    // if Type == STRUCT
+	PointerLevel int
+	IsUnsigned   bool // sizeof(unsigned ...)
+	Expr         Expr // non-nil for sizeof expr
+}
+
+func (*SizeofExpr) exprNode() {}
+func (s *SizeofExpr) String() string {
+	if s.Expr != nil {
+		return fmt.Sprintf("sizeof(%s)", s.Expr)
+	}
+	typeStr := s.Type.String()
+	if s.IsUnsigned {
+		typeStr = "unsigned " + typeStr
+	}
+	if s.Type == STRUCT {
+		typeStr += " " + s.StructName
+	}
+	for i := 0; i < s.PointerLevel; i++ {
+		typeStr += "*"
+	}
+	return fmt.Sprintf("sizeof(%s)", typeStr)
+}
+
 // IndexExpr represents Left[Index]
 type IndexExpr struct {
 	Left    Expr
@@ -172,6 +226,7 @@ type VariableDecl struct {
 	IsChar       bool
 	PointerLevel int // 0 for scalar, 1 for *, 2 for **, etc.
 	IsUnsigned   bool
+	Line         int // source line of the declaration, 0 if unknown
 }
 
 func (*VariableDecl) stmtNode() {}
@@ -193,15 +248,54 @@ func (d *VariableDecl) String() string {
 	return fmt.Sprintf("VariableDecl(%s %s = %s)", typeStr, d.Name, d.Init)
 }
 
-// StructDecl represents struct Name { int field1; ... }
+// StructDecl represents struct Name { int field1; ... } and, with IsUnion
+// set, union Name { ... } whose fields all start at offset 0.
 type StructDecl struct {
-	Name   string
-	Fields []VariableDecl // Init is nil
+	Name    string
+	Fields  []VariableDecl // Init is nil
+	IsUnion bool
+	// Inline holds the anonymous struct types declared in field position,
+	// e.g. struct { int x; } pos; They get synthetic names "Name.anonN".
+	Inline []*StructDecl
 }
 
 func (*StructDecl) stmtNode() {}
 func (s *StructDecl) String() string {
-	return fmt.Sprintf("StructDecl(struct %s, fields=%v)", s.Name, s.Fields)
+	kind := "struct"
+	if s.IsUnion {
+		kind = "union"
+	}
+	return fmt.Sprintf("StructDecl(%s %s, fields=%v)", kind, s.Name, s.Fields)
+}
+
+// Enumerator is one named constant of an enum with its resolved value.
+type Enumerator struct {
+	Name  string
+	Value uint16
+}
+
+// EnumDecl represents enum Name { A, B = 5, C };
+// Values are resolved by the parser; Name is empty for an anonymous enum.
+type EnumDecl struct {
+	Name    string
+	Members []Enumerator
+}
+
+func (*EnumDecl) stmtNode() {}
+func (s *EnumDecl) String() string {
+	return fmt.Sprintf("EnumDecl(enum %s, members=%v)", s.Name, s.Members)
+}
+
+// TypedefDecl represents typedef <type> Name; Type is the resolved
+// underlying type, which the parser substitutes wherever Name is used.
+type TypedefDecl struct {
+	Name string
+	Type TypeInfo
+}
+
+func (*TypedefDecl) stmtNode() {}
+func (s *TypedefDecl) String() string {
+	return fmt.Sprintf("TypedefDecl(%s, %+v)", s.Name, s.Type)
 }
 
 // Assignment represents  Left = Value;
@@ -209,6 +303,7 @@ type Assignment struct {
 	Left  Expr
 	Op    TokenType
 	Value Expr
+	Line  int // source line of the operator, 0 if unknown
 }
 
 func (*Assignment) stmtNode() {}
@@ -262,6 +357,17 @@ func (w *WhileStmt) String() string {
 	return fmt.Sprintf("WhileStmt(while %s do %s)", w.Condition, w.Body)
 }
 
+// DoWhileStmt represents do body while (cond); the body always runs at least once.
+type DoWhileStmt struct {
+	Body      Stmt
+	Condition Expr
+}
+
+func (*DoWhileStmt) stmtNode() {}
+func (d *DoWhileStmt) String() string {
+	return fmt.Sprintf("DoWhileStmt(do %s while %s)", d.Body, d.Condition)
+}
+
 // ForStmt represents for (init; cond; post) body
 type ForStmt struct {
 	Init Stmt
@@ -275,7 +381,8 @@ func (f *ForStmt) String() string {
 	return fmt.Sprintf("ForStmt(init=%s, cond=%s, post=%s, body=%s)", f.Init, f.Cond, f.Post, f.Body)
 }
 
-// FunctionDecl represents int name(params) { body }
+// FunctionDecl represents int name(params) { body }, or a prototype
+// int name(params); when Body is nil.
 type FunctionDecl struct {
 	Name       string
 	Params     []VariableDecl
@@ -291,6 +398,7 @@ func (f *FunctionDecl) String() string {
 // ExprStmt represents an expression evaluated for its side effects (e.g. a function call).
 type ExprStmt struct {
 	Expr Expr
+	Line int // source line of the statement, 0 if unknown
 }
 
 func (*ExprStmt) stmtNode() {}
@@ -337,3 +445,21 @@ type ContinueStmt struct{}
 
 func (*ContinueStmt) stmtNode()        {}
 func (s *ContinueStmt) String() string { return "ContinueStmt" }
+
+// GotoStmt represents goto Name;
+type GotoStmt struct {
+	Name string
+	Line int
+}
+
+func (*GotoStmt) stmtNode()        {}
+func (s *GotoStmt) String() string { return fmt.Sprintf("GotoStmt(%s)", s.Name) }
+
+// LabelStmt represents Name: at statement position.
+type LabelStmt struct {
+	Name string
+	Line int
+}
+
+func (*LabelStmt) stmtNode()        {}
+func (s *LabelStmt) String() string { return fmt.Sprintf("LabelStmt(%s)", s.Name) }
@@ -0,0 +1,74 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"gocpu/pkg/asm"
+	"gocpu/pkg/cpu"
+)
+
+func generateWith(t *testing.T, src string, opts Options) string {
+	t.Helper()
+	tokens, err := Lex(src)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, src)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	code, err := GenerateWithOptions(stmts, NewSymbolTable(), opts)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	return code
+}
+
+const byteOpsSrc = `
+char a = 200;
+char b = 100;
+int main() {
+	char c = 250;
+	c += 10;
+	c -= 1;
+	c &= 0x0F;
+	int wide = 1000;
+	wide += 1;
+	return (a + b) * 256 + c;
+}
+`
+
+func TestByteOps_Codegen(t *testing.T) {
+	code := generateWith(t, byteOpsSrc, Options{ByteOps: true})
+	assertContains(t, code, "ADDB R1, R0")
+	assertContains(t, code, "SUBB R1, R0")
+	assertContains(t, code, "ANDB R1, R0")
+	// int arithmetic keeps the word ALU.
+	assertContains(t, code, "ADD R1, R0")
+
+	off := generateWith(t, byteOpsSrc, Options{})
+	for _, op := range []string{"ADDB", "SUBB", "ANDB"} {
+		if strings.Contains(off, op) {
+			t.Errorf("%s emitted without Options.ByteOps", op)
+		}
+	}
+}
+
+func TestByteOps_Run(t *testing.T) {
+	code := generateWith(t, byteOpsSrc, Options{ByteOps: true})
+	mc, _, err := asm.Assemble(code)
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+	vm := cpu.NewCPU()
+	copy(vm.Memory[:], mc)
+	for i := 0; i < 10000 && !vm.Halted; i++ {
+		vm.Step()
+	}
+	// a + b wraps at 8 bits: (200 + 100) & 0xFF = 44.
+	// c: 250 + 10 = 4, - 1 = 3, & 0x0F = 3.
+	if want := uint16(44*256 + 3); vm.Regs[0] != want {
+		t.Errorf("expected %d, got %d", want, vm.Regs[0])
+	}
+}
@@ -16,6 +16,41 @@ type CodeGen struct {
 	dataPool        map[string][]uint16 // Label -> Data
 	dataCache       map[string]string   // Content -> Label
 	loopStack       []LoopLabel
+	labels          map[string]string // goto labels of currentFunction -> asm label
+	labelDepths     map[string]int    // goto label -> switch nesting depth at the label
+	switchDepth     int               // switch targets currently pushed on the stack
+	frameSizes      map[string]int    // function name -> stack frame size in bytes
+	opts            Options
+}
+
+// Options controls optional code generation behaviour.
+type Options struct {
+	// ByteOps uses the 8-bit ALU instructions (ADDB, SUBB, ANDB) for +, -
+	// and & when both operands are char, and for +=, -= and &= on a char
+	// lvalue. Note that this skips C's promotion to int, so char + char
+	// wraps at 8 bits.
+	ByteOps bool
+	// ZeroLocals clears each function's whole local frame with FILL on
+	// entry, so uninitialized locals read as 0 instead of stack garbage.
+	ZeroLocals bool
+	// AlignStructs starts every non-char struct field on an even offset,
+	// padding after char fields as needed, so word fields are loaded with a
+	// single aligned access. The struct size is padded to even as well. Off
+	// by default: fields are packed, which memory-mapped layouts rely on.
+	AlignStructs bool
+	// Warn, if set, receives non-fatal diagnostics such as implicit
+	// narrowing conversions. Warnings never stop code generation.
+	Warn func(Warning)
+}
+
+// Warning is a non-fatal diagnostic reported through Options.Warn.
+type Warning struct {
+	Line int // source line, 0 if unknown
+	Msg  string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("line %d: warning: %s", w.Line, w.Msg)
 }
 
 type LoopLabel struct {
@@ -30,6 +65,7 @@ func newCodeGen(syms *SymbolTable) *CodeGen {
 		stringPool: make(map[string]string),
 		dataPool:   make(map[string][]uint16),
 		dataCache:  make(map[string]string),
+		frameSizes: make(map[string]int),
 	}
 }
 
@@ -95,10 +131,98 @@ func (cg *CodeGen) calcSize(decl VariableDecl) (int, error) {
 	return elemSize, nil
 }
 
+// isStructValue reports whether t is a struct itself rather than a pointer to one.
+func isStructValue(t TypeInfo) bool {
+	return t.IsStruct && t.PointerLevel == 0
+}
+
+// sizeOf resolves a sizeof expression to its size in bytes.
+func (cg *CodeGen) sizeOf(n *SizeofExpr) (int, error) {
+	if n.Expr == nil {
+		return cg.calcSize(VariableDecl{
+			IsChar:       n.Type == CHAR,
+			IsStruct:     n.Type == STRUCT,
+			StructName:   n.StructName,
+			PointerLevel: n.PointerLevel,
+		})
+	}
+
+	if s, ok := n.Expr.(*StringLiteral); ok {
+		return len(s.Value) + 1, nil // include the null terminator
+	}
+
+	typ, err := cg.getType(n.Expr)
+	if err != nil {
+		return 0, err
+	}
+	return cg.typeSize(typ)
+}
+
+// typeSize returns the size in bytes of a value of type t.
+func (cg *CodeGen) typeSize(t TypeInfo) (int, error) {
+	return cg.calcSize(VariableDecl{
+		IsChar:       t.IsChar,
+		IsStruct:     t.IsStruct,
+		StructName:   t.StructName,
+		PointerLevel: t.PointerLevel,
+		IsArray:      t.IsArray,
+		ArraySizes:   t.ArraySizes,
+	})
+}
+
+// pointeeSize returns the size of the element a pointer type points to: 1
+// for char*, the struct size for a struct pointer, and 2 (a word or a
+// pointer) otherwise.
+func (cg *CodeGen) pointeeSize(t TypeInfo) (int, error) {
+	if t.PointerLevel != 1 {
+		return 2, nil
+	}
+	if t.IsChar {
+		return 1, nil
+	}
+	if t.IsStruct {
+		def, ok := cg.syms.GetStruct(t.StructName)
+		if !ok {
+			return 0, fmt.Errorf("unknown struct %q", t.StructName)
+		}
+		return def.Size, nil
+	}
+	return 2, nil
+}
+
+// pointerDiffSize returns the element size to divide by when l - r subtracts
+// two pointers, or 0 when either operand is not a pointer.
+func (cg *CodeGen) pointerDiffSize(l, r Expr) (int, error) {
+	lt, err := cg.getType(l)
+	if err != nil {
+		return 0, err
+	}
+	rt, err := cg.getType(r)
+	if err != nil {
+		return 0, err
+	}
+	if lt.PointerLevel == 0 || rt.PointerLevel == 0 || lt.IsArray || rt.IsArray {
+		return 0, nil
+	}
+	return cg.pointeeSize(lt)
+}
+
+// constRef returns the value of n if it names an enumerator that is not
+// shadowed by a variable.
+func (cg *CodeGen) constRef(n *VarRef) (uint16, bool) {
+	if _, ok := cg.syms.Lookup(n.Name); ok {
+		return 0, false
+	}
+	return cg.syms.Const(n.Name)
+}
+
 // getType determines the type of an expression.
 func (cg *CodeGen) getType(e Expr) (TypeInfo, error) {
 	switch n := e.(type) {
 	case *VarRef:
+		if _, ok := cg.constRef(n); ok {
+			return TypeInfo{}, nil
+		}
 		sym, ok := cg.syms.Lookup(n.Name)
 		if !ok {
 			return TypeInfo{}, fmt.Errorf("undefined variable %q", n.Name)
@@ -150,7 +274,13 @@ func (cg *CodeGen) getType(e Expr) (TypeInfo, error) {
 				return TypeInfo{}, fmt.Errorf("multi-dimensional indexing not supported for pointers")
 			}
 			// Dereferencing decreases pointer level
-			return TypeInfo{IsChar: leftType.IsChar, PointerLevel: leftType.PointerLevel - 1, IsUnsigned: leftType.IsUnsigned}, nil
+			return TypeInfo{
+				IsStruct:     leftType.IsStruct,
+				StructName:   leftType.StructName,
+				IsChar:       leftType.IsChar,
+				PointerLevel: leftType.PointerLevel - 1,
+				IsUnsigned:   leftType.IsUnsigned,
+			}, nil
 		}
 		return TypeInfo{}, nil
 
@@ -159,7 +289,10 @@ func (cg *CodeGen) getType(e Expr) (TypeInfo, error) {
 		if err != nil {
 			return TypeInfo{}, err
 		}
-		if !leftType.IsStruct {
+		if !isStructValue(leftType) {
+			if leftType.IsStruct {
+				return TypeInfo{}, fmt.Errorf("member access on pointer to struct %s (use -> or (*p).%s)", leftType.StructName, n.Member)
+			}
 			return TypeInfo{}, fmt.Errorf("member access on non-struct type")
 		}
 
@@ -183,6 +316,8 @@ func (cg *CodeGen) getType(e Expr) (TypeInfo, error) {
 			// If rightType is pointer, decrement level.
 			if rightType.PointerLevel > 0 {
 				return TypeInfo{
+					IsStruct:     rightType.IsStruct,
+					StructName:   rightType.StructName,
 					IsChar:       rightType.IsChar,
 					PointerLevel: rightType.PointerLevel - 1,
 					IsUnsigned:   rightType.IsUnsigned,
@@ -193,17 +328,354 @@ func (cg *CodeGen) getType(e Expr) (TypeInfo, error) {
 
 	case *Literal:
 		return TypeInfo{IsUnsigned: n.IsUnsigned}, nil
+
+	case *CastExpr:
+		return TypeInfo{
+			IsStruct:     n.Type == STRUCT,
+			StructName:   n.StructName,
+			IsChar:       n.Type == CHAR,
+			PointerLevel: n.PointerLevel,
+			IsUnsigned:   n.IsUnsigned,
+		}, nil
+
+	case *TernaryExpr:
+		// Result takes the type of the true arm (e.g. so pointer arithmetic still scales).
+		return cg.getType(n.Then)
+
+	case *FunctionCall:
+		switch n.Name {
+		case "min", "max", "clamp":
+			return TypeInfo{IsUnsigned: cg.anyUnsigned(n.Args)}, nil
+		case "cycles":
+			return TypeInfo{IsUnsigned: true}, nil
+		}
 	}
 
 	// Default scalar
 	return TypeInfo{}, nil
 }
 
+// aluOp returns the mnemonic for a word ALU op, or its 8-bit variant
+// (e.g. ADD -> ADDB) when isByte is true and Options.ByteOps is enabled.
+func (cg *CodeGen) aluOp(op string, isByte bool) string {
+	if isByte && cg.opts.ByteOps {
+		return op + "B"
+	}
+	return op
+}
+
+// charOperands reports whether both operands of a binary expression are
+// scalar chars.
+func (cg *CodeGen) charOperands(l, r Expr) bool {
+	isChar := func(e Expr) bool {
+		t, err := cg.getType(e)
+		return err == nil && t.IsChar && t.PointerLevel == 0 && !t.IsArray
+	}
+	return isChar(l) && isChar(r)
+}
+
+func (cg *CodeGen) warn(line int, format string, args ...interface{}) {
+	if cg.opts.Warn != nil {
+		cg.opts.Warn(Warning{Line: line, Msg: fmt.Sprintf(format, args...)})
+	}
+}
+
+// checkConversion warns when storing value into a scalar of type dst would
+// silently lose information: an int narrowed into a char, or a pointer stored
+// into a plain integer. An explicit cast suppresses both.
+func (cg *CodeGen) checkConversion(line int, dst TypeInfo, value Expr) {
+	if cg.opts.Warn == nil || dst.IsArray || dst.IsStruct || dst.PointerLevel > 0 {
+		return
+	}
+	src, err := cg.getType(value)
+	if err != nil || isStructValue(src) {
+		return // codegen reports the real error
+	}
+
+	srcIsPointer := src.PointerLevel > 0 || src.IsArray
+	switch v := value.(type) {
+	case *StringLiteral:
+		srcIsPointer = true
+	case *UnaryExpr:
+		srcIsPointer = srcIsPointer || v.Op == AND
+	}
+	if srcIsPointer {
+		dstName := "int"
+		if dst.IsChar {
+			dstName = "char"
+		}
+		cg.warn(line, "assigning pointer to %s without a cast", dstName)
+		return
+	}
+
+	if !dst.IsChar || src.IsChar {
+		return
+	}
+	if v, isUnsigned, err := cg.evalConst(value); err == nil {
+		if v <= 0xFF || (!isUnsigned && int16(v) >= -128 && int16(v) < 0) {
+			return
+		}
+		cg.warn(line, "implicit conversion from int to char changes value from %d to %d", int16(v), byte(v))
+		return
+	}
+	cg.warn(line, "implicit conversion from int to char may truncate value")
+}
+
+// checkEffect warns about an expression statement such as "x + 1;" that
+// computes an arithmetic, comparison or logical result and throws it away,
+// which usually means an assignment is missing.
+func (cg *CodeGen) checkEffect(s *ExprStmt) {
+	switch e := s.Expr.(type) {
+	case *BinaryExpr, *LogicalExpr:
+	case *UnaryExpr:
+		if e.Op == STAR || e.Op == AND {
+			return
+		}
+	default:
+		return
+	}
+	if !hasSideEffects(s.Expr) {
+		cg.warn(s.Line, "statement has no effect; is an assignment missing?")
+	}
+}
+
+// hasSideEffects reports whether evaluating e might do more than compute a
+// value: call a function, increment or decrement a variable, or read through
+// a pointer, which may be a volatile device register since the compiler does
+// not track volatile.
+func hasSideEffects(e Expr) bool {
+	switch n := e.(type) {
+	case *FunctionCall, *PostfixExpr:
+		return true
+	case *UnaryExpr:
+		return n.Op == STAR || hasSideEffects(n.Right)
+	case *BinaryExpr:
+		return hasSideEffects(n.Left) || hasSideEffects(n.Right)
+	case *LogicalExpr:
+		return hasSideEffects(n.Left) || hasSideEffects(n.Right)
+	case *TernaryExpr:
+		return hasSideEffects(n.Cond) || hasSideEffects(n.Then) || hasSideEffects(n.Else)
+	case *CastExpr:
+		return hasSideEffects(n.Expr)
+	case *IndexExpr:
+		// Indexing a pointer reads through it.
+		return true
+	case *MemberExpr:
+		return hasSideEffects(n.Left)
+	}
+	return false
+}
+
+// foldBinary computes a binary operation on two constants. ok is false if the
+// operator cannot be folded at compile time.
+func foldBinary(op TokenType, l, r uint16, isUnsigned bool) (res uint16, ok bool, err error) {
+	boolVal := func(b bool) uint16 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	switch op {
+	case PLUS:
+		return l + r, true, nil
+	case MINUS:
+		return l - r, true, nil
+	case STAR:
+		return l * r, true, nil
+	case SLASH:
+		if r == 0 {
+			return 0, false, fmt.Errorf("division by zero in constant expression")
+		}
+		if isUnsigned {
+			return l / r, true, nil
+		}
+		return uint16(int16(l) / int16(r)), true, nil
+	case PERCENT:
+		if r == 0 {
+			return 0, false, fmt.Errorf("modulo by zero in constant expression")
+		}
+		if isUnsigned {
+			return l % r, true, nil
+		}
+		return uint16(int16(l) % int16(r)), true, nil
+	case AND:
+		return l & r, true, nil
+	case PIPE:
+		return l | r, true, nil
+	case CARET:
+		return l ^ r, true, nil
+	case SHL_OP:
+		return l << r, true, nil
+	case SHR_OP:
+		return l >> r, true, nil
+	case EQUALS:
+		return boolVal(l == r), true, nil
+	case NOT_EQ:
+		return boolVal(l != r), true, nil
+	case LESS:
+		if isUnsigned {
+			return boolVal(l < r), true, nil
+		}
+		return boolVal(int16(l) < int16(r)), true, nil
+	case GREATER:
+		if isUnsigned {
+			return boolVal(l > r), true, nil
+		}
+		return boolVal(int16(l) > int16(r)), true, nil
+	case LESS_EQ:
+		if isUnsigned {
+			return boolVal(l <= r), true, nil
+		}
+		return boolVal(int16(l) <= int16(r)), true, nil
+	case GREATER_EQ:
+		if isUnsigned {
+			return boolVal(l >= r), true, nil
+		}
+		return boolVal(int16(l) >= int16(r)), true, nil
+	}
+	return 0, false, nil
+}
+
+// evalConst evaluates an integer constant expression at compile time.
+// It returns isUnsigned alongside the value so folding matches runtime semantics.
+func (cg *CodeGen) evalConst(e Expr) (val uint16, isUnsigned bool, err error) {
+	switch n := e.(type) {
+	case *Literal:
+		return n.Value, n.IsUnsigned, nil
+
+	case *VarRef:
+		if v, ok := cg.constRef(n); ok {
+			return v, false, nil
+		}
+
+	case *SizeofExpr:
+		size, err := cg.sizeOf(n)
+		return uint16(size), false, err
+
+	case *CastExpr:
+		// The cast decides the signedness: (unsigned)-1 is unsigned and
+		// (int)1u is signed.
+		v, _, err := cg.evalConst(n.Expr)
+		if err != nil {
+			return 0, false, err
+		}
+		if n.Type == CHAR && n.PointerLevel == 0 {
+			v &= 0xFF
+		}
+		return v, n.IsUnsigned, nil
+
+	case *UnaryExpr:
+		v, u, err := cg.evalConst(n.Right)
+		if err != nil {
+			return 0, false, err
+		}
+		switch n.Op {
+		case MINUS:
+			return -v, u, nil
+		case TILDE:
+			return ^v, u, nil
+		case NOT:
+			if v == 0 {
+				return 1, false, nil
+			}
+			return 0, false, nil
+		}
+
+	case *BinaryExpr:
+		l, lu, err := cg.evalConst(n.Left)
+		if err != nil {
+			return 0, false, err
+		}
+		r, ru, err := cg.evalConst(n.Right)
+		if err != nil {
+			return 0, false, err
+		}
+		res, ok, err := foldBinary(n.Op, l, r, lu || ru)
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			return res, lu || ru, nil
+		}
+
+	case *LogicalExpr:
+		l, _, err := cg.evalConst(n.Left)
+		if err != nil {
+			return 0, false, err
+		}
+		r, _, err := cg.evalConst(n.Right)
+		if err != nil {
+			return 0, false, err
+		}
+		if n.Op == AND_LOGICAL && l != 0 && r != 0 || n.Op == OR_LOGICAL && (l != 0 || r != 0) {
+			return 1, false, nil
+		}
+		return 0, false, nil
+
+	case *TernaryExpr:
+		c, _, err := cg.evalConst(n.Cond)
+		if err != nil {
+			return 0, false, err
+		}
+		if c != 0 {
+			return cg.evalConst(n.Then)
+		}
+		return cg.evalConst(n.Else)
+	}
+	return 0, false, fmt.Errorf("expression %s is not a compile-time constant", e)
+}
+
+// foldableOperand returns the value of a binary operand that can be folded
+// at compile time: an integer constant expression that is not a pointer, so
+// folding cannot skip pointer-difference scaling. Signedness comes from the
+// operand's type, as it does at run time, so (unsigned)-1 stays unsigned.
+func (cg *CodeGen) foldableOperand(e Expr) (val uint16, isUnsigned bool, ok bool) {
+	typ, err := cg.getType(e)
+	if err != nil || typ.PointerLevel > 0 || typ.IsArray {
+		return 0, false, false
+	}
+	val, _, err = cg.evalConst(e)
+	return val, typ.IsUnsigned, err == nil
+}
+
+// globalWordCopy reports whether assignment n copies one word-sized global
+// scalar into another, e.g. "a = b;" with both int or pointer globals, which
+// a single MOVM can do without going through a register.
+func (cg *CodeGen) globalWordCopy(n *Assignment) (dst, src Symbol, ok bool) {
+	global := func(e Expr) (Symbol, bool) {
+		ref, isRef := e.(*VarRef)
+		if !isRef {
+			return Symbol{}, false
+		}
+		if _, isConst := cg.constRef(ref); isConst {
+			return Symbol{}, false
+		}
+		sym, found := cg.syms.Lookup(ref.Name)
+		if !found || sym.Scope != ScopeGlobal {
+			return Symbol{}, false
+		}
+		t := sym.Type
+		if t.IsArray || isStructValue(t) || t.IsChar && t.PointerLevel == 0 {
+			return Symbol{}, false
+		}
+		return sym, true
+	}
+	if dst, ok = global(n.Left); !ok {
+		return
+	}
+	src, ok = global(n.Value)
+	return
+}
+
 // genAddress computes the address of an expression and puts it in R1.
 // Supports: VarRef, IndexExpr, MemberExpr, UnaryExpr(STAR).
 func (cg *CodeGen) genAddress(e Expr) error {
 	switch n := e.(type) {
 	case *VarRef:
+		if _, ok := cg.constRef(n); ok {
+			return fmt.Errorf("cannot take address of enumerator %q", n.Name)
+		}
 		sym, ok := cg.syms.Lookup(n.Name)
 		if !ok {
 			return fmt.Errorf("undefined variable %q", n.Name)
@@ -245,7 +717,9 @@ func (cg *CodeGen) genAddress(e Expr) error {
 
 		if leftType.IsArray {
 			baseElemSize := 2
-			if leftType.IsChar {
+			if leftType.PointerLevel > 0 {
+				// Array of pointers: word-sized elements
+			} else if leftType.IsChar {
 				baseElemSize = 1
 			} else if leftType.IsStruct {
 				def, ok := cg.syms.GetStruct(leftType.StructName)
@@ -287,11 +761,9 @@ func (cg *CodeGen) genAddress(e Expr) error {
 			if len(n.Indices) != 1 {
 				return fmt.Errorf("pointers only support single index")
 			}
-			elemSize := 2
-			// If pointer to char (level 1), element size is 1.
-			// If pointer to pointer (level > 1), element size is 2 (pointer size).
-			if leftType.IsChar && leftType.PointerLevel == 1 {
-				elemSize = 1
+			elemSize, err := cg.pointeeSize(leftType)
+			if err != nil {
+				return err
 			}
 
 			if err := cg.genExpr(n.Indices[0]); err != nil {
@@ -301,6 +773,9 @@ func (cg *CodeGen) genAddress(e Expr) error {
 			if elemSize == 2 {
 				cg.line("    LDI R3, 1")
 				cg.line("    SHL R0, R3")
+			} else if elemSize != 1 {
+				cg.line("    LDI R3, %d", elemSize)
+				cg.line("    MUL R0, R3")
 			}
 			// Add to offset (which is 0)
 			cg.line("    POP R1")
@@ -315,34 +790,18 @@ func (cg *CodeGen) genAddress(e Expr) error {
 		return nil
 
 	case *MemberExpr:
-		// Left.Member
-		// Need struct type info of Left.
-		typ, err := cg.getType(n.Left)
+		offset, err := cg.memberOffset(n)
 		if err != nil {
 			return err
 		}
-		if !typ.IsStruct {
-			return fmt.Errorf("member access on non-struct type")
-		}
 
-		def, ok := cg.syms.GetStruct(typ.StructName)
-		if !ok {
-			return fmt.Errorf("unknown struct %q", typ.StructName)
-		}
-		field, ok := def.Fields[n.Member]
-		if !ok {
-			return fmt.Errorf("struct %s has no member %q", typ.StructName, n.Member)
-		}
-
-		// Address of Left
-		// If Left is VarRef (struct instance): genExpr(Left) returns address of struct (because IsStruct=true).
-		if err := cg.genExpr(n.Left); err != nil {
+		// Address of Left: a struct instance, array element, nested member, or *ptr.
+		if err := cg.genAddress(n.Left); err != nil {
 			return err
 		}
-		// R0 has base address.
+		// R1 has base address.
 
-		cg.line("    MOV R1, R0")
-		cg.line("    LDI R3, %d", field.Offset)
+		cg.line("    LDI R3, %d", offset)
 		cg.line("    ADD R1, R3")
 		return nil
 
@@ -356,21 +815,99 @@ func (cg *CodeGen) genAddress(e Expr) error {
 			return nil
 		}
 	}
+	if n, ok := e.(*PostfixExpr); ok {
+		return fmt.Errorf("cannot take address of %s: not an lvalue", n)
+	}
 	return fmt.Errorf("cannot take address of expression type %T", e)
 }
 
+// memberOffset returns the byte offset of the field named by Left.Member
+// within Left's struct type.
+func (cg *CodeGen) memberOffset(n *MemberExpr) (int, error) {
+	typ, err := cg.getType(n.Left)
+	if err != nil {
+		return 0, err
+	}
+	if !isStructValue(typ) {
+		return 0, fmt.Errorf("member access on non-struct type")
+	}
+
+	def, ok := cg.syms.GetStruct(typ.StructName)
+	if !ok {
+		return 0, fmt.Errorf("unknown struct %q", typ.StructName)
+	}
+	field, ok := def.Fields[n.Member]
+	if !ok {
+		return 0, fmt.Errorf("struct %s has no member %q", typ.StructName, n.Member)
+	}
+	return field.Offset, nil
+}
+
+// directOperand reports whether genDirectOperand can load e: a literal, an
+// enumerator or a scalar variable.
+func (cg *CodeGen) directOperand(e Expr) bool {
+	switch n := e.(type) {
+	case *Literal:
+		return true
+	case *VarRef:
+		if _, ok := cg.constRef(n); ok {
+			return true
+		}
+		sym, ok := cg.syms.Lookup(n.Name)
+		return ok && !sym.Type.IsArray && !isStructValue(sym.Type)
+	}
+	return false
+}
+
+// genDirectOperand loads an operand accepted by directOperand into R0
+// without touching R1, so a binary operator can keep its left operand in R1
+// rather than on the stack.
+func (cg *CodeGen) genDirectOperand(e Expr) error {
+	n, ok := e.(*VarRef)
+	if !ok {
+		return cg.genExpr(e)
+	}
+	if v, ok := cg.constRef(n); ok {
+		cg.line("    LDI R0, %d    ; %s", v, n.Name)
+		return nil
+	}
+	sym, _ := cg.syms.Lookup(n.Name)
+	isByte := sym.Type.IsChar && sym.Type.PointerLevel == 0
+	switch {
+	case sym.Scope == ScopeGlobal:
+		cg.line("    LDI R0, %s    ; &%s (global)", sym.Label, n.Name)
+	case !isByte:
+		cg.line("    LDX R0, [R2 + %d]    ; %s (local/param)", uint16(sym.Address), n.Name)
+		return nil
+	default:
+		cg.line("    MOV R0, R2        ; FP")
+		cg.line("    LDI R3, %d", uint16(sym.Address))
+		cg.line("    ADD R0, R3        ; &%s (local/param)", n.Name)
+	}
+	if isByte {
+		cg.line("    LDB R0, [R0]")
+	} else {
+		cg.line("    LD  R0, [R0]")
+	}
+	return nil
+}
+
 // genExpr emits the instructions that evaluate expr and leave the result in R0.
 func (cg *CodeGen) genExpr(e Expr) error {
 	switch n := e.(type) {
 
 	case *VarRef:
+		if v, ok := cg.constRef(n); ok {
+			cg.line("    LDI R0, %d    ; %s", v, n.Name)
+			return nil
+		}
 		sym, ok := cg.syms.Lookup(n.Name)
 		if !ok {
 			return fmt.Errorf("undefined variable %q", n.Name)
 		}
 
 		// If Array or Struct, return address.
-		if sym.Type.IsArray || sym.Type.IsStruct {
+		if sym.Type.IsArray || isStructValue(sym.Type) {
 			if err := cg.genAddress(e); err != nil {
 				return err
 			}
@@ -396,7 +933,7 @@ func (cg *CodeGen) genExpr(e Expr) error {
 			return err
 		}
 
-		if typ.IsArray || typ.IsStruct {
+		if typ.IsArray || isStructValue(typ) {
 			if err := cg.genAddress(e); err != nil {
 				return err
 			}
@@ -404,6 +941,19 @@ func (cg *CodeGen) genExpr(e Expr) error {
 			return nil
 		}
 
+		if m, ok := e.(*MemberExpr); ok && !(typ.IsChar && typ.PointerLevel == 0) {
+			// Word member: a single indexed load from the struct's address.
+			offset, err := cg.memberOffset(m)
+			if err != nil {
+				return err
+			}
+			if err := cg.genAddress(m.Left); err != nil {
+				return err
+			}
+			cg.line("    LDX R0, [R1 + %d]", offset)
+			return nil
+		}
+
 		if err := cg.genAddress(e); err != nil {
 			return err
 		}
@@ -466,94 +1016,42 @@ func (cg *CodeGen) genExpr(e Expr) error {
 		}
 		return fmt.Errorf("codegen: unknown logical operator %s", n.Op)
 
+	case *TernaryExpr:
+		falseLabel := cg.newLabel()
+		endLabel := cg.newLabel()
+
+		if err := cg.genExpr(n.Cond); err != nil {
+			return err
+		}
+		cg.line("    LDI R1, 0")
+		cg.line("    SUB R0, R1")
+		cg.line("    JZ  %s", falseLabel)
+
+		if err := cg.genExpr(n.Then); err != nil {
+			return err
+		}
+		cg.line("    JMP %s", endLabel)
+
+		cg.line("%s:", falseLabel)
+		if err := cg.genExpr(n.Else); err != nil {
+			return err
+		}
+		cg.line("%s:", endLabel)
+		return nil
+
 	case *BinaryExpr:
 		// Optimization: Constant Folding
-		// If both operands are literals, compute the result at compile time.
-		if left, ok := n.Left.(*Literal); ok {
-			if right, ok := n.Right.(*Literal); ok {
-				// If either literal is explicitly unsigned (u/U suffix), treat the
+		// If both operands are integer constants (literals, enumerators or
+		// folded expressions such as -1), compute the result at compile time.
+		if left, leftUnsigned, ok := cg.foldableOperand(n.Left); ok {
+			if right, rightUnsigned, ok := cg.foldableOperand(n.Right); ok {
+				// If either operand is explicitly unsigned (u/U suffix), treat the
 				// whole operation as unsigned; otherwise default to signed (int).
-				leftType, _ := cg.getType(n.Left)
-				rightType, _ := cg.getType(n.Right)
-				isUnsigned := leftType.IsUnsigned || rightType.IsUnsigned
-
-				var res uint16
-				switch n.Op {
-				case PLUS:
-					res = left.Value + right.Value
-				case MINUS:
-					res = left.Value - right.Value
-				case STAR:
-					res = left.Value * right.Value
-				case SLASH:
-					if right.Value == 0 {
-						return fmt.Errorf("division by zero in constant expression")
-					}
-					if isUnsigned {
-						res = left.Value / right.Value
-					} else {
-						res = uint16(int16(left.Value) / int16(right.Value))
-					}
-				case PERCENT:
-					if right.Value == 0 {
-						return fmt.Errorf("modulo by zero in constant expression")
-					}
-					if isUnsigned {
-						res = left.Value % right.Value
-					} else {
-						res = uint16(int16(left.Value) % int16(right.Value))
-					}
-				case AND:
-					res = left.Value & right.Value
-				case PIPE:
-					res = left.Value | right.Value
-				case CARET:
-					res = left.Value ^ right.Value
-				case SHL_OP:
-					res = left.Value << right.Value
-				case SHR_OP:
-					res = left.Value >> right.Value
-				case EQUALS:
-					if left.Value == right.Value {
-						res = 1
-					} else {
-						res = 0
-					}
-				case NOT_EQ:
-					if left.Value != right.Value {
-						res = 1
-					} else {
-						res = 0
-					}
-				case LESS:
-					if isUnsigned {
-						if left.Value < right.Value {
-							res = 1
-						} else {
-							res = 0
-						}
-					} else {
-						if int16(left.Value) < int16(right.Value) {
-							res = 1
-						} else {
-							res = 0
-						}
-					}
-				case GREATER:
-					if isUnsigned {
-						if left.Value > right.Value {
-							res = 1
-						} else {
-							res = 0
-						}
-					} else {
-						if int16(left.Value) > int16(right.Value) {
-							res = 1
-						} else {
-							res = 0
-						}
-					}
-				default:
+				res, ok, err := foldBinary(n.Op, left, right, leftUnsigned || rightUnsigned)
+				if err != nil {
+					return err
+				}
+				if !ok {
 					goto RuntimeEval
 				}
 				cg.line("    LDI R0, %d", res)
@@ -565,11 +1063,20 @@ func (cg *CodeGen) genExpr(e Expr) error {
 		if err := cg.genExpr(n.Left); err != nil {
 			return err
 		}
-		cg.line("    PUSH R0")
-		if err := cg.genExpr(n.Right); err != nil {
-			return err
+		if cg.directOperand(n.Right) {
+			// A literal or plain variable loads without touching R1, so the
+			// left operand can wait there instead of on the stack.
+			cg.line("    MOV R1, R0")
+			if err := cg.genDirectOperand(n.Right); err != nil {
+				return err
+			}
+		} else {
+			cg.line("    PUSH R0")
+			if err := cg.genExpr(n.Right); err != nil {
+				return err
+			}
+			cg.line("    POP  R1")
 		}
-		cg.line("    POP  R1")
 
 		switch n.Op {
 		case LESS_EQ:
@@ -610,11 +1117,20 @@ func (cg *CodeGen) genExpr(e Expr) error {
 			cg.line("    LDI R0, 0") // False
 			cg.line("%s:", labelEnd)
 		case PLUS:
-			cg.line("    ADD R1, R0")
+			cg.line("    %s R1, R0", cg.aluOp("ADD", cg.charOperands(n.Left, n.Right)))
 			cg.line("    MOV R0, R1")
 		case MINUS:
-			cg.line("    SUB R1, R0")
+			cg.line("    %s R1, R0", cg.aluOp("SUB", cg.charOperands(n.Left, n.Right)))
 			cg.line("    MOV R0, R1")
+			// Pointer difference: a byte distance scaled down to elements.
+			size, err := cg.pointerDiffSize(n.Left, n.Right)
+			if err != nil {
+				return err
+			}
+			if size > 1 {
+				cg.line("    LDI R3, %d", size)
+				cg.line("    IDIV R0, R3")
+			}
 		case STAR:
 			cg.line("    MUL R1, R0")
 			cg.line("    MOV R0, R1")
@@ -681,7 +1197,7 @@ func (cg *CodeGen) genExpr(e Expr) error {
 			cg.line("    LDI R0, 0")
 			cg.line("%s:", label)
 		case AND:
-			cg.line("    AND R1, R0")
+			cg.line("    %s R1, R0", cg.aluOp("AND", cg.charOperands(n.Left, n.Right)))
 			cg.line("    MOV R0, R1")
 		case PIPE:
 			cg.line("    OR  R1, R0")
@@ -690,8 +1206,18 @@ func (cg *CodeGen) genExpr(e Expr) error {
 			cg.line("    XOR R1, R0")
 			cg.line("    MOV R0, R1")
 		case PERCENT:
+			// Remainder is a - (a / b) * b; the division must match the signedness
+			// of the operands so that e.g. -7 % 3 yields -1.
+			typ, err := cg.getType(n.Left)
+			if err != nil {
+				return err
+			}
 			cg.line("    MOV R3, R1")
-			cg.line("    DIV R1, R0")
+			if typ.IsUnsigned {
+				cg.line("    DIV R1, R0")
+			} else {
+				cg.line("    IDIV R1, R0")
+			}
 			cg.line("    MUL R1, R0")
 			cg.line("    SUB R3, R1")
 			cg.line("    MOV R0, R3")
@@ -708,6 +1234,14 @@ func (cg *CodeGen) genExpr(e Expr) error {
 		}
 
 	case *UnaryExpr:
+		// Optimization: Constant Folding
+		// -5, ~0xFF, !0 and the like load their value directly.
+		if n.Op == MINUS || n.Op == TILDE || n.Op == NOT {
+			if v, _, err := cg.evalConst(n); err == nil {
+				cg.line("    LDI R0, %d", v)
+				return nil
+			}
+		}
 		if n.Op == AND {
 			// Address-of: &x
 			// Must be valid lvalue (VarRef, IndexExpr, MemberExpr)
@@ -788,6 +1322,13 @@ func (cg *CodeGen) genExpr(e Expr) error {
 	case *Literal:
 		cg.line("    LDI R0, %d", n.Value)
 
+	case *SizeofExpr:
+		size, err := cg.sizeOf(n)
+		if err != nil {
+			return err
+		}
+		cg.line("    LDI R0, %d    ; sizeof", size)
+
 	case *StringLiteral:
 		label, ok := cg.stringPool[n.Value]
 		if !ok {
@@ -797,6 +1338,10 @@ func (cg *CodeGen) genExpr(e Expr) error {
 		cg.line("    LDI R0, %s", label)
 
 	case *FunctionCall:
+		if handled, err := cg.genIntrinsic(n); handled || err != nil {
+			return err
+		}
+
 		for i := len(n.Args) - 1; i >= 0; i-- {
 			if err := cg.genExpr(n.Args[i]); err != nil {
 				return err
@@ -824,36 +1369,243 @@ func (cg *CodeGen) genExpr(e Expr) error {
 		}
 
 	case *PostfixExpr:
-		// x++
-		// R0 = x. x = x + 1.
+		// x++: R0 = x, then x = x + 1.
+		// ++x: x = x + 1, then R0 = x.
+		typ, err := cg.getType(n.Left)
+		if err != nil {
+			return err
+		}
+		isByte := typ.IsChar && typ.PointerLevel == 0 && !typ.IsArray
+
 		if err := cg.genAddress(n.Left); err != nil {
 			return err
 		}
-		// R1 = &x.
-		cg.line("    LD  R0, [R1]")
-		cg.line("    PUSH R0") // Save original value (result)
-
-		// Calculate new value
-		// R0 is current value.
-		if n.Op == PLUS_PLUS {
-			cg.line("    LDI R3, 1")
-			cg.line("    ADD R0, R3")
-		} else if n.Op == MINUS_MINUS {
-			cg.line("    LDI R3, 1")
-			cg.line("    SUB R0, R3")
-		} else {
-			return fmt.Errorf("codegen: unknown postfix op %s", n.Op)
+		// R1 = &x.
+		if isByte {
+			cg.line("    LDB R0, [R1]")
+		} else {
+			cg.line("    LD  R0, [R1]")
+		}
+		if !n.Prefix {
+			cg.line("    PUSH R0") // Save original value (result)
+		}
+
+		// Calculate new value
+		// R0 is current value.
+		if n.Op == PLUS_PLUS {
+			cg.line("    LDI R3, 1")
+			cg.line("    ADD R0, R3")
+		} else if n.Op == MINUS_MINUS {
+			cg.line("    LDI R3, 1")
+			cg.line("    SUB R0, R3")
+		} else {
+			return fmt.Errorf("codegen: unknown postfix op %s", n.Op)
+		}
+
+		// Store new value
+		if isByte {
+			cg.line("    STB [R1], R0")
+		} else {
+			cg.line("    ST  [R1], R0")
+		}
+
+		if n.Prefix {
+			if isByte {
+				cg.line("    LDB R0, [R1]") // Result is the stored (truncated) byte
+			}
+			return nil
+		}
+
+		// Restore original value to R0
+		cg.line("    POP R0")
+		return nil
+
+	default:
+		return fmt.Errorf("codegen: unknown expression node %T", e)
+	}
+	return nil
+}
+
+// MDU (math/divide unit) MMIO registers used by the fixed-point intrinsics.
+const (
+	mduRegA   = 0xFF20
+	mduRegB   = 0xFF21 // writing B triggers the calculation
+	mduRegRes = 0xFF22
+	mduRegOp  = 0xFF23
+)
+
+// cycleCountLoReg is the MMIO register holding the low 16 bits of the CPU's
+// executed-instruction counter, read by the cycles() intrinsic.
+const cycleCountLoReg = 0xFF2A
+
+// genIntrinsic emits inline code for built-in functions that map directly onto
+// hardware. It reports whether the call was handled as an intrinsic.
+func (cg *CodeGen) genIntrinsic(n *FunctionCall) (bool, error) {
+	switch n.Name {
+	case "static_assert":
+		// Checked entirely at compile time; emits no code.
+		if len(n.Args) != 2 {
+			return true, fmt.Errorf("static_assert expects 2 arguments, got %d", len(n.Args))
+		}
+		msg, ok := n.Args[1].(*StringLiteral)
+		if !ok {
+			return true, fmt.Errorf("static_assert message must be a string literal")
+		}
+		val, _, err := cg.evalConst(n.Args[0])
+		if err != nil {
+			return true, fmt.Errorf("static_assert: %w", err)
+		}
+		if val == 0 {
+			return true, fmt.Errorf("static assertion failed: %s", msg.Value)
+		}
+		return true, nil
+
+	case "fmul", "fdiv":
+		// Q8.8 multiply/divide via the MDU. Op 0 = multiply, 1 = divide.
+		if len(n.Args) != 2 {
+			return true, fmt.Errorf("%s expects 2 arguments, got %d", n.Name, len(n.Args))
+		}
+		op := 0
+		if n.Name == "fdiv" {
+			op = 1
+		}
+
+		if err := cg.genExpr(n.Args[0]); err != nil {
+			return true, err
+		}
+		cg.line("    PUSH R0")
+		if err := cg.genExpr(n.Args[1]); err != nil {
+			return true, err
+		}
+		cg.line("    POP  R1") // R1 = a, R0 = b
+
+		cg.line("    LDI R3, 0x%04X    ; MDU A", mduRegA)
+		cg.line("    ST  [R3], R1")
+		cg.line("    LDI R1, %d", op)
+		cg.line("    LDI R3, 0x%04X    ; MDU op", mduRegOp)
+		cg.line("    ST  [R3], R1")
+		cg.line("    LDI R3, 0x%04X    ; MDU B (triggers %s)", mduRegB, n.Name)
+		cg.line("    ST  [R3], R0")
+		cg.line("    LDI R3, 0x%04X    ; MDU result", mduRegRes)
+		cg.line("    LD  R0, [R3]")
+		return true, nil
+
+	case "cycles":
+		// Low 16 bits of the instruction counter; differences between two
+		// reads are exact as long as fewer than 65536 instructions separate
+		// them.
+		if len(n.Args) != 0 {
+			return true, fmt.Errorf("cycles expects no arguments, got %d", len(n.Args))
+		}
+		cg.line("    LDI R3, 0x%04X    ; cycle counter (low)", cycleCountLoReg)
+		cg.line("    LD  R0, [R3]")
+		return true, nil
+
+	case "min", "max":
+		// Unsigned comparison if either operand is unsigned, as for < and >.
+		if len(n.Args) != 2 {
+			return true, fmt.Errorf("%s expects 2 arguments, got %d", n.Name, len(n.Args))
+		}
+		op := strings.ToUpper(n.Name)
+		if cg.anyUnsigned(n.Args) {
+			op += "U"
+		}
+
+		if err := cg.genExpr(n.Args[0]); err != nil {
+			return true, err
+		}
+		cg.line("    PUSH R0")
+		if err := cg.genExpr(n.Args[1]); err != nil {
+			return true, err
+		}
+		cg.line("    POP  R1") // R1 = a, R0 = b
+		cg.line("    %s R0, R1", op)
+		return true, nil
+
+	case "clamp":
+		// clamp(x, lo, hi) = min(max(x, lo), hi)
+		if len(n.Args) != 3 {
+			return true, fmt.Errorf("clamp expects 3 arguments, got %d", len(n.Args))
+		}
+		suffix := ""
+		if cg.anyUnsigned(n.Args) {
+			suffix = "U"
+		}
+
+		for i, arg := range n.Args {
+			if err := cg.genExpr(arg); err != nil {
+				return true, err
+			}
+			if i < 2 {
+				cg.line("    PUSH R0")
+			}
+		}
+		cg.line("    POP  R1") // R1 = lo, R0 = hi
+		cg.line("    POP  R3") // R3 = x
+		cg.line("    MAX%s R3, R1", suffix)
+		cg.line("    MIN%s R3, R0", suffix)
+		cg.line("    MOV R0, R3")
+		return true, nil
+	}
+	return false, nil
+}
+
+// anyUnsigned reports whether any of args has an unsigned type.
+func (cg *CodeGen) anyUnsigned(args []Expr) bool {
+	for _, arg := range args {
+		if typ, _ := cg.getType(arg); typ.IsUnsigned {
+			return true
+		}
+	}
+	return false
+}
+
+// collectLabels records every LabelStmt in a function body into cg.labels,
+// assigning each a unique assembler label so that gotos can jump forwards as
+// well as backwards. depth is the number of enclosing switches, whose targets
+// sit on the stack at the label; it is kept in cg.labelDepths.
+func (cg *CodeGen) collectLabels(stmt Stmt, depth int) error {
+	switch s := stmt.(type) {
+	case *BlockStmt:
+		for _, child := range s.Stmts {
+			if err := cg.collectLabels(child, depth); err != nil {
+				return err
+			}
+		}
+	case *LabelStmt:
+		if _, dup := cg.labels[s.Name]; dup {
+			return fmt.Errorf("line %d: duplicate label %q in function %s", s.Line, s.Name, cg.currentFunction)
+		}
+		cg.labels[s.Name] = fmt.Sprintf("%s_%s_%s", cg.newLabel(), cg.currentFunction, s.Name)
+		cg.labelDepths[s.Name] = depth
+	case *IfStmt:
+		if err := cg.collectLabels(s.Body, depth); err != nil {
+			return err
+		}
+		if s.ElseBody != nil {
+			return cg.collectLabels(s.ElseBody, depth)
+		}
+	case *WhileStmt:
+		return cg.collectLabels(s.Body, depth)
+	case *DoWhileStmt:
+		return cg.collectLabels(s.Body, depth)
+	case *ForStmt:
+		if s.Body != nil {
+			return cg.collectLabels(s.Body, depth)
+		}
+	case *SwitchStmt:
+		for _, clause := range s.Cases {
+			for _, child := range clause.Body {
+				if err := cg.collectLabels(child, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		for _, child := range s.Default {
+			if err := cg.collectLabels(child, depth+1); err != nil {
+				return err
+			}
 		}
-
-		// Store new value
-		cg.line("    ST  [R1], R0")
-
-		// Restore original value to R0
-		cg.line("    POP R0")
-		return nil
-
-	default:
-		return fmt.Errorf("codegen: unknown expression node %T", e)
 	}
 	return nil
 }
@@ -895,6 +1647,12 @@ func (cg *CodeGen) countLocals(stmt Stmt) (int, error) {
 			return 0, err
 		}
 		count += c
+	case *DoWhileStmt:
+		c, err := cg.countLocals(s.Body)
+		if err != nil {
+			return 0, err
+		}
+		count += c
 	case *ForStmt:
 		if s.Init != nil {
 			c, err := cg.countLocals(s.Init)
@@ -932,33 +1690,9 @@ func (cg *CodeGen) countLocals(stmt Stmt) (int, error) {
 		// Note: This defines it globally/in the map. C allows local struct definitions.
 		// Our SymbolTable has a single struct map, so this effectively makes it global
 		// or overwrites previous definitions. For this C-subset, this is acceptable.
-		def := StructDef{
-			Name:   s.Name,
-			Fields: make(map[string]FieldInfo),
-			Size:   0,
-		}
-		byteOffset := 0
-		for _, field := range s.Fields {
-			size, err := cg.calcSize(field)
-			if err != nil {
-				return 0, err
-			}
-
-			typeInfo := TypeInfo{
-				IsArray:      field.IsArray,
-				ArraySizes:   field.ArraySizes,
-				IsStruct:     field.IsStruct,
-				StructName:   field.StructName,
-				IsChar:       field.IsChar,
-				PointerLevel: field.PointerLevel,
-				IsUnsigned:   field.IsUnsigned,
-			}
-
-			def.Fields[field.Name] = FieldInfo{Offset: byteOffset, Type: typeInfo}
-			byteOffset += size
+		if _, err := cg.defineStruct(s); err != nil {
+			return 0, err
 		}
-		def.Size = byteOffset
-		cg.syms.DefineStruct(def)
 
 	case *ExprStmt, *Assignment, *ReturnStmt:
 		return 0, nil
@@ -970,47 +1704,290 @@ func (cg *CodeGen) countLocals(stmt Stmt) (int, error) {
 	return count, nil
 }
 
+// defineStruct lays out decl's fields in declaration order (or all at offset
+// 0 for a union) and records the result in the symbol table. Inline anonymous
+// structs are defined first so that fields using them can be sized.
+func (cg *CodeGen) defineStruct(decl *StructDecl) (StructDef, error) {
+	for _, inline := range decl.Inline {
+		if _, err := cg.defineStruct(inline); err != nil {
+			return StructDef{}, err
+		}
+	}
+
+	def := StructDef{
+		Name:    decl.Name,
+		Fields:  make(map[string]FieldInfo),
+		Size:    0,
+		IsUnion: decl.IsUnion,
+	}
+	byteOffset := 0
+	wordAligned := false
+	for _, field := range decl.Fields {
+		size, err := cg.calcSize(field)
+		if err != nil {
+			return StructDef{}, err
+		}
+		if cg.opts.AlignStructs && !(field.IsChar && field.PointerLevel == 0) {
+			wordAligned = true
+			byteOffset += byteOffset & 1
+		}
+
+		typeInfo := TypeInfo{
+			IsArray:      field.IsArray,
+			ArraySizes:   field.ArraySizes,
+			IsStruct:     field.IsStruct,
+			StructName:   field.StructName,
+			IsChar:       field.IsChar,
+			PointerLevel: field.PointerLevel,
+			IsUnsigned:   field.IsUnsigned,
+		}
+
+		def.Fields[field.Name] = FieldInfo{Offset: byteOffset, Type: typeInfo}
+		def.Order = append(def.Order, field.Name)
+		if decl.IsUnion {
+			def.Size = max(def.Size, size)
+		} else {
+			byteOffset += size
+			def.Size = byteOffset
+		}
+	}
+	if wordAligned {
+		def.Size += def.Size & 1
+	}
+	cg.syms.DefineStruct(def)
+	return def, nil
+}
+
+// internData adds vals to the initializer data pool, reusing an existing
+// label when the same data was already emitted, and returns its label.
+func (cg *CodeGen) internData(vals []uint16) string {
+	keyBuilder := strings.Builder{}
+	for i, v := range vals {
+		if i > 0 {
+			keyBuilder.WriteString(",")
+		}
+		fmt.Fprintf(&keyBuilder, "%d", v)
+	}
+	key := keyBuilder.String()
+
+	label, ok := cg.dataCache[key]
+	if !ok {
+		label = cg.newDataLabel()
+		cg.dataCache[key] = label
+		cg.dataPool[label] = vals
+	}
+	return label
+}
+
+// asmEscape quotes s for a .STRING directive. Quotes, backslashes and bytes
+// outside printable ASCII are escaped so every byte survives assembly.
+func asmEscape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; {
+		case b == '"' || b == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(b)
+		case b == '\n':
+			sb.WriteString("\\n")
+		case b < 0x20 || b >= 0x7F:
+			fmt.Fprintf(&sb, "\\x%02x", b)
+		default:
+			sb.WriteByte(b)
+		}
+	}
+	return sb.String()
+}
+
+// charArrayImage packs s into size bytes, zero-padded, as little-endian words.
+// A trailing odd byte occupies the low half of the last word.
+func charArrayImage(s string, size int) []uint16 {
+	words := make([]uint16, (size+1)/2)
+	for i := 0; i < len(s) && i < size; i++ {
+		words[i/2] |= uint16(s[i]) << (8 * (i % 2))
+	}
+	return words
+}
+
+// genCharArrayInit initializes the char array sym from a string literal by
+// copying its zero-padded image from the data pool.
+func (cg *CodeGen) genCharArrayInit(sym Symbol, s string) error {
+	image := charArrayImage(s, sym.Size)
+	label := cg.internData(image)
+
+	if sym.Scope == ScopeGlobal {
+		cg.line("    LDI R1, %s", sym.Label)
+	} else {
+		cg.line("    MOV R1, R2")
+		cg.line("    LDI R3, %d", uint16(sym.Address))
+		cg.line("    ADD R1, R3")
+	}
+
+	// COPY moves whole words; an odd final byte is stored separately so the
+	// byte after the array is left alone.
+	cg.line("    PUSH R2")
+	cg.line("    LDI R0, %s", label)
+	cg.line("    LDI R2, %d", sym.Size/2)
+	cg.line("    COPY R0, R1, R2")
+	cg.line("    POP R2")
+	if sym.Size%2 == 1 {
+		cg.line("    LDI R3, %d", sym.Size-1)
+		cg.line("    ADD R1, R3")
+		cg.line("    LDI R0, %d", image[len(image)-1]&0xFF)
+		cg.line("    STB [R1], R0")
+	}
+	return nil
+}
+
+// globalConst resolves a global initializer element to a constant: a
+// literal, a negated literal or an enumerator.
+func (cg *CodeGen) globalConst(e Expr) (uint16, bool) {
+	if lit, ok := e.(*Literal); ok {
+		return lit.Value, true
+	}
+	if un, ok := e.(*UnaryExpr); ok && un.Op == MINUS {
+		if lit, ok := un.Right.(*Literal); ok {
+			return uint16(-int16(lit.Value)), true // 2's complement
+		}
+	}
+	if ref, ok := e.(*VarRef); ok {
+		return cg.constRef(ref)
+	}
+	return 0, false
+}
+
+// globalImage lays out a braced global initializer as the words of a
+// sym.Size-byte object. Struct members follow declaration order and chars
+// take a single byte, so the image matches the field offsets; the rest of
+// the object is zero.
+func (cg *CodeGen) globalImage(name string, sym Symbol, list *InitializerList) ([]uint16, error) {
+	img := make([]byte, sym.Size+sym.Size%2)
+	if err := cg.initAggregate(img, 0, sym.Type, list, name); err != nil {
+		return nil, err
+	}
+	words := make([]uint16, len(img)/2)
+	for i := range words {
+		words[i] = uint16(img[2*i]) | uint16(img[2*i+1])<<8
+	}
+	return words, nil
+}
+
+// initAggregate fills the array or struct of type t at img[off:] from the
+// braced list, rejecting lists with more elements than t has members.
+func (cg *CodeGen) initAggregate(img []byte, off int, t TypeInfo, list *InitializerList, name string) error {
+	pos := 0
+	if err := cg.initMembers(img, off, t, list.Elements, &pos, name); err != nil {
+		return err
+	}
+	if pos < len(list.Elements) {
+		return fmt.Errorf("too many initializers for %s", name)
+	}
+	return nil
+}
+
+// initMembers fills the members of the aggregate t in order, consuming
+// elements from elems starting at *pos. A member that is itself an aggregate
+// takes either a nested brace list or, with the braces elided, as many of
+// the following elements as it has scalars.
+func (cg *CodeGen) initMembers(img []byte, off int, t TypeInfo, elems []Expr, pos *int, name string) error {
+	type member struct {
+		off  int
+		typ  TypeInfo
+		name string
+	}
+	var members []member
+	if !t.IsArray && !isStructValue(t) {
+		members = append(members, member{off, t, name})
+	} else if t.IsArray {
+		elem := t
+		elem.ArraySizes = t.ArraySizes[1:]
+		elem.IsArray = len(elem.ArraySizes) > 0
+		size, err := cg.typeSize(elem)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < t.ArraySizes[0]; i++ {
+			members = append(members, member{off + i*size, elem, fmt.Sprintf("%s[%d]", name, i)})
+		}
+	} else {
+		def, ok := cg.syms.GetStruct(t.StructName)
+		if !ok {
+			return fmt.Errorf("unknown struct %q", t.StructName)
+		}
+		for _, field := range def.Order {
+			f := def.Fields[field]
+			members = append(members, member{off + f.Offset, f.Type, name + "." + field})
+			if def.IsUnion {
+				break // only the first union member can be initialized
+			}
+		}
+	}
+
+	for _, m := range members {
+		if *pos >= len(elems) {
+			return nil
+		}
+		e := elems[*pos]
+		isAggregate := m.typ.IsArray || isStructValue(m.typ)
+
+		if str, ok := e.(*StringLiteral); ok && m.typ.IsArray && m.typ.IsChar &&
+			m.typ.PointerLevel == 0 && len(m.typ.ArraySizes) == 1 {
+			if len(str.Value) > m.typ.ArraySizes[0] {
+				return fmt.Errorf("initializer-string for char array %s is too long", m.name)
+			}
+			copy(img[m.off:], str.Value)
+			*pos++
+			continue
+		}
+		if list, ok := e.(*InitializerList); ok && isAggregate {
+			if err := cg.initAggregate(img, m.off, m.typ, list, m.name); err != nil {
+				return err
+			}
+			*pos++
+			continue
+		}
+		if isAggregate {
+			if err := cg.initMembers(img, m.off, m.typ, elems, pos, m.name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		val, ok := cg.globalConst(e)
+		if !ok {
+			return fmt.Errorf("initializer for global %s must be a constant", m.name)
+		}
+		img[m.off] = byte(val)
+		if !m.typ.IsChar || m.typ.PointerLevel > 0 {
+			img[m.off+1] = byte(val >> 8)
+		}
+		*pos++
+	}
+	return nil
+}
+
 // genStmt emits the instructions that carry out stmt.
 func (cg *CodeGen) genStmt(s Stmt) error {
 	switch n := s.(type) {
 
 	case *ExprStmt:
 		cg.comment("call: %s", n.Expr)
+		cg.checkEffect(n)
 		if err := cg.genExpr(n.Expr); err != nil {
 			return err
 		}
 
 	case *StructDecl:
 		// Define struct layout in symtable.
-		def := StructDef{
-			Name:   n.Name,
-			Fields: make(map[string]FieldInfo),
-			Size:   0,
-		}
-		byteOffset := 0
-		for _, field := range n.Fields {
-			size, err := cg.calcSize(field)
-			if err != nil {
-				return err
-			}
-
-			// field.Type info
-			typeInfo := TypeInfo{
-				IsArray:      field.IsArray,
-				ArraySizes:   field.ArraySizes,
-				IsStruct:     field.IsStruct,
-				StructName:   field.StructName,
-				IsChar:       field.IsChar,
-				PointerLevel: field.PointerLevel,
-				IsUnsigned:   field.IsUnsigned,
-			}
-
-			def.Fields[field.Name] = FieldInfo{Offset: byteOffset, Type: typeInfo}
-			byteOffset += size
+		def, err := cg.defineStruct(n)
+		if err != nil {
+			return err
+		}
+		if n.IsUnion {
+			cg.comment("union %s defined (size %d)", n.Name, def.Size)
+		} else {
+			cg.comment("struct %s defined (size %d)", n.Name, def.Size)
 		}
-		def.Size = byteOffset
-		cg.syms.DefineStruct(def)
-		cg.comment("struct %s defined (size %d)", n.Name, def.Size)
 
 	case *VariableDecl:
 		size, err := cg.calcSize(*n)
@@ -1036,30 +2013,22 @@ func (cg *CodeGen) genStmt(s Stmt) error {
 		cg.comment("var %s (size %d) at offset %d", n.Name, size, sym.Address)
 
 		if n.Init != nil {
-			if n.IsArray || n.IsStruct {
+			cg.checkConversion(n.Line, typeInfo, n.Init)
+			if n.IsArray || (n.IsStruct && n.PointerLevel == 0) {
+				if str, isStr := n.Init.(*StringLiteral); isStr && n.IsChar && n.IsArray {
+					return cg.genCharArrayInit(sym, str.Value)
+				}
 				if list, isList := n.Init.(*InitializerList); isList {
 					// Local array initialization
 					vals := make([]uint16, 0, len(list.Elements))
-					keyBuilder := strings.Builder{}
-					for i, elem := range list.Elements {
+					for _, elem := range list.Elements {
 						lit, ok := elem.(*Literal)
 						if !ok {
 							return fmt.Errorf("local array initializer must be constant")
 						}
 						vals = append(vals, lit.Value)
-						if i > 0 {
-							keyBuilder.WriteString(",")
-						}
-						fmt.Fprintf(&keyBuilder, "%d", lit.Value)
-					}
-					key := keyBuilder.String()
-
-					label, ok := cg.dataCache[key]
-					if !ok {
-						label = cg.newDataLabel()
-						cg.dataCache[key] = label
-						cg.dataPool[label] = vals
 					}
+					label := cg.internData(vals)
 
 					// Calculate Destination Address (R1)
 					if sym.Scope == ScopeGlobal {
@@ -1114,6 +2083,14 @@ func (cg *CodeGen) genStmt(s Stmt) error {
 		// If LHS is *ptr = ...
 		// genAddress handles *ptr.
 
+		if n.Op == ASSIGN {
+			cg.checkConversion(n.Line, lhsType, n.Value)
+			if dst, src, ok := cg.globalWordCopy(n); ok {
+				cg.line("    MOVM %s, %s    ; %s = %s", dst.Label, src.Label, n.Left, n.Value)
+				return nil
+			}
+		}
+
 		if err := cg.genAddress(n.Left); err != nil {
 			return err
 		}
@@ -1139,18 +2116,49 @@ func (cg *CodeGen) genStmt(s Stmt) error {
 			cg.line("    POP R1") // Restore current value (LHS)
 			// R0 is RHS, R1 is LHS.
 			// Result goes to R0.
+			isByte := lhsType.IsChar && lhsType.PointerLevel == 0 && !lhsType.IsArray && !lhsType.IsStruct
 			switch n.Op {
 			case PLUS_ASSIGN:
-				cg.line("    ADD R1, R0")
+				cg.line("    %s R1, R0", cg.aluOp("ADD", isByte))
 				cg.line("    MOV R0, R1")
 			case MINUS_ASSIGN:
-				cg.line("    SUB R1, R0")
+				cg.line("    %s R1, R0", cg.aluOp("SUB", isByte))
 				cg.line("    MOV R0, R1")
 			case STAR_ASSIGN:
 				cg.line("    MUL R1, R0")
 				cg.line("    MOV R0, R1")
 			case SLASH_ASSIGN:
-				cg.line("    DIV R1, R0")
+				if lhsType.IsUnsigned {
+					cg.line("    DIV R1, R0")
+				} else {
+					cg.line("    IDIV R1, R0")
+				}
+				cg.line("    MOV R0, R1")
+			case PERCENT_ASSIGN:
+				// Remainder is a - (a / b) * b, as for the binary % operator.
+				cg.line("    MOV R3, R1")
+				if lhsType.IsUnsigned {
+					cg.line("    DIV R1, R0")
+				} else {
+					cg.line("    IDIV R1, R0")
+				}
+				cg.line("    MUL R1, R0")
+				cg.line("    SUB R3, R1")
+				cg.line("    MOV R0, R3")
+			case AND_ASSIGN:
+				cg.line("    %s R1, R0", cg.aluOp("AND", isByte))
+				cg.line("    MOV R0, R1")
+			case PIPE_ASSIGN:
+				cg.line("    OR  R1, R0")
+				cg.line("    MOV R0, R1")
+			case CARET_ASSIGN:
+				cg.line("    XOR R1, R0")
+				cg.line("    MOV R0, R1")
+			case SHL_ASSIGN:
+				cg.line("    SHL R1, R0")
+				cg.line("    MOV R0, R1")
+			case SHR_ASSIGN:
+				cg.line("    SHR R1, R0")
 				cg.line("    MOV R0, R1")
 			default:
 				return fmt.Errorf("codegen: unknown assignment op %s", n.Op)
@@ -1244,6 +2252,30 @@ func (cg *CodeGen) genStmt(s Stmt) error {
 
 		cg.loopStack = cg.loopStack[:len(cg.loopStack)-1]
 
+	case *DoWhileStmt:
+		cg.comment("do-while %s", n.Condition)
+		startLabel := cg.newLabel()
+		condLabel := cg.newLabel()
+		endLabel := cg.newLabel()
+
+		// For do-while loops, continue jumps to the condition check
+		cg.loopStack = append(cg.loopStack, LoopLabel{Start: startLabel, End: endLabel, Post: condLabel})
+
+		cg.line("%s:", startLabel)
+		if err := cg.genStmt(n.Body); err != nil {
+			return err
+		}
+		cg.line("%s:", condLabel)
+		if err := cg.genExpr(n.Condition); err != nil {
+			return err
+		}
+		cg.line("    LDI R1, 0")
+		cg.line("    SUB R0, R1")
+		cg.line("    JNZ %s", startLabel)
+		cg.line("%s:", endLabel)
+
+		cg.loopStack = cg.loopStack[:len(cg.loopStack)-1]
+
 	case *ForStmt:
 		if cg.syms.inFunction() {
 			cg.syms.EnterScope()
@@ -1304,8 +2336,40 @@ func (cg *CodeGen) genStmt(s Stmt) error {
 			return fmt.Errorf("continue statement outside of loop")
 		}
 		label := cg.loopStack[len(cg.loopStack)-1].Post
+		if label == "" {
+			return fmt.Errorf("continue statement outside of loop")
+		}
+		cg.line("    JMP %s", label)
+
+	case *GotoStmt:
+		label, ok := cg.labels[n.Name]
+		if !ok {
+			return fmt.Errorf("line %d: goto to undefined label %q in function %s", n.Line, n.Name, cg.currentFunction)
+		}
+		// Balance the switch targets on the stack: drop those of switches
+		// being left, and push placeholders for switches being entered so
+		// their endLabel POP still matches. Bodies never reread the target.
+		for d := cg.switchDepth; d > cg.labelDepths[n.Name]; d-- {
+			cg.line("    POP R1")
+		}
+		for d := cg.switchDepth; d < cg.labelDepths[n.Name]; d++ {
+			cg.line("    PUSH R1")
+		}
 		cg.line("    JMP %s", label)
 
+	case *LabelStmt:
+		cg.line("%s:", cg.labels[n.Name])
+
+	case *TypedefDecl:
+		cg.syms.DefineTypedef(n.Name, n.Type)
+
+	case *EnumDecl:
+		for _, m := range n.Members {
+			if !cg.syms.DefineConst(m.Name, m.Value) {
+				return fmt.Errorf("redeclaration of enumerator %q with a different value", m.Name)
+			}
+		}
+
 	case *AsmStmt:
 		cg.line("%s", n.Instruction)
 
@@ -1320,10 +2384,16 @@ func (cg *CodeGen) genStmt(s Stmt) error {
 		cg.line("    PUSH R0")
 
 		endLabel := cg.newLabel()
+		defaultLabel := endLabel
+		if len(n.Default) > 0 {
+			defaultLabel = cg.newLabel()
+		}
 
-		for _, clause := range n.Cases {
-			caseLabel := cg.newLabel()
-			nextCaseLabel := cg.newLabel()
+		// Dispatch: compare the target against every case value up front so
+		// that the bodies below can be laid out back to back and fall through.
+		caseLabels := make([]string, len(n.Cases))
+		for i, clause := range n.Cases {
+			caseLabels[i] = cg.newLabel()
 
 			// Get target from stack (PEEK) into R1
 			// Note: LDSP gets SP. In GoCPU, SP points to last pushed value.
@@ -1337,23 +2407,37 @@ func (cg *CodeGen) genStmt(s Stmt) error {
 
 			// Compare R1 (target) == R0 (case)
 			cg.line("    SUB R1, R0")
-			cg.line("    JZ  %s", caseLabel)
-			cg.line("    JMP %s", nextCaseLabel)
+			cg.line("    JZ  %s", caseLabels[i])
+		}
+		cg.line("    JMP %s", defaultLabel)
+
+		// break leaves through endLabel, which drops the target. continue
+		// belongs to the enclosing loop, so route it through a stub that
+		// drops the target first.
+		entry := LoopLabel{End: endLabel}
+		if len(cg.loopStack) > 0 {
+			if outer := cg.loopStack[len(cg.loopStack)-1].Post; outer != "" {
+				entry.Post = cg.newLabel()
+				cg.line("%s:", entry.Post)
+				cg.line("    POP R0")
+				cg.line("    JMP %s", outer)
+			}
+		}
+		cg.loopStack = append(cg.loopStack, entry)
+		cg.switchDepth++
 
-			cg.line("%s:", caseLabel)
-			// Generate body
+		for i, clause := range n.Cases {
+			cg.line("%s:", caseLabels[i])
 			for _, stmt := range clause.Body {
 				if err := cg.genStmt(stmt); err != nil {
 					return err
 				}
 			}
-			cg.line("    JMP %s", endLabel)
-
-			cg.line("%s:", nextCaseLabel)
 		}
 
 		// Default case
 		if len(n.Default) > 0 {
+			cg.line("%s:", defaultLabel)
 			for _, stmt := range n.Default {
 				if err := cg.genStmt(stmt); err != nil {
 					return err
@@ -1361,6 +2445,9 @@ func (cg *CodeGen) genStmt(s Stmt) error {
 			}
 		}
 
+		cg.loopStack = cg.loopStack[:len(cg.loopStack)-1]
+		cg.switchDepth--
+
 		cg.line("%s:", endLabel)
 		cg.line("    POP R0") // Discard target from stack
 
@@ -1380,9 +2467,21 @@ func (cg *CodeGen) genStmt(s Stmt) error {
 			return err
 		}
 
+		cg.labels = make(map[string]string)
+		cg.labelDepths = make(map[string]int)
+		if err := cg.collectLabels(n.Body, 0); err != nil {
+			return err
+		}
+
 		// Calculate total stack frame size: body locals + spilled register params
 		spilledSize := int(-cg.syms.nextLocal)
 		totalFrameSize := localsSize + spilledSize
+		if cg.opts.ZeroLocals {
+			// Round up so FILL can clear the frame in whole words without
+			// touching the saved FP above it.
+			totalFrameSize += totalFrameSize % 2
+		}
+		cg.frameSizes[n.Name] = totalFrameSize
 
 		cg.line("%s:", n.Name)
 		cg.line("    PUSH R2")
@@ -1393,6 +2492,12 @@ func (cg *CodeGen) genStmt(s Stmt) error {
 			cg.line("    LDSP R3")
 			cg.line("    SUB R3, R1")
 			cg.line("    STSP R3")
+
+			if cg.opts.ZeroLocals {
+				cg.line("    LDI R1, %d", totalFrameSize/2)
+				cg.line("    LDI R0, 0")
+				cg.line("    FILL R3, R1, R0   ; zero locals")
+			}
 		}
 
 		// Spill register arguments (R4-R7) to their local stack slots
@@ -1431,6 +2536,8 @@ func (cg *CodeGen) genStmt(s Stmt) error {
 		}
 
 		cg.currentFunction = ""
+		cg.labels = nil
+		cg.labelDepths = nil
 		cg.syms.ExitFunction()
 		cg.line("%s:", skipLabel)
 
@@ -1441,16 +2548,84 @@ func (cg *CodeGen) genStmt(s Stmt) error {
 }
 
 func Generate(stmts []Stmt, syms *SymbolTable) (string, error) {
+	return GenerateWithOptions(stmts, syms, Options{})
+}
+
+// GenerateWithOptions is Generate with optional code generation behaviour.
+func GenerateWithOptions(stmts []Stmt, syms *SymbolTable, opts Options) (string, error) {
+	code, _, err := GenerateWithStats(stmts, syms, opts)
+	return code, err
+}
+
+// intrinsics are the call names handled by genIntrinsic rather than by a
+// function in the program.
+var intrinsics = map[string]bool{
+	"static_assert": true,
+	"fmul":          true,
+	"fdiv":          true,
+	"min":           true,
+	"max":           true,
+	"clamp":         true,
+	"cycles":        true,
+}
+
+// checkCalls reports the first call to a name that is neither a function
+// defined or declared (by prototype) in the program nor an intrinsic.
+func checkCalls(stmts []Stmt) error {
+	declared := make(map[string]bool)
+	for _, s := range stmts {
+		if f, ok := s.(*FunctionDecl); ok {
+			declared[f.Name] = true
+		}
+	}
+
+	var err error
+	visit := func(c *FunctionCall) {
+		if err == nil && !declared[c.Name] && !intrinsics[c.Name] {
+			err = fmt.Errorf("line %d: call to undefined function %q", c.Line, c.Name)
+		}
+	}
+	for _, s := range stmts {
+		switch d := s.(type) {
+		case *FunctionDecl:
+			walkCallsStmt(d.Body, visit)
+		case *VariableDecl:
+			walkCallsExpr(d.Init, visit)
+		}
+	}
+	return err
+}
+
+// GenerateWithStats is GenerateWithOptions that also reports the stack frame
+// size in bytes of every generated function (locals plus spilled register
+// parameters, excluding the saved frame pointer and return address).
+func GenerateWithStats(stmts []Stmt, syms *SymbolTable, opts Options) (string, map[string]int, error) {
+	if err := checkCalls(stmts); err != nil {
+		return "", nil, err
+	}
+
+	// Prototypes have served their purpose once every call is resolved.
+	var defs []Stmt
+	for _, s := range stmts {
+		if f, ok := s.(*FunctionDecl); ok && f.Body == nil {
+			continue
+		}
+		defs = append(defs, s)
+	}
+	stmts = defs
+
 	// 1. Run Dead Code Elimination
 	stmts = eliminateDeadFunctions(stmts)
 
 	cg := newCodeGen(syms)
+	cg.opts = opts
 
-	// 0. Process Struct Declarations
+	// 0. Process Struct, Enum and Typedef Declarations
 	for _, s := range stmts {
-		if decl, ok := s.(*StructDecl); ok {
+		switch decl := s.(type) {
+		case *StructDecl, *EnumDecl, *TypedefDecl:
 			if err := cg.genStmt(decl); err != nil {
-				return "", err
+				return "", nil, err
 			}
 		}
 	}
@@ -1458,6 +2633,9 @@ func Generate(stmts []Stmt, syms *SymbolTable) (string, error) {
 	// 1. PRE-PASS: Allocate Global Symbols (to prevent redeclaration errors)
 	for _, s := range stmts {
 		if decl, ok := s.(*VariableDecl); ok {
+			if _, isConst := cg.syms.Const(decl.Name); isConst {
+				return "", nil, fmt.Errorf("global %q redeclares an enumerator", decl.Name)
+			}
 			size, _ := cg.calcSize(*decl)
 			typeInfo := TypeInfo{
 				IsArray:      decl.IsArray,
@@ -1472,6 +2650,24 @@ func Generate(stmts []Stmt, syms *SymbolTable) (string, error) {
 		}
 	}
 
+	for _, s := range stmts {
+		if decl, ok := s.(*VariableDecl); ok && decl.Init != nil {
+			sym, _ := cg.syms.Lookup(decl.Name)
+			cg.checkConversion(decl.Line, sym.Type, decl.Init)
+		}
+	}
+
+	// Top-level static_assert checks (the only expression statements allowed here).
+	for _, s := range stmts {
+		if es, ok := s.(*ExprStmt); ok {
+			if call, ok := es.Expr.(*FunctionCall); ok {
+				if _, err := cg.genIntrinsic(call); err != nil {
+					return "", nil, err
+				}
+			}
+		}
+	}
+
 	// 2. Entry Point & Interrupt Vector
 	hasISR, hasMain := false, false
 	for _, s := range stmts {
@@ -1526,9 +2722,12 @@ func Generate(stmts []Stmt, syms *SymbolTable) (string, error) {
 				if _, isList := decl.Init.(*InitializerList); isList {
 					continue
 				}
+				if _, isStr := decl.Init.(*StringLiteral); isStr && decl.IsArray {
+					continue // char array image emitted in the data section
+				}
 
 				if err := cg.genExpr(decl.Init); err != nil {
-					return "", err
+					return "", nil, err
 				}
 				sym, _ := cg.syms.Lookup(decl.Name)
 				cg.line("    LDI R1, %s", sym.Label)
@@ -1567,7 +2766,7 @@ func Generate(stmts []Stmt, syms *SymbolTable) (string, error) {
 		if _, ok := s.(*FunctionDecl); ok {
 			cg.out.WriteByte('\n')
 			if err := cg.genStmt(s); err != nil {
-				return "", err
+				return "", nil, err
 			}
 		}
 	}
@@ -1599,31 +2798,24 @@ func Generate(stmts []Stmt, syms *SymbolTable) (string, error) {
 		handled := false
 
 		if initExpr != nil {
-			// Helper function to resolve basic literals and negative literals
-			resolveConstant := func(e Expr) (uint16, bool) {
-				if lit, ok := e.(*Literal); ok {
-					return lit.Value, true
-				}
-				if un, ok := e.(*UnaryExpr); ok && un.Op == MINUS {
-					if lit, ok := un.Right.(*Literal); ok {
-						return uint16(-int16(lit.Value)), true // 2's complement
-					}
+			if str, ok := initExpr.(*StringLiteral); ok && sym.Type.IsArray {
+				// char msg[N] = "..."; bytes packed two per word, zero-padded
+				for _, w := range charArrayImage(str.Value, sym.Size) {
+					cg.line(".WORD %d", w)
 				}
-				return 0, false
-			}
-
-			if val, ok := resolveConstant(initExpr); ok {
+				handled = true
+			} else if val, ok := cg.globalConst(initExpr); ok {
 				// Handle scalar
 				cg.line(".WORD %d", val)
 				handled = true
 			} else if list, ok := initExpr.(*InitializerList); ok {
-				// Handle array
-				for _, elem := range list.Elements {
-					if val, ok := resolveConstant(elem); ok {
-						cg.line(".WORD %d", val)
-					} else {
-						return "", fmt.Errorf("global arrays must be initialized with constant values")
-					}
+				// Handle arrays and structs, laid out field by field
+				words, err := cg.globalImage(name, sym, list)
+				if err != nil {
+					return "", nil, err
+				}
+				for _, w := range words {
+					cg.line(".WORD %d", w)
 				}
 				handled = true
 			}
@@ -1652,9 +2844,7 @@ func Generate(stmts []Stmt, syms *SymbolTable) (string, error) {
 					break
 				}
 			}
-			// Escape special characters for the assembler
-			val = strings.ReplaceAll(val, "\n", "\\n")
-			cg.line("%s: .STRING \"%s\"", label, val)
+			cg.line("%s: .STRING \"%s\"", label, asmEscape(val))
 		}
 	}
 
@@ -1670,5 +2860,5 @@ func Generate(stmts []Stmt, syms *SymbolTable) (string, error) {
 		}
 	}
 
-	return cg.out.String(), nil
+	return optimize(cg.out.String()), cg.frameSizes, nil
 }
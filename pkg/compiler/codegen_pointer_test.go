@@ -52,3 +52,59 @@ func TestCodeGen_MultiLevelPointers(t *testing.T) {
 		t.Error("Expected LD instructions")
 	}
 }
+
+func TestCodeGen_PointerDifference(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want uint16
+	}{
+		{"Int", `int a[8]; int main() { int *p = &a[6]; int *q = &a[1]; return p - q; }`, 5},
+		{"Negative", `int a[8]; int main() { int *p = &a[1]; int *q = &a[6]; return p - q; }`, 0xFFFB},
+		{"Char", `char s[8]; int main() { char *p = &s[7]; char *q = &s[2]; return p - q; }`, 5},
+		{"Struct", `struct P { int x; int y; int z; };
+			struct P ps[4];
+			int main() { struct P *p = &ps[3]; struct P *q = &ps[0]; return p - q; }`, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if regs := runCode(t, tt.src); regs[0] != tt.want {
+				t.Errorf("result = %d, expected %d", regs[0], tt.want)
+			}
+		})
+	}
+
+	// Only a pointer difference is divided by the element size.
+	asm := generateWith(t, `int main() { int x = 7; int y = 3; return x - y; }`, Options{})
+	if strings.Contains(asm, "IDIV") {
+		t.Errorf("int subtraction scaled like a pointer difference:\n%s", asm)
+	}
+}
+
+func TestCodeGen_NegativePointerIndex(t *testing.T) {
+	src := `
+	int a[4];
+	int main() {
+		a[1] = 11;
+		a[2] = 22;
+		int *p = &a[2];
+		int i = -1;
+		return p[-1] + p[i] * 100;
+	}
+	`
+	if regs := runCode(t, src); regs[0] != 11+11*100 {
+		t.Errorf("p[-1] + p[i]*100 = %d, expected %d", regs[0], 11+11*100)
+	}
+
+	chars := `
+	char s[4];
+	int main() {
+		s[0] = 5;
+		char *p = &s[1];
+		return p[-1];
+	}
+	`
+	if regs := runCode(t, chars); regs[0] != 5 {
+		t.Errorf("char p[-1] = %d, expected 5", regs[0])
+	}
+}
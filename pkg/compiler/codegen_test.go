@@ -13,6 +13,13 @@ func assertContains(t *testing.T, code, expected string) {
 	}
 }
 
+func assertNotContains(t *testing.T, code, unexpected string) {
+	t.Helper()
+	if strings.Contains(code, unexpected) {
+		t.Errorf("Expected code not to contain %q.\nCode:\n%s", unexpected, code)
+	}
+}
+
 func TestGenerate_GlobalVars(t *testing.T) {
 	syms := NewSymbolTable()
 	stmts := []Stmt{
@@ -322,9 +329,10 @@ func TestPointerArithmetic(t *testing.T) {
 
 	assertContains(t, code, "ADD R1, R0")
 
-	assertContains(t, code, "PUSH R0")
+	// The address stays in R1 while the value is loaded: the peephole pass
+	// drops the PUSH R1/POP R1 around the constant.
 	assertContains(t, code, "LDI R0, 10")
-	assertContains(t, code, "POP R1")
+	assertNotContains(t, code, "PUSH R1")
 	assertContains(t, code, "ST  [R1], R0")
 }
 
@@ -387,6 +395,42 @@ func TestGenerate_Modulo(t *testing.T) {
 	assertContains(t, code, "MOV R0, R3")
 }
 
+func TestGenerate_ModuloSignedness(t *testing.T) {
+	gen := func(unsigned bool) string {
+		t.Helper()
+		syms := NewSymbolTable()
+		stmts := []Stmt{
+			&VariableDecl{Name: "v1", Init: &Literal{Value: 7}, IsUnsigned: unsigned},
+			&VariableDecl{Name: "v2", Init: &Literal{Value: 3}, IsUnsigned: unsigned},
+			&VariableDecl{Name: "e", Init: &BinaryExpr{Op: PERCENT, Left: &VarRef{Name: "v1"}, Right: &VarRef{Name: "v2"}}},
+			&FunctionDecl{Name: "main", Body: &BlockStmt{}}, // Trigger __init
+		}
+		code, err := Generate(stmts, syms)
+		if err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		return code
+	}
+
+	signed := gen(false)
+	assertContains(t, signed, "IDIV R1, R0")
+
+	unsigned := gen(true)
+	assertContains(t, unsigned, "DIV R1, R0")
+	if strings.Contains(unsigned, "IDIV") {
+		t.Errorf("unsigned modulo should use DIV, not IDIV:\n%s", unsigned)
+	}
+}
+
+func TestGenerate_CompoundBitwiseAssign(t *testing.T) {
+	code, err := compileSource(`int main() { int x = 1; x |= 8; return x; }`)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	assertContains(t, code, "; x PIPE_ASSIGN ...")
+	assertContains(t, code, "OR  R1, R0")
+}
+
 func TestGenerate_Shifts(t *testing.T) {
 	syms := NewSymbolTable()
 	stmts := []Stmt{
@@ -527,6 +571,10 @@ func TestGenerate_ComplexCalls(t *testing.T) {
 	// Declare functions to avoid lookup errors
 	syms.Allocate("foo", TypeInfo{}, 1)
 	syms.Allocate("bar", TypeInfo{}, 1)
+	prototypes := []Stmt{
+		&FunctionDecl{Name: "foo", ReturnType: "int"},
+		&FunctionDecl{Name: "bar", ReturnType: "int"},
+	}
 
 	// foo(bar(1))
 	stmts1 := []Stmt{
@@ -549,16 +597,14 @@ func TestGenerate_ComplexCalls(t *testing.T) {
 			},
 		},
 	}
-	code1, err := Generate(stmts1, syms)
+	code1, err := Generate(append(prototypes, stmts1...), syms)
 	if err != nil {
 		t.Fatalf("Generate failed: %v", err)
 	}
-	// bar(1) called first
-	assertContains(t, code1, "LDI R0, 1")
-	assertContains(t, code1, "PUSH R0")
-	assertContains(t, code1, "CALL bar")
-	// result pushed
-	assertContains(t, code1, "PUSH R0")
+	// bar(1) called first, its argument moved into R4
+	assertContains(t, code1, "LDI R0, 1\n    MOV R4, R0\n    CALL bar")
+	// result passed on in R4
+	assertContains(t, code1, "CALL bar\n    MOV R4, R0\n    CALL foo")
 	// foo called
 	assertContains(t, code1, "CALL foo")
 
@@ -586,12 +632,12 @@ func TestGenerate_ComplexCalls(t *testing.T) {
 			},
 		},
 	}
-	code2, err := Generate(stmts2, syms)
+	code2, err := Generate(append(prototypes, stmts2...), syms)
 	if err != nil {
 		t.Fatalf("Generate failed: %v", err)
 	}
 	assertContains(t, code2, "ADD R1, R0") // Result in R0
-	assertContains(t, code2, "PUSH R0")    // Push arg
+	assertContains(t, code2, "MOV R4, R0") // Pass arg
 	assertContains(t, code2, "CALL foo")
 }
 
@@ -1,20 +1,30 @@
 package compiler
 
 import (
+	"errors"
 	"fmt"
 	"gocpu/pkg/asm"
+	"reflect"
+	"sort"
 
 	"os"
 )
 
 func Compile(src string, baseDir string) (*string, []byte, error) {
+	assembly, machineCode, _, err := CompileWithSymbols(src, baseDir)
+	return assembly, machineCode, err
+}
+
+// CompileWithSymbols is like Compile but also returns the address of every
+// global variable and generated function, for debuggers.
+func CompileWithSymbols(src string, baseDir string) (*string, []byte, *SymbolMap, error) {
 
 	// Preprocess
 	var err error
 	src, err = Preprocess(src, baseDir)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "preprocess error:", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	// fmt.Printf("Source:\n%s\n", src)
@@ -22,29 +32,210 @@ func Compile(src string, baseDir string) (*string, []byte, error) {
 	tokens, err := Lex(src)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "lex error:", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	stmts, err := Parse(tokens, src)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "parse error:", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	syms := NewSymbolTable()
-	assembly, err := Generate(stmts, syms)
+	assembly, frameSizes, err := GenerateWithStats(stmts, syms, Options{
+		Warn: func(w Warning) { fmt.Fprintln(os.Stderr, w) },
+	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "codegen error:", err)
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	// fmt.Println("Assembly:\n", assembly)
 
+	assembler := asm.NewAssembler()
+	machineCode, _, err := assembler.Assemble(assembly)
+	if err != nil {
+		return &assembly, nil, nil, assemblyError(err)
+	}
+
+	return &assembly, machineCode, buildSymbolMap(syms, frameSizes, assembler), nil
+
+}
+
+// CompileMulti compiles several translation units into a single image.
+// Each source is preprocessed and parsed on its own (defines do not leak
+// between files), then the top-level declarations are merged before code
+// generation. Keys of sources are file names used in error messages; files are
+// processed in sorted name order so output is deterministic.
+//
+// Defining the same function or global in two files is an error, unless both
+// definitions are identical (as happens when files share an #include).
+func CompileMulti(sources map[string]string, baseDir string) (*string, []byte, error) {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type symbolKey struct {
+		kind string // "function", "global" or "struct"
+		name string
+	}
+	type definition struct {
+		file  string
+		stmt  Stmt
+		index int // position in merged
+	}
+	defined := make(map[symbolKey]definition)
+
+	var merged []Stmt
+	for _, name := range names {
+		src, err := Preprocess(sources[name], baseDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "preprocess error:", err)
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		tokens, err := Lex(src)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "lex error:", err)
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		stmts, err := Parse(tokens, src)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "parse error:", err)
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		for _, s := range stmts {
+			var key symbolKey
+			switch d := s.(type) {
+			case *FunctionDecl:
+				key = symbolKey{"function", d.Name}
+			case *VariableDecl:
+				key = symbolKey{"global", d.Name}
+			case *StructDecl:
+				key = symbolKey{"struct", d.Name}
+			default:
+				merged = append(merged, s)
+				continue
+			}
+
+			if prev, ok := defined[key]; ok {
+				if sameDefinition(prev.stmt, s) {
+					continue // same definition from a shared include
+				}
+				if fn, ok := s.(*FunctionDecl); ok {
+					// A prototype merges with the definition it declares.
+					prevFn := prev.stmt.(*FunctionDecl)
+					if prevFn.Body == nil || fn.Body == nil {
+						if prevFn.Body == nil {
+							merged[prev.index] = fn
+							defined[key] = definition{file: name, stmt: fn, index: prev.index}
+						}
+						continue
+					}
+				}
+				if decl, ok := s.(*VariableDecl); ok {
+					// An uninitialised global (e.g. "extern int x;") is only a
+					// declaration; it merges with a definition of the same type.
+					prevDecl := prev.stmt.(*VariableDecl)
+					if sameGlobalType(prevDecl, decl) && (prevDecl.Init == nil || decl.Init == nil) {
+						if prevDecl.Init == nil {
+							merged[prev.index] = decl
+							defined[key] = definition{file: name, stmt: decl, index: prev.index}
+						}
+						continue
+					}
+				}
+				return nil, nil, fmt.Errorf("%s: duplicate definition of %s %q (first defined in %s)",
+					name, key.kind, key.name, prev.file)
+			}
+			defined[key] = definition{file: name, stmt: s, index: len(merged)}
+			merged = append(merged, s)
+		}
+	}
+
+	syms := NewSymbolTable()
+	assembly, err := Generate(merged, syms)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "codegen error:", err)
+		return nil, nil, err
+	}
+
 	machineCode, _, err := asm.Assemble(assembly)
 	if err != nil {
-		return &assembly, nil, fmt.Errorf("assembly error: %v", err)
+		return &assembly, nil, assemblyError(err)
 	}
 
 	return &assembly, machineCode, nil
+}
+
+// assemblyError reports a failure to assemble the generated code. The
+// assembler can only point at a line of generated assembly, so a program that
+// outgrows memory gets a message of its own; asm.ErrProgramTooLarge stays
+// visible to errors.Is.
+func assemblyError(err error) error {
+	if errors.Is(err, asm.ErrProgramTooLarge) {
+		return fmt.Errorf("compiled program does not fit in the 64 KB address space: %w", err)
+	}
+	return fmt.Errorf("assembly error: %v", err)
+}
+
+// sameGlobalType reports whether two global declarations differ only in their
+// initialiser.
+func sameGlobalType(a, b *VariableDecl) bool {
+	x, y := *a, *b
+	x.Init, y.Init = nil, nil
+	x.Line, y.Line = 0, 0
+	return reflect.DeepEqual(x, y)
+}
+
+// sameDefinition reports whether two top-level statements are structurally
+// identical. Source line numbers are ignored, since the same #include lands
+// at a different line in each file.
+func sameDefinition(a, b Stmt) bool {
+	return equalIgnoringLines(reflect.ValueOf(a), reflect.ValueOf(b))
+}
 
+func equalIgnoringLines(a, b reflect.Value) bool {
+	if a.IsValid() != b.IsValid() {
+		return false
+	}
+	if !a.IsValid() {
+		return true
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		return equalIgnoringLines(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if a.Type().Field(i).Name == "Line" {
+				continue
+			}
+			if !equalIgnoringLines(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !equalIgnoringLines(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a.Equal(b)
+	}
 }
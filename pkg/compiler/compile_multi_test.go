@@ -0,0 +1,148 @@
+package compiler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gocpu/pkg/asm"
+	"gocpu/pkg/cpu"
+)
+
+func runImage(t *testing.T, machineCode []byte) *cpu.CPU {
+	t.Helper()
+	vm := cpu.NewCPU()
+	copy(vm.Memory[:], machineCode)
+	for i := 0; i < 10000 && !vm.Halted; i++ {
+		vm.Step()
+	}
+	return vm
+}
+
+func TestCompileMulti(t *testing.T) {
+	sources := map[string]string{
+		"main.c": `
+		#define SCALE 3
+		extern int offset;
+		int triple(int x);
+		int main() {
+			return triple(4) * SCALE + offset;
+		}
+		`,
+		"util.c": `
+		#define SCALE 1
+		int offset = 5;
+		int triple(int x) {
+			return x * 3 * SCALE;
+		}
+		`,
+	}
+
+	_, mc, err := CompileMulti(sources, ".")
+	if err != nil {
+		t.Fatalf("CompileMulti failed: %v", err)
+	}
+	vm := runImage(t, mc)
+	// triple(4) = 12 (util.c SCALE=1), * 3 (main.c SCALE=3) = 36, + 5 = 41
+	if vm.Regs[0] != 41 {
+		t.Errorf("expected 41, got %d", vm.Regs[0])
+	}
+}
+
+func TestCompileMulti_SharedInclude(t *testing.T) {
+	// Both files pull in the same library; identical definitions are merged.
+	sources := map[string]string{
+		"a.c": `#include <stdio.c>
+		int main() { return helper() + strlen("abc"); }`,
+		"b.c": `#include <stdio.c>
+		int helper() { return strlen("hello"); }`,
+	}
+	_, mc, err := CompileMulti(sources, ".")
+	if err != nil {
+		t.Fatalf("CompileMulti failed: %v", err)
+	}
+	vm := runImage(t, mc)
+	if vm.Regs[0] != 8 {
+		t.Errorf("expected 8, got %d", vm.Regs[0])
+	}
+}
+
+func TestCompileMulti_SharedIncludeAtDifferentLines(t *testing.T) {
+	// The include lands on a different source line in each file; the
+	// definitions are still identical and must merge.
+	sources := map[string]string{
+		"a.c": `#include <stdio.c>
+		int main() { return helper(); }`,
+		"b.c": `// helper lives here
+
+		#include <stdio.c>
+		int helper() { return strlen("hi"); }`,
+	}
+	_, mc, err := CompileMulti(sources, ".")
+	if err != nil {
+		t.Fatalf("CompileMulti failed: %v", err)
+	}
+	vm := runImage(t, mc)
+	if vm.Regs[0] != 2 {
+		t.Errorf("expected 2, got %d", vm.Regs[0])
+	}
+}
+
+func TestCompileMulti_Duplicates(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources map[string]string
+		wantErr string
+	}{
+		{
+			name: "function",
+			sources: map[string]string{
+				"a.c": "int f() { return 1; } int main() { return f(); }",
+				"b.c": "int f() { return 2; }",
+			},
+			wantErr: `b.c: duplicate definition of function "f" (first defined in a.c)`,
+		},
+		{
+			name: "global",
+			sources: map[string]string{
+				"a.c": "int g = 1; int main() { return g; }",
+				"b.c": "int g = 2;",
+			},
+			wantErr: `b.c: duplicate definition of global "g" (first defined in a.c)`,
+		},
+		{
+			name: "global type mismatch",
+			sources: map[string]string{
+				"a.c": "int g = 1; int main() { return g; }",
+				"b.c": "char g;",
+			},
+			wantErr: `duplicate definition of global "g"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := CompileMulti(tt.sources, ".")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCompile_ProgramTooLarge(t *testing.T) {
+	src := `int big[40000];
+	int main() { big[1] = 2; return big[1]; }`
+	_, code, err := Compile(src, ".")
+	if !errors.Is(err, asm.ErrProgramTooLarge) {
+		t.Fatalf("Compile error = %v, want asm.ErrProgramTooLarge", err)
+	}
+	if !strings.Contains(err.Error(), "64 KB") || code != nil {
+		t.Errorf("error %q (code %d bytes), want a 64 KB message and no code", err, len(code))
+	}
+
+	_, _, err = CompileMulti(map[string]string{"a.c": src}, ".")
+	if !errors.Is(err, asm.ErrProgramTooLarge) {
+		t.Errorf("CompileMulti error = %v, want asm.ErrProgramTooLarge", err)
+	}
+}
@@ -3,6 +3,9 @@ package compiler
 import (
 	"strings"
 	"testing"
+
+	"gocpu/pkg/asm"
+	"gocpu/pkg/cpu"
 )
 
 func TestControlFlow(t *testing.T) {
@@ -107,3 +110,288 @@ func TestControlFlow(t *testing.T) {
 		})
 	}
 }
+
+func TestDoWhile_Codegen(t *testing.T) {
+	src := `
+	int main() {
+		int x = 0;
+		do {
+			x = x + 1;
+		} while (x < 3);
+		return x;
+	}
+	`
+	tokens, err := Lex(src)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, src)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	code, err := Generate(stmts, NewSymbolTable())
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	// Body label, body, condition label, condition, JNZ back to body label.
+	bodyIdx := strings.Index(code, "; do-while")
+	if bodyIdx < 0 {
+		t.Fatalf("missing do-while comment:\n%s", code)
+	}
+	rest := code[bodyIdx:]
+	lines := strings.Split(rest, "\n")
+	bodyLabel := strings.TrimSuffix(strings.TrimSpace(lines[1]), ":")
+	if !strings.HasPrefix(bodyLabel, "L") {
+		t.Fatalf("expected body label after comment, got %q", lines[1])
+	}
+
+	bodyPos := strings.Index(rest, "ST  [R1], R0") // x = x + 1
+	condPos := strings.Index(rest, "JNZ "+bodyLabel)
+	if bodyPos < 0 || condPos < 0 {
+		t.Fatalf("missing body or back-edge JNZ %s:\n%s", bodyLabel, rest)
+	}
+	if bodyPos > condPos {
+		t.Errorf("expected body to precede condition check:\n%s", rest)
+	}
+}
+
+func TestDoWhile_E2E(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"runs until false", "int main() { int x = 0; do { x = x + 1; } while (x < 5); return x; }", 5},
+		{"runs at least once", "int main() { int x = 10; do { x = x + 1; } while (0); return x; }", 11},
+		{"single statement body", "int main() { int x = 0; do x = x + 2; while (x < 7); return x; }", 8},
+		{"break", "int main() { int x = 0; do { x = x + 1; if (x == 3) break; } while (1); return x; }", 3},
+		{"continue checks condition", `
+		int main() {
+			int i = 0;
+			int sum = 0;
+			do {
+				i = i + 1;
+				if (i == 2) continue;
+				sum = sum + i;
+			} while (i < 4);
+			return sum;
+		}`, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, regs[0])
+			}
+		})
+	}
+}
+
+func TestSwitchFallthrough_E2E(t *testing.T) {
+	const classify = `
+	int classify(int x) {
+		int y = 0;
+		switch (x) {
+			case 1: y = y + 1;
+			case 2: y = y + 10;
+			case 3: y = y + 100; break;
+			case 4: y = 1000; break;
+			default: y = 5;
+		}
+		return y;
+	}
+	`
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"no break falls through", classify + "int main() { return classify(1); }", 111},
+		{"falls through from middle", classify + "int main() { return classify(2); }", 110},
+		{"break stops", classify + "int main() { return classify(4); }", 1000},
+		{"default", classify + "int main() { return classify(9); }", 5},
+		{"last case falls into default", `
+		int main() {
+			int y = 0;
+			switch (2) {
+				case 1: y = 1; break;
+				case 2: y = 2;
+				default: y = y * 3;
+			}
+			return y;
+		}`, 6},
+		{"no match and no default", `
+		int main() {
+			int y = 7;
+			switch (3) { case 1: y = 1; case 2: y = 2; }
+			return y;
+		}`, 7},
+		{"continue targets enclosing loop", `
+		int main() {
+			int sum = 0;
+			for (int i = 0; i < 5; i++) {
+				switch (i) {
+					case 2: continue;
+					case 3: sum = sum + 100; break;
+				}
+				sum = sum + i;
+			}
+			return sum;
+		}`, 108},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, regs[0])
+			}
+		})
+	}
+}
+
+func TestSwitch_ContinueOutsideLoop(t *testing.T) {
+	src := `int main() { switch (1) { case 1: continue; } return 0; }`
+	tokens, err := Lex(src)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, src)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, err := Generate(stmts, NewSymbolTable()); err == nil || !strings.Contains(err.Error(), "continue") {
+		t.Errorf("expected continue-outside-loop error, got %v", err)
+	}
+}
+
+func TestGoto_E2E(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"backward loop", `
+		int main() {
+			int i = 0;
+		again:
+			i = i + 1;
+			if (i < 5) goto again;
+			return i;
+		}`, 5},
+		{"forward skip", `
+		int main() {
+			int x = 1;
+			goto done;
+			x = 99;
+		done:
+			return x;
+		}`, 1},
+		{"escape nested loops", `
+		int main() {
+			int n = 0;
+			for (int i = 0; i < 10; i++) {
+				for (int j = 0; j < 10; j++) {
+					n = n + 1;
+					if (i == 2 && j == 3) goto out;
+				}
+			}
+		out:
+			return n;
+		}`, 24},
+		{"into and out of switches", `
+		int main() {
+			int n = 0;
+			switch (1) {
+			case 1:
+				n = n + 1;
+				goto inner;
+			}
+			switch (2) {
+			case 2:
+				switch (3) {
+				case 3:
+				inner:
+					n = n + 10;
+					if (n < 30) goto inner;
+					goto out;
+				}
+			}
+		out:
+			return n;
+		}`, 31},
+		{"same label name in two functions", `
+		int f() { int r = 3; goto end; r = 0; end: return r; }
+		int main() { int r = 4; goto end; r = 0; end: return r + f(); }`, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, regs[0])
+			}
+		})
+	}
+}
+
+func TestGoto_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"undefined label", "int main() { goto nowhere; return 0; }", `undefined label "nowhere"`},
+		{"label in other function", "int f() { here: return 0; }\nint main() { goto here; return 0; }", `undefined label "here"`},
+		{"duplicate label", "int main() { a: a: return 0; }", `duplicate label "a"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Lex(tt.src)
+			if err != nil {
+				t.Fatalf("Lex failed: %v", err)
+			}
+			stmts, err := Parse(tokens, tt.src)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			_, err = Generate(stmts, NewSymbolTable())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestGoto_OutOfSwitchKeepsStack(t *testing.T) {
+	// A goto-driven state machine leaves its switch every iteration; each
+	// exit must drop the switch target or the stack grows into the code.
+	src := `
+	int main() {
+		int n = 0;
+	top:
+		n++;
+		if (n == 32000) return n;
+		switch (n & 1) {
+		case 1:
+			goto top;
+		default:
+			goto top;
+		}
+		return 0;
+	}`
+	code := generateWith(t, src, Options{})
+	mc, _, err := asm.Assemble(code)
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+	res, err := cpu.RunProgram(mc, cpu.Options{MaxSteps: 5000000})
+	if err != nil {
+		t.Fatalf("RunProgram failed: %v", err)
+	}
+	if res.Reason != cpu.HaltInstruction || res.Regs[0] != 32000 {
+		t.Errorf("halted by %v with R0 = %d, want HLT with 32000", res.Reason, res.Regs[0])
+	}
+	if res.SP != 0xB5FE {
+		t.Errorf("SP = 0x%04X after the run, want 0xB5FE", res.SP)
+	}
+}
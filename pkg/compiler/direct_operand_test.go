@@ -0,0 +1,62 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBinaryDirectOperand_Codegen(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		pushPop  bool
+		contains string
+	}{
+		{"literal", "a + 1", false, "MOV R1, R0\n    LDI R0, 1\n    ADD R1, R0"},
+		{"local", "a + b", false, "MOV R1, R0\n    LDX R0, [R2 + 65532]    ; b (local/param)"},
+		{"global", "a + g", false, "MOV R1, R0\n    LDI R0, g    ; &g (global)\n    LD  R0, [R0]"},
+		{"call", "a + f()", true, "CALL f"},
+		{"nested", "a + (b * 2)", true, "MUL R1, R0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := `
+			int g;
+			int f() { return 3; }
+			int main() {
+				int a = 1;
+				int b = 2;
+				return ` + tt.expr + `;
+			}
+			`
+			code := generateWith(t, src, Options{})
+			body := code[strings.Index(code, "main:"):]
+			if got := strings.Contains(body, "PUSH R0"); got != tt.pushPop {
+				t.Errorf("PUSH R0 present = %v, want %v:\n%s", got, tt.pushPop, body)
+			}
+			assertContains(t, body, tt.contains)
+		})
+	}
+}
+
+func TestBinaryDirectOperand_Run(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want uint16
+	}{
+		{"local int", `int main() { int a = 7; int b = 5; return a - b; }`, 2},
+		{"local char", `int main() { char c = 200; int a = 1000; return a - c; }`, 800},
+		{"global char", `char c = 3; int main() { int a = 10; return a * c; }`, 30},
+		{"global pointer", `int x[4]; int *p = x; int *q = x; int main() { q = &x[3]; return q - p; }`, 3},
+		{"enumerator", `enum { K = 6 }; int main() { int a = 2; return a << K; }`, 128},
+		{"comparison", `int main() { int a = 3; int b = 4; return (a < b) + (a >= b); }`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runWith(t, tt.src, Options{}); got != tt.want {
+				t.Errorf("main returned %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
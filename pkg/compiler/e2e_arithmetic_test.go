@@ -2,6 +2,7 @@ package compiler
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -112,6 +113,135 @@ func TestCompoundAssignment_E2E(t *testing.T) {
 	}
 }
 
+func TestPrefixVsPostfix_E2E(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected uint16
+	}{
+		// Result of the expression itself.
+		{"PrefixInc", "int x = 5; int a = ++x; return a;", 6},
+		{"PostfixInc", "int x = 5; int a = x++; return a;", 5},
+		{"PrefixDec", "int x = 5; int a = --x; return a;", 4},
+		{"PostfixDec", "int x = 5; int a = x--; return a;", 5},
+		// Both forms update the variable.
+		{"PrefixUpdates", "int x = 5; ++x; ++x; --x; return x;", 6},
+		{"InExpression", "int x = 5; return ++x * 10 + x;", 66},
+		{"CharWraps", "char c = 255; int a = ++c; return a * 256 + c;", 0},
+		{"ArrayElement", "int arr[2]; arr[1] = 9; int a = ++arr[1]; return a + arr[1];", 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, "int main() { "+tt.body+" }")
+			if regs[0] != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, regs[0])
+			}
+		})
+	}
+}
+
+func TestPrefix_AddressOfRejected(t *testing.T) {
+	_, err := compileSource(`int main() { int x = 1; int *p = &(++x); return 0; }`)
+	if err == nil || !strings.Contains(err.Error(), "not an lvalue") {
+		t.Errorf("expected not-an-lvalue error, got %v", err)
+	}
+}
+
+func TestCompoundBitwiseAssignment_E2E(t *testing.T) {
+	tests := []struct {
+		stmt     string
+		expected uint16
+	}{
+		{"x %= 7;", 100 % 7},
+		{"x &= 0x0F;", 100 & 0x0F},
+		{"x |= 0x04;", 100 | 0x04},
+		{"x ^= 0xFF;", 100 ^ 0xFF},
+		{"x <<= 2;", 100 << 2},
+		{"x >>= 3;", 100 >> 3},
+	}
+	for _, tt := range tests {
+		src := fmt.Sprintf("int main() { int x = 100; %s return x; }", tt.stmt)
+		regs := runCode(t, src)
+		if regs[0] != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.stmt, tt.expected, regs[0])
+		}
+	}
+
+	t.Run("SignedModulo", func(t *testing.T) {
+		regs := runCode(t, "int main() { int x = -7; x %= 3; return x; }")
+		if int16(regs[0]) != -1 {
+			t.Errorf("signed %%=: expected -1, got %d", int16(regs[0]))
+		}
+	})
+}
+
+func TestCompoundDivision_Signedness(t *testing.T) {
+	t.Run("Signed", func(t *testing.T) {
+		src := `
+		int main() {
+			int x = -10;
+			x /= 3; // IDIV: -3
+			return x;
+		}
+		`
+		regs := runCode(t, src)
+		if int16(regs[0]) != -3 {
+			t.Errorf("signed /=: expected -3, got %d", int16(regs[0]))
+		}
+	})
+
+	t.Run("Unsigned", func(t *testing.T) {
+		src := `
+		int main() {
+			unsigned int x = 0xFFF6;
+			x /= 2; // DIV: 32763
+			return x;
+		}
+		`
+		regs := runCode(t, src)
+		if regs[0] != 32763 {
+			t.Errorf("unsigned /=: expected 32763, got %d", regs[0])
+		}
+	})
+
+	t.Run("Codegen", func(t *testing.T) {
+		signed, err := compileSource(`int main() { int x = 9; x /= 2; return x; }`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(signed, "IDIV R1, R0") {
+			t.Errorf("signed /= should emit IDIV:\n%s", signed)
+		}
+		unsigned, err := compileSource(`int main() { unsigned x = 9; x /= 2; return x; }`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(unsigned, "IDIV") {
+			t.Errorf("unsigned /= should emit DIV, not IDIV:\n%s", unsigned)
+		}
+	})
+}
+
+func TestCompoundAssignment_CharStoresByte(t *testing.T) {
+	// Each compound op on a char must wrap at 8 bits and must not clobber
+	// the neighbouring byte.
+	src := `
+	char buf[2];
+	int main() {
+		buf[0] = 250;
+		buf[1] = 7;
+		buf[0] += 10; // 260 -> 4
+		buf[0] -= 5;  // -1  -> 255
+		buf[0] *= 2;  // 510 -> 254
+		return buf[0] + buf[1] * 1000;
+	}
+	`
+	regs := runCode(t, src)
+	if regs[0] != 7254 {
+		t.Errorf("char compound assignment: expected 7254, got %d", regs[0])
+	}
+}
+
 func TestPostfix_E2E(t *testing.T) {
 	src := `
 	int main() {
@@ -87,3 +87,38 @@ func TestStructWithBytes(t *testing.T) {
 		t.Errorf("Expected 3, got %d", res)
 	}
 }
+
+func TestBytePostfixIncrement(t *testing.T) {
+	// b sits directly below a on the stack; a word-sized store would clobber a.
+	res := runByteTest(t, "int main() { char a = 1; char b = 255; b++; return a; }")
+	if res != 1 {
+		t.Errorf("Expected neighbouring char to stay 1, got %d", res)
+	}
+
+	res = runByteTest(t, "int main() { char a = 1; char b = 255; b++; return b; }")
+	if res != 0 {
+		t.Errorf("Expected 0 (overflow), got %d", res)
+	}
+
+	res = runByteTest(t, "int main() { char a = 7; char b = 0; b--; return a; }")
+	if res != 7 {
+		t.Errorf("Expected neighbouring char to stay 7, got %d", res)
+	}
+}
+
+func TestCharFunctionTypes(t *testing.T) {
+	code := `
+	char next(char c) { return c + 1; }
+	int main() {
+		char buf[2];
+		char *p = buf;
+		p[0] = next('A');
+		p[1] = 0;
+		return buf[0];
+	}
+	`
+	res := runByteTest(t, code)
+	if res != 'B' {
+		t.Errorf("Expected 'B' (66), got %d", res)
+	}
+}
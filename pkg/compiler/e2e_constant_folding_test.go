@@ -157,6 +157,42 @@ func TestConstantFolding_CodeGen(t *testing.T) {
 			t.Error("unsigned constant folding should not emit DIV; expected a single LDI")
 		}
 	})
+
+	// Each initializer below is a constant, so the code between the
+	// declaration comment and the local's address must be one LDI.
+	src := `int main() {
+		int x = -(5);
+		int y = ~0;
+		int z = !0;
+		int le = 3 <= 4;
+		int ge = (-1) >= 1;
+		return x + y + z + le + ge;
+	}`
+	code := generateWith(t, src, Options{})
+	for _, tt := range []struct {
+		name string
+		want string
+	}{
+		{"x", "LDI R0, 65531"},
+		{"y", "LDI R0, 65535"},
+		{"z", "LDI R0, 1"},
+		{"le", "LDI R0, 1"},
+		{"ge", "LDI R0, 0"},
+	} {
+		t.Run("FoldsToSingleLDI_"+tt.name, func(t *testing.T) {
+			start := strings.Index(code, "; var "+tt.name+" ")
+			if start < 0 {
+				t.Fatalf("no declaration of %s in:\n%s", tt.name, code)
+			}
+			lines := strings.Split(code[start:], "\n")
+			if got := strings.TrimSpace(lines[1]); got != tt.want || !strings.HasPrefix(lines[2], "    MOV R1, R2") {
+				t.Errorf("%s initializer:\n%s\nwant the single instruction %q", tt.name, strings.Join(lines[1:4], "\n"), tt.want)
+			}
+		})
+	}
+	if got := runWith(t, src, Options{}); int16(got) != -5-1+1+1+0 {
+		t.Errorf("main returned %d, want %d", int16(got), -5-1+1+1+0)
+	}
 }
 
 // TestUnsignedLiteral_Lexer checks that the u-suffix tokens are lexed correctly.
@@ -192,3 +228,29 @@ func TestUnsignedLiteral_Lexer(t *testing.T) {
 		}
 	}
 }
+
+func TestBinaryLiterals(t *testing.T) {
+	code, err := compileSource(`
+	int main() {
+		int x = 0b101;
+		return x;
+	}
+	`)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	assertContains(t, code, "LDI R0, 5")
+
+	regs := runCode(t, `int main() { return 0b1111000011110000 | 0B1111; }`)
+	if regs[0] != 0xF0FF {
+		t.Errorf("expected 0xF0FF, got 0x%04X", regs[0])
+	}
+
+	tokens, err := Lex(`int main() { return 0b11111111111111111; }`)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	if _, err := Parse(tokens, ""); err == nil || !strings.Contains(err.Error(), "out of 16-bit range") {
+		t.Errorf("expected an out-of-range error for a 17-bit literal, got %v", err)
+	}
+}
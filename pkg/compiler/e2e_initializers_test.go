@@ -1,6 +1,9 @@
 package compiler
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestInitializers_E2E(t *testing.T) {
 	t.Run("GlobalArray", func(t *testing.T) {
@@ -56,3 +59,184 @@ func TestInitializers_E2E(t *testing.T) {
 		}
 	})
 }
+
+func TestCharArrayStringInit(t *testing.T) {
+	t.Run("ParseSizeInference", func(t *testing.T) {
+		src := `char msg[] = "hello"; char buf[16] = "hi";`
+		tokens, err := Lex(src)
+		if err != nil {
+			t.Fatalf("Lex failed: %v", err)
+		}
+		stmts, err := Parse(tokens, src)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		msg := stmts[0].(*VariableDecl)
+		if msg.ArraySizes[0] != 6 {
+			t.Errorf("msg size = %d, want 6 (5 chars + null)", msg.ArraySizes[0])
+		}
+		if lit, ok := msg.Init.(*StringLiteral); !ok || lit.Value != "hello" {
+			t.Errorf("msg init = %v, want string literal", msg.Init)
+		}
+		if buf := stmts[1].(*VariableDecl); buf.ArraySizes[0] != 16 {
+			t.Errorf("buf size = %d, want 16", buf.ArraySizes[0])
+		}
+	})
+
+	t.Run("ParseTooLong", func(t *testing.T) {
+		src := `char s[2] = "abc";`
+		tokens, err := Lex(src)
+		if err != nil {
+			t.Fatalf("Lex failed: %v", err)
+		}
+		if _, err := Parse(tokens, src); err == nil || !strings.Contains(err.Error(), "too long") {
+			t.Errorf("expected too-long error, got %v", err)
+		}
+	})
+
+	t.Run("CodegenLocalCopiesFromData", func(t *testing.T) {
+		code, err := compileSource(`int main() { char msg[] = "hello"; return msg[0]; }`)
+		if err != nil {
+			t.Fatalf("compile failed: %v", err)
+		}
+		// "hello\0" packed little-endian: "he" = 0x6568, "ll" = 0x6C6C, "o\0" = 0x006F.
+		for _, want := range []string{"COPY R0, R1, R2", "LDI R2, 3", "D0:", ".WORD 25960", ".WORD 27756", ".WORD 111"} {
+			assertContains(t, code, want)
+		}
+	})
+
+	t.Run("CodegenGlobalEmitsBytes", func(t *testing.T) {
+		code, err := compileSource(`char g[] = "hi"; int main() { return g[1]; }`)
+		if err != nil {
+			t.Fatalf("compile failed: %v", err)
+		}
+		// g[3]: "hi" = 0x6968, then the null and one padding byte.
+		assertContains(t, code, "g:\n.WORD 26984\n.WORD 0")
+		if strings.Contains(code, "COPY") {
+			t.Errorf("global char array should be initialized statically:\n%s", code)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"local inferred size", `
+		int main() {
+			char msg[] = "hello";
+			return sizeof(msg) * 1000 + msg[1] + msg[5];
+		}`, 6000 + 'e'},
+		{"local zero fills the rest", `
+		int main() {
+			char buf[16];
+			int i;
+			for (i = 0; i < 16; i++) { buf[i] = 'x'; }
+			char dst[16] = "hi";
+			int sum = 0;
+			for (i = 2; i < 16; i++) { sum = sum + dst[i]; }
+			return dst[0] + dst[1] + sum;
+		}`, 'h' + 'i'},
+		{"odd size keeps neighbour", `
+		int main() {
+			char before = 'B';
+			char s[3] = "ab";
+			char after = 'A';
+			return s[0] + s[1] + s[2] + before + after;
+		}`, 'a' + 'b' + 'B' + 'A'},
+		{"global", `
+		char greeting[] = "hey";
+		char pad[8] = "ok";
+		int main() { return greeting[2] + pad[1] + pad[7] + sizeof(greeting); }`, 'y' + 'k' + 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, regs[0])
+			}
+		})
+	}
+}
+
+func TestGlobalStructInit(t *testing.T) {
+	t.Run("CodegenTwoFieldStruct", func(t *testing.T) {
+		code, err := compileSource(`struct Point { int x; int y; };
+		struct Point p = {10, 20};
+		int main() { return p.y; }`)
+		if err != nil {
+			t.Fatalf("compile failed: %v", err)
+		}
+		assertContains(t, code, "p:\n.WORD 10\n.WORD 20\n")
+	})
+
+	t.Run("CodegenPacksCharFields", func(t *testing.T) {
+		code, err := compileSource(`struct Rec { char a; char b; int n; };
+		struct Rec r = {1, 2, 0x1234};
+		int main() { return r.n; }`)
+		if err != nil {
+			t.Fatalf("compile failed: %v", err)
+		}
+		// a and b share the first word: 0x0201.
+		assertContains(t, code, "r:\n.WORD 513\n.WORD 4660\n")
+	})
+
+	t.Run("TooManyInitializers", func(t *testing.T) {
+		_, err := compileSource(`struct Point { int x; int y; };
+		struct Point p = {1, 2, 3};
+		int main() { return 0; }`)
+		if err == nil || !strings.Contains(err.Error(), "too many initializers for p") {
+			t.Errorf("expected too many initializers error, got %v", err)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"fields in declaration order", `
+		struct Point { int x; int y; };
+		struct Point p = {3, -4};
+		int main() { return p.x * 10 + p.y; }`, 26},
+		{"missing fields are zero", `
+		struct Point { int x; int y; };
+		struct Point p = {7};
+		int main() { return p.x * 10 + p.y; }`, 70},
+		{"char field packing", `
+		struct Rec { char tag; int value; char flag; };
+		struct Rec r = {'A', 1000, 1};
+		int main() { return r.tag + r.value + r.flag; }`, 'A' + 1000 + 1},
+		{"nested struct with braces", `
+		struct Point { int x; int y; };
+		struct Line { struct Point a; struct Point b; int color; };
+		struct Line l = {{1, 2}, {3, 4}, 5};
+		int main() { return l.a.x + l.a.y * 10 + l.b.x * 100 + l.b.y * 1000 + l.color * 10000; }`, 54321},
+		{"nested struct braces elided", `
+		struct Point { int x; int y; };
+		struct Line { struct Point a; struct Point b; };
+		struct Line l = {1, 2, 3};
+		int main() { return l.a.x + l.a.y * 10 + l.b.x * 100 + l.b.y * 1000; }`, 321},
+		{"array of structs", `
+		struct Point { int x; int y; };
+		struct Point pts[3] = {{1, 2}, {3, 4}};
+		int main() { return pts[0].y + pts[1].x * 10 + pts[2].y * 100 + sizeof(pts); }`, 2 + 30 + 12},
+		{"array and string members", `
+		struct Entry { char name[4]; int vals[2]; };
+		struct Entry e = {"ab", {5, 6}};
+		int main() { return e.name[1] + e.name[3] + e.vals[0] * e.vals[1]; }`, 'b' + 30},
+		{"enumerator values", `
+		enum { LEFT = 1, RIGHT };
+		struct Move { int dir; int steps; };
+		struct Move m = {RIGHT, 5};
+		int main() { return m.dir * 10 + m.steps; }`, 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, regs[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package compiler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse_Sizeof(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Expr
+	}{
+		{"int", "int x = sizeof(int);", &SizeofExpr{Type: INT}},
+		{"char pointer", "int x = sizeof(char*);", &SizeofExpr{Type: CHAR, PointerLevel: 1}},
+		{"unsigned int", "int x = sizeof(unsigned int);", &SizeofExpr{Type: INT, IsUnsigned: true}},
+		{"struct", "int x = sizeof(struct Point);", &SizeofExpr{Type: STRUCT, StructName: "Point"}},
+		{"parenthesised expr", "int x = sizeof(arr);", &SizeofExpr{Expr: &VarRef{Name: "arr"}}},
+		{"bare expr", "int x = sizeof arr;", &SizeofExpr{Expr: &VarRef{Name: "arr"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Lex(tt.input)
+			if err != nil {
+				t.Fatalf("Lex failed: %v", err)
+			}
+			stmts, err := Parse(tokens, tt.input)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			decl, ok := stmts[0].(*VariableDecl)
+			if !ok {
+				t.Fatalf("expected VariableDecl, got %T", stmts[0])
+			}
+			if !reflect.DeepEqual(decl.Init, tt.expected) {
+				t.Errorf("Parse mismatch:\nGot:      %v\nExpected: %v", decl.Init, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSizeof_E2E(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"int", "int main() { return sizeof(int); }", 2},
+		{"char", "int main() { return sizeof(char); }", 1},
+		{"pointer type", "int main() { return sizeof(char*); }", 2},
+		{"struct type", "struct Point { int x; int y; char c; }; int main() { return sizeof(struct Point); }", 5},
+		{"int array", "int main() { int arr[10]; return sizeof(arr); }", 20},
+		{"2D char array", "int main() { char grid[3][4]; return sizeof(grid); }", 12},
+		{"struct array", "struct P { int x; int y; }; int main() { struct P ps[3]; return sizeof ps; }", 12},
+		{"pointer variable", "int main() { int arr[10]; int *p = arr; return sizeof(p); }", 2},
+		{"array element", "int main() { char buf[8]; return sizeof(buf[0]); }", 1},
+		{"string literal", `int main() { return sizeof("abc"); }`, 4},
+		{"global initializer", "int arr[6]; int n = sizeof(arr); int main() { return n; }", 12},
+		{"not evaluated", "int g = 0; int main() { int s = sizeof(g++); return g + s; }", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, regs[0])
+			}
+		})
+	}
+}
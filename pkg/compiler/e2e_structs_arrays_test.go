@@ -55,6 +55,158 @@ func TestStructs_E2E(t *testing.T) {
 			t.Errorf("Struct member read failed: expected 20, got %d", regs[0])
 		}
 	})
+
+	t.Run("IndexedMemberLoad", func(t *testing.T) {
+		src := `
+		struct Rec { char tag; int a; int b; };
+		int main() {
+			struct Rec r;
+			struct Rec* p = &r;
+			r.tag = 'x';
+			r.a = 5;
+			p->b = 7;
+			return p->b * 10 + r.a + (r.tag == 'x');
+		}
+		`
+		code, err := compileSource(src)
+		if err != nil {
+			t.Fatalf("compile failed: %v", err)
+		}
+		assertContains(t, code, "LDX R0, [R1 + 3]") // p->b
+		assertContains(t, code, "LDX R0, [R1 + 1]") // r.a
+		regs := runCode(t, src)
+		if regs[0] != 76 {
+			t.Errorf("indexed member load failed: expected 76, got %d", regs[0])
+		}
+	})
+}
+
+func TestInlineAnonymousStruct_E2E(t *testing.T) {
+	t.Run("NestedFieldAccess", func(t *testing.T) {
+		src := `
+		struct Outer {
+			int id;
+			struct { int x; int y; } pos;
+			struct { char r; struct { int lo; int hi; } range; } *extra;
+		};
+		struct Outer g;
+		int main() {
+			struct Outer o;
+			o.id = 1;
+			o.pos.x = 30;
+			o.pos.y = 4;
+			g.pos.y = 500;
+			struct Outer *p = &o;
+			p->pos.x = p->pos.x + 100;
+			return o.pos.x + o.pos.y + o.id + g.pos.y + sizeof(struct Outer);
+		}
+		`
+		// sizeof: id (2) + pos (4) + extra pointer (2) = 8.
+		regs := runCode(t, src)
+		if regs[0] != 643 {
+			t.Errorf("expected 643, got %d", regs[0])
+		}
+	})
+
+	t.Run("ParsedAsSyntheticStruct", func(t *testing.T) {
+		src := "struct Outer { struct { int x; int y; } pos; };"
+		tokens, err := Lex(src)
+		if err != nil {
+			t.Fatalf("Lex failed: %v", err)
+		}
+		stmts, err := Parse(tokens, src)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		outer := stmts[0].(*StructDecl)
+		if len(outer.Inline) != 1 || outer.Inline[0].Name != "Outer.anon0" {
+			t.Fatalf("expected one inline struct Outer.anon0, got %v", outer.Inline)
+		}
+		if f := outer.Fields[0]; !f.IsStruct || f.StructName != "Outer.anon0" {
+			t.Errorf("field pos = %+v, want struct Outer.anon0", f)
+		}
+	})
+
+	t.Run("RejectedOutsideStruct", func(t *testing.T) {
+		src := "int main() { struct { int x; } v; return 0; }"
+		tokens, err := Lex(src)
+		if err != nil {
+			t.Fatalf("Lex failed: %v", err)
+		}
+		if _, err := Parse(tokens, src); err == nil {
+			t.Error("expected an error for an anonymous struct variable")
+		}
+	})
+}
+
+func TestStructPointers_E2E(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"deref member read", `
+		struct Point { int x; int y; };
+		int main() {
+			struct Point pt;
+			pt.x = 3;
+			pt.y = 40;
+			struct Point *p = &pt;
+			return (*p).y;
+		}`, 40},
+		{"arrow write", `
+		struct Point { int x; int y; };
+		int main() {
+			struct Point pt;
+			struct Point *p = &pt;
+			p->x = 7;
+			p->y = p->x + 1;
+			return pt.x * 10 + pt.y;
+		}`, 78},
+		{"char member through pointer", `
+		struct Rec { char a; char b; int c; };
+		int main() {
+			struct Rec r;
+			struct Rec *p = &r;
+			p->a = 1;
+			p->b = 2;
+			p->c = 300;
+			return r.a + r.b + r.c;
+		}`, 303},
+		{"pointer indexing scales by struct size", `
+		struct Point { int x; int y; char tag; };
+		int main() {
+			struct Point pts[3];
+			pts[2].y = 99;
+			struct Point *p = &pts[0];
+			return p[2].y;
+		}`, 99},
+		{"linked nodes", `
+		struct Node { int v; struct Node *next; };
+		int main() {
+			struct Node a;
+			struct Node b;
+			a.v = 1;
+			b.v = 2;
+			a.next = &b;
+			b.next = 0;
+			return a.next->v;
+		}`, 2},
+		{"sizeof pointer", `
+		struct Point { int x; int y; char tag; };
+		int main() {
+			struct Point *p;
+			return sizeof(p) * 10 + sizeof(*p);
+		}`, 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, regs[0])
+			}
+		})
+	}
 }
 
 func TestPointers_E2E(t *testing.T) {
@@ -62,6 +62,36 @@ func TestUnsigned_E2E(t *testing.T) {
 		}
 	})
 
+	t.Run("SignedModuloNegative", func(t *testing.T) {
+		// int uses IDIV for the quotient, so -7 % 3 = -1
+		src := `
+		int main() {
+			int x = -7;
+			int y = 3;
+			return x % y;
+		}
+		`
+		regs := runCode(t, src)
+		if int16(regs[0]) != -1 {
+			t.Errorf("signed modulo: expected -1, got %d", int16(regs[0]))
+		}
+	})
+
+	t.Run("UnsignedModulo", func(t *testing.T) {
+		// 0xFFF9 = 65529 unsigned; 65529 % 3 = 0
+		src := `
+		int main() {
+			unsigned int x = 0xFFF9;
+			unsigned int y = 3;
+			return x % y;
+		}
+		`
+		regs := runCode(t, src)
+		if regs[0] != 0 {
+			t.Errorf("unsigned modulo: expected 0, got %d", regs[0])
+		}
+	})
+
 	t.Run("UnsignedLessThanLargeValue", func(t *testing.T) {
 		// 0x8000 = 32768 unsigned; 32768 < 5 is false.
 		// Signed JN would interpret 0x8000 as -32768, and (-32768 - 5) wraps to a
@@ -208,4 +238,42 @@ func TestUnsigned_E2E(t *testing.T) {
 			t.Errorf("unsigned arithmetic: expected 700, got %d", regs[0])
 		}
 	})
+	t.Run("CastToUnsignedDivision", func(t *testing.T) {
+		// The cast makes the division unsigned even for a signed operand.
+		src := `
+		int main() {
+			int a = -2;
+			return (unsigned)a / 2;
+		}
+		`
+		regs := runCode(t, src)
+		if regs[0] != 0x7FFF {
+			t.Errorf("(unsigned)a / 2: expected 0x7FFF, got 0x%04X", regs[0])
+		}
+	})
+
+	t.Run("CastToIntDivision", func(t *testing.T) {
+		src := `
+		int main() {
+			unsigned int a = 0xFFFE;
+			return (int)a / 2;
+		}
+		`
+		regs := runCode(t, src)
+		if int16(regs[0]) != -1 {
+			t.Errorf("(int)a / 2: expected -1, got %d", int16(regs[0]))
+		}
+	})
+
+	t.Run("ConstantCastToUnsigned", func(t *testing.T) {
+		src := `
+		int main() {
+			return (unsigned int)-2 / 2;
+		}
+		`
+		regs := runCode(t, src)
+		if regs[0] != 0x7FFF {
+			t.Errorf("(unsigned int)-2 / 2: expected 0x7FFF, got 0x%04X", regs[0])
+		}
+	})
 }
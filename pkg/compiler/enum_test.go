@@ -0,0 +1,172 @@
+package compiler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse_EnumValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *EnumDecl
+	}{
+		{
+			name:  "implicit values count from zero",
+			input: "enum Dir { NORTH, EAST, SOUTH, WEST };",
+			expected: &EnumDecl{Name: "Dir", Members: []Enumerator{
+				{"NORTH", 0}, {"EAST", 1}, {"SOUTH", 2}, {"WEST", 3},
+			}},
+		},
+		{
+			name:  "explicit value restarts the count",
+			input: "enum Color { RED, GREEN = 5, BLUE };",
+			expected: &EnumDecl{Name: "Color", Members: []Enumerator{
+				{"RED", 0}, {"GREEN", 5}, {"BLUE", 6},
+			}},
+		},
+		{
+			name:  "anonymous with negative, hex and enumerator values",
+			input: "enum { LOW = -2, MID, HIGH = 0x10, TOP = MID, };",
+			expected: &EnumDecl{Members: []Enumerator{
+				{"LOW", 0xFFFE}, {"MID", 0xFFFF}, {"HIGH", 0x10}, {"TOP", 0xFFFF},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Lex(tt.input)
+			if err != nil {
+				t.Fatalf("Lex failed: %v", err)
+			}
+			stmts, err := Parse(tokens, tt.input)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if len(stmts) != 1 || !reflect.DeepEqual(stmts[0], tt.expected) {
+				t.Errorf("Parse mismatch:\nGot:      %v\nExpected: %v", stmts, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParse_EnumErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"duplicate enumerator", "enum A { X, Y }; enum B { X };", `redeclaration of enumerator "X"`},
+		{"non-constant value", "enum A { X = y };", "not a constant"},
+		{"duplicate in one block", "int main() { enum { X }; enum { X = 2 }; return 0; }", `redeclaration of enumerator "X"`},
+		{"block enumerator used after its block", "int main() { { enum { X = 4 }; } enum { Y = X }; return 0; }", "not a constant"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Lex(tt.input)
+			if err != nil {
+				t.Fatalf("Lex failed: %v", err)
+			}
+			_, err = Parse(tokens, tt.input)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerate_EnumFoldsToConstant(t *testing.T) {
+	code, err := compileSource(`enum Color { RED, GREEN = 5, BLUE };
+	int main() {
+		return BLUE;
+	}`)
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	assertContains(t, code, "LDI R0, 6    ; BLUE")
+	if strings.Contains(code, "BLUE:") || strings.Contains(code, "LDI R1, BLUE") {
+		t.Errorf("enumerator must not be allocated as a variable:\n%s", code)
+	}
+}
+
+func TestGenerate_EnumErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"assign to enumerator", "enum { A }; int main() { A = 1; return 0; }", `cannot take address of enumerator "A"`},
+		{"global with enumerator name", "enum { A }; int A; int main() { return 0; }", `redeclares an enumerator`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileSource(tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEnum_E2E(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"case labels", `
+		enum State { IDLE, RUNNING = 4, DONE };
+		int step(enum State s) {
+			switch (s) {
+				case IDLE: return 10;
+				case RUNNING: return 20;
+				case DONE: return 30;
+			}
+			return 0;
+		}
+		int main() { return step(IDLE) + step(DONE); }`, 40},
+		{"array size", `
+		enum { SLOTS = 6 };
+		int table[SLOTS];
+		int main() {
+			int local[SLOTS];
+			return sizeof(table) + sizeof(local);
+		}`, 24},
+		{"global initializers", `
+		enum Color { RED, GREEN = 5, BLUE };
+		int palette[3] = { BLUE, GREEN, RED };
+		enum Color current = GREEN;
+		int main() { return palette[0] * 10 + current; }`, 65},
+		{"local shadows enumerator", `
+		enum { N = 3 };
+		int main() { int N = 9; return N; }`, 9},
+		{"enum return type", `
+		enum State { IDLE, RUNNING, DONE };
+		enum State next(enum State s) { return s + 1; }
+		int main() { enum State s = next(RUNNING); return s; }`, 2},
+		{"constant expressions", `
+		enum { W = 4, H = 3 };
+		int main() { int x = W * H + 1; return x; }`, 13},
+		{"block enums in two functions", `
+		int f() { enum { A = 2 }; return A; }
+		int g() { enum { A = 5 }; return A * 10; }
+		int main() { return f() + g(); }`, 52},
+		{"block enum shadows and then ends", `
+		enum { A = 1 };
+		int main() {
+			int x = 0;
+			{ enum { A = 7, B }; int arr[B]; x = A + sizeof(arr); }
+			return x * 10 + A;
+		}`, 231},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, regs[0])
+			}
+		})
+	}
+}
@@ -120,3 +120,70 @@ func TestFunctions(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateWithStats_FrameSizes(t *testing.T) {
+	src := `
+	int fill(int a, int b) {
+		int buf[10];
+		buf[0] = a + b;
+		return buf[0];
+	}
+	int main() {
+		return fill(1, 2);
+	}
+	`
+	tokens, err := Lex(src)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, src)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	_, sizes, err := GenerateWithStats(stmts, NewSymbolTable(), Options{})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	// 10 words of buf plus the two register parameters spilled to the frame.
+	if got, want := sizes["fill"], 10*2+2*2; got < want {
+		t.Errorf("frame size of fill = %d, want at least %d", got, want)
+	}
+	if got, ok := sizes["main"]; !ok || got != 0 {
+		t.Errorf("frame size of main = %d (present %v), want 0", got, ok)
+	}
+}
+
+func TestUndefinedFunctionCall(t *testing.T) {
+	_, err := compileSource(`
+	int main() {
+		int x = 1;
+		return helpr(x);
+	}
+	`)
+	if err == nil {
+		t.Fatal("expected an error for a call to an undefined function")
+	}
+	if !strings.Contains(err.Error(), `line 4: call to undefined function "helpr"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPrototypeThenUse(t *testing.T) {
+	code, err := compileSource(`
+	int twice(int n);
+	int main() {
+		return twice(21);
+	}
+	int twice(int n) {
+		return n * 2;
+	}
+	`)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	assertContains(t, code, "CALL twice")
+	if n := strings.Count(code, "\ntwice:"); n != 1 {
+		t.Errorf("expected one definition of twice, found %d:\n%s", n, code)
+	}
+}
@@ -0,0 +1,156 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"gocpu/pkg/cpu"
+)
+
+func TestFixedPointIntrinsics_E2E(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"fmul 1.0 * 2.0", "int main() { return fmul(0x0100, 0x0200); }", 0x0200},
+		{"fmul 1.5 * 1.5", "int main() { return fmul(0x0180, 0x0180); }", 0x0240},
+		{"fmul negative", "int main() { return fmul(-256, 0x0200); }", 0xFE00},
+		{"fdiv 2.0 / 1.0", "int main() { return fdiv(0x0200, 0x0100); }", 0x0200},
+		{"fdiv 1.0 / 2.0", "int main() { int a = 0x0100; int b = 0x0200; return fdiv(a, b); }", 0x0080},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected 0x%04X, got 0x%04X", tt.expected, regs[0])
+			}
+		})
+	}
+}
+
+func TestFixedPointIntrinsics_ArgCount(t *testing.T) {
+	src := "int main() { return fmul(1); }"
+	tokens, err := Lex(src)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, src)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, err := Generate(stmts, NewSymbolTable()); err == nil {
+		t.Error("expected error for fmul with one argument")
+	}
+}
+
+func TestMinMaxIntrinsics_Codegen(t *testing.T) {
+	code, err := compileSource(`
+	int main() {
+		int a = 3;
+		int b = 9;
+		return min(a, b);
+	}
+	`)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	assertContains(t, code, "MIN R0, R1")
+
+	code, err = compileSource(`
+	int main() {
+		unsigned a = 3;
+		return max(a, 9);
+	}
+	`)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	assertContains(t, code, "MAXU R0, R1")
+
+	code, err = compileSource(`
+	int main() {
+		int x = 300;
+		return clamp(x, 0, 255);
+	}
+	`)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	maxAt := strings.Index(code, "MAX R3, R1")
+	minAt := strings.Index(code, "MIN R3, R0")
+	if maxAt < 0 || minAt < maxAt {
+		t.Errorf("clamp should emit MAX then MIN:\n%s", code)
+	}
+}
+
+func TestMinMaxIntrinsics_E2E(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"min", "int main() { int a = 7; int b = 2; return min(a, b); }", 2},
+		{"max", "int main() { int a = 7; int b = 2; return max(a, b); }", 7},
+		{"min signed", "int main() { int a = -5; return min(a, 1); }", 0xFFFB},
+		{"min unsigned", "int main() { unsigned a = 65531; return min(a, 1); }", 1},
+		{"clamp high", "int main() { int x = 300; return clamp(x, 0, 255); }", 255},
+		{"clamp low", "int main() { int x = -4; return clamp(x, 0, 255); }", 0},
+		{"clamp inside", "int main() { int x = 42; return clamp(x, 0, 255); }", 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected 0x%04X, got 0x%04X", tt.expected, regs[0])
+			}
+		})
+	}
+}
+
+func TestMinMaxIntrinsics_ArgCount(t *testing.T) {
+	for _, src := range []string{
+		"int main() { return min(1); }",
+		"int main() { return max(1, 2, 3); }",
+		"int main() { return clamp(1, 2); }",
+	} {
+		if _, err := compileSource(src); err == nil {
+			t.Errorf("expected an argument count error for %q", src)
+		}
+	}
+}
+
+func TestCyclesIntrinsic_Codegen(t *testing.T) {
+	if cycleCountLoReg != cpu.CycleCountLoReg {
+		t.Fatalf("cycleCountLoReg = 0x%04X, CPU uses 0x%04X", cycleCountLoReg, cpu.CycleCountLoReg)
+	}
+	code, err := compileSource("int main() { return cycles(); }")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	assertContains(t, code, "LDI R3, 0xFF2A")
+	assertContains(t, code, "LD  R0, [R3]")
+
+	if _, err := compileSource("int main() { return cycles(1); }"); err == nil {
+		t.Error("expected an argument count error for cycles(1)")
+	}
+}
+
+func TestCyclesIntrinsic_E2E(t *testing.T) {
+	regs := runCode(t, `
+	int main() {
+		unsigned start = cycles();
+		int i;
+		int sum = 0;
+		for (i = 0; i < 20; i++) {
+			sum += i;
+		}
+		unsigned end = cycles();
+		return end - start;
+	}
+	`)
+	// Each iteration runs several instructions.
+	if regs[0] < 20*3 || regs[0] > 2000 {
+		t.Errorf("elapsed cycles = %d, want a count proportional to the 20-iteration loop", regs[0])
+	}
+}
@@ -3,6 +3,7 @@ package compiler
 import (
 	"fmt"
 	"unicode"
+	"unicode/utf8"
 )
 
 // keywords maps source text to its keyword TokenType.
@@ -14,6 +15,7 @@ var keywords = map[string]TokenType{
 	"if":       IF,
 	"else":     ELSE,
 	"while":    WHILE,
+	"do":       DO,
 	"return":   RETURN,
 	"struct":   STRUCT,
 	"for":      FOR,
@@ -23,6 +25,11 @@ var keywords = map[string]TokenType{
 	"default":  DEFAULT,
 	"break":    BREAK,
 	"continue": CONTINUE,
+	"goto":     GOTO,
+	"sizeof":   SIZEOF,
+	"enum":     ENUM,
+	"typedef":  TYPEDEF,
+	"union":    UNION,
 	"volatile": VOLATILE,
 	"const":    CONST,
 	"static":   STATIC,
@@ -118,7 +125,7 @@ func (l *Lexer) scanIdent() Token {
 	return Token{Type: tt, Lexeme: lexeme, Line: line}
 }
 
-// scanInt collects a decimal or hex integer literal, including an optional
+// scanInt collects a decimal, hex or binary integer literal, including an optional
 // u/U suffix that marks the literal as unsigned (e.g. 10u, 0xFFFFu).
 // The first digit must still be at l.peek().
 func (l *Lexer) scanInt() Token {
@@ -138,6 +145,12 @@ func (l *Lexer) scanInt() Token {
 				break
 			}
 		}
+	} else if l.peek() == '0' && (l.peek2() == 'b' || l.peek2() == 'B') {
+		l.advance() // consume '0'
+		l.advance() // consume 'b'
+		for l.pos < len(l.src) && (l.peek() == '0' || l.peek() == '1') {
+			l.advance()
+		}
 	} else {
 		// Normal decimal digits
 		for l.pos < len(l.src) && unicode.IsDigit(l.peek()) {
@@ -155,6 +168,72 @@ func (l *Lexer) scanInt() Token {
 	return Token{Type: INTEGER, Lexeme: string(l.src[start:l.pos]), Line: line}
 }
 
+// scanEscape consumes an escape sequence, starting at the backslash, and
+// returns the byte it denotes. Besides the single-character escapes it
+// accepts \xNN (one or two hex digits) and octal \NNN (one to three digits,
+// so \0 is the null byte).
+func (l *Lexer) scanEscape(line int) (byte, error) {
+	l.advance() // consume backslash
+	next := l.peek()
+
+	switch {
+	case next == 'x':
+		l.advance()
+		val, digits := 0, 0
+		for digits < 2 && isHexDigit(l.peek()) {
+			val = val*16 + hexValue(l.advance())
+			digits++
+		}
+		if digits == 0 {
+			return 0, fmt.Errorf("invalid hex escape \\x%c on line %d: expected hex digits", l.peek(), line)
+		}
+		return byte(val), nil
+	case next >= '0' && next <= '7':
+		val := 0
+		for digits := 0; digits < 3 && l.peek() >= '0' && l.peek() <= '7'; digits++ {
+			val = val*8 + int(l.advance()-'0')
+		}
+		if val > 0xFF {
+			return 0, fmt.Errorf("octal escape out of range on line %d", line)
+		}
+		return byte(val), nil
+	}
+
+	var val byte
+	switch next {
+	case 'n':
+		val = '\n'
+	case 'r':
+		val = '\r'
+	case 't':
+		val = '\t'
+	case '\\':
+		val = '\\'
+	case '\'':
+		val = '\''
+	case '"':
+		val = '"'
+	default:
+		return 0, fmt.Errorf("unknown escape sequence \\%c on line %d", next, line)
+	}
+	l.advance()
+	return val, nil
+}
+
+func isHexDigit(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
+
+func hexValue(r rune) int {
+	switch {
+	case r >= 'a':
+		return int(r-'a') + 10
+	case r >= 'A':
+		return int(r-'A') + 10
+	}
+	return int(r - '0')
+}
+
 // scanChar collects a character literal 'c'
 func (l *Lexer) scanChar() (Token, error) {
 	line := l.line
@@ -168,27 +247,11 @@ func (l *Lexer) scanChar() (Token, error) {
 	}
 
 	if r == '\\' {
-		l.advance() // consume backslash
-		next := l.peek()
-		switch next {
-		case 'n':
-			val = '\n'
-		case 'r':
-			val = '\r'
-		case 't':
-			val = '\t'
-		case '0':
-			val = 0
-		case '\\':
-			val = '\\'
-		case '\'':
-			val = '\''
-		case '"':
-			val = '"'
-		default:
-			return Token{}, fmt.Errorf("unknown escape sequence \\%c on line %d", next, line)
+		b, err := l.scanEscape(line)
+		if err != nil {
+			return Token{}, err
 		}
-		l.advance()
+		val = rune(b)
 	} else {
 		val = r
 		l.advance()
@@ -204,10 +267,12 @@ func (l *Lexer) scanChar() (Token, error) {
 }
 
 // scanString collects a string literal "..."
+// Escapes produce single bytes, so "\xFF" is one byte rather than the UTF-8
+// encoding of U+00FF.
 func (l *Lexer) scanString() (Token, error) {
 	line := l.line
 	l.advance() // consume opening "
-	var val []rune
+	var val []byte
 
 	for l.pos < len(l.src) {
 		r := l.peek()
@@ -218,24 +283,14 @@ func (l *Lexer) scanString() (Token, error) {
 			return Token{}, fmt.Errorf("unterminated string literal on line %d", line)
 		}
 		if r == '\\' {
-			l.advance() // consume backslash
-			next := l.peek()
-			switch next {
-			case 'n':
-				val = append(val, '\n')
-			case 't':
-				val = append(val, '\t')
-			case '"':
-				val = append(val, '"')
-			case '\\':
-				val = append(val, '\\')
-			default:
-				return Token{}, fmt.Errorf("unknown escape sequence \\%c on line %d", next, line)
+			b, err := l.scanEscape(line)
+			if err != nil {
+				return Token{}, err
 			}
-			l.advance()
+			val = append(val, b)
 			continue
 		}
-		val = append(val, r)
+		val = utf8.AppendRune(val, r)
 		l.advance()
 	}
 
@@ -313,6 +368,8 @@ func (l *Lexer) nextToken() (Token, error) {
 		return Token{COMMA, ",", line}, nil
 	case ':':
 		return Token{COLON, ":", line}, nil
+	case '?':
+		return Token{QUESTION, "?", line}, nil
 
 	case '+':
 		if l.peek() == '+' {
@@ -333,6 +390,10 @@ func (l *Lexer) nextToken() (Token, error) {
 			l.advance()
 			return Token{MINUS_ASSIGN, "-=", line}, nil
 		}
+		if l.peek() == '>' {
+			l.advance()
+			return Token{ARROW, "->", line}, nil
+		}
 		return Token{MINUS, "-", line}, nil
 	case '*':
 		if l.peek() == '=' {
@@ -351,18 +412,34 @@ func (l *Lexer) nextToken() (Token, error) {
 			l.advance()
 			return Token{AND_LOGICAL, "&&", line}, nil
 		}
+		if l.peek() == '=' {
+			l.advance()
+			return Token{AND_ASSIGN, "&=", line}, nil
+		}
 		return Token{AND, "&", line}, nil
 	case '|':
 		if l.peek() == '|' {
 			l.advance()
 			return Token{OR_LOGICAL, "||", line}, nil
 		}
+		if l.peek() == '=' {
+			l.advance()
+			return Token{PIPE_ASSIGN, "|=", line}, nil
+		}
 		return Token{PIPE, "|", line}, nil
 	case '^':
+		if l.peek() == '=' {
+			l.advance()
+			return Token{CARET_ASSIGN, "^=", line}, nil
+		}
 		return Token{CARET, "^", line}, nil
 	case '~':
 		return Token{TILDE, "~", line}, nil
 	case '%':
+		if l.peek() == '=' {
+			l.advance()
+			return Token{PERCENT_ASSIGN, "%=", line}, nil
+		}
 		return Token{PERCENT, "%", line}, nil
 	case '!':
 		if l.peek() == '=' {
@@ -377,6 +454,10 @@ func (l *Lexer) nextToken() (Token, error) {
 		}
 		if l.peek() == '<' {
 			l.advance()
+			if l.peek() == '=' {
+				l.advance()
+				return Token{SHL_ASSIGN, "<<=", line}, nil
+			}
 			return Token{SHL_OP, "<<", line}, nil
 		}
 		return Token{LESS, "<", line}, nil
@@ -387,6 +468,10 @@ func (l *Lexer) nextToken() (Token, error) {
 		}
 		if l.peek() == '>' {
 			l.advance()
+			if l.peek() == '=' {
+				l.advance()
+				return Token{SHR_ASSIGN, ">>=", line}, nil
+			}
 			return Token{SHR_OP, ">>", line}, nil
 		}
 		return Token{GREATER, ">", line}, nil
@@ -1,6 +1,7 @@
 package compiler
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -46,3 +47,59 @@ func TestLexer_ByteDeprecated(t *testing.T) {
 		t.Errorf("Expected 'byte' to be lexed as IDENTIFIER, got %s", tokens[0].Type)
 	}
 }
+
+func TestLexer_NumericEscapes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"hex at start", `"\x1b[2J"`, "\x1b[2J"},
+		{"hex at end", `"ab\x41"`, "abA"},
+		{"single hex digit before non-hex", `"\x7g"`, "\x07g"},
+		{"hex stops after two digits", `"\x414"`, "A4"},
+		{"high byte is one byte", `"\xFF"`, "\xff"},
+		{"octal null and value", `"a\0b\101"`, "a\x00bA"},
+		{"carriage return", `"\r\n"`, "\r\n"},
+		{"only escape", `"\x00"`, "\x00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Lex(tt.input)
+			if err != nil {
+				t.Fatalf("Lex failed: %v", err)
+			}
+			if tokens[0].Type != STRING || tokens[0].Lexeme != tt.want {
+				t.Errorf("got %s %q, want STRING %q", tokens[0].Type, tokens[0].Lexeme, tt.want)
+			}
+		})
+	}
+
+	chars := map[string]string{
+		`'\x41'`: "65",
+		`'\xff'`: "255",
+		`'\0'`:   "0",
+		`'\101'`: "65",
+	}
+	for input, want := range chars {
+		tokens, err := Lex(input)
+		if err != nil {
+			t.Fatalf("Lex(%s) failed: %v", input, err)
+		}
+		if tokens[0].Type != INTEGER || tokens[0].Lexeme != want {
+			t.Errorf("Lex(%s) = %s %q, want INTEGER %q", input, tokens[0].Type, tokens[0].Lexeme, want)
+		}
+	}
+}
+
+func TestLexer_InvalidEscapes(t *testing.T) {
+	for _, input := range []string{`"\xZZ"`, `'\xg'`, `"\x"`, `"\400"`, `"\q"`} {
+		if _, err := Lex(input); err == nil {
+			t.Errorf("Lex(%s): expected an error", input)
+		}
+	}
+	_, err := Lex(`"\xZZ"`)
+	if err == nil || !strings.Contains(err.Error(), "invalid hex escape") {
+		t.Errorf("expected invalid hex escape error, got %v", err)
+	}
+}
@@ -67,6 +67,16 @@ func TestLex(t *testing.T) {
 				{Type: EOF, Lexeme: "", Line: 1},
 			},
 		},
+		{
+			name:  "Binary Integers",
+			input: "0b10110100 0B1 0b101u",
+			expected: []Token{
+				{Type: INTEGER, Lexeme: "0b10110100", Line: 1},
+				{Type: INTEGER, Lexeme: "0B1", Line: 1},
+				{Type: UNSIGNED_LIT, Lexeme: "0b101", Line: 1},
+				{Type: EOF, Lexeme: "", Line: 1},
+			},
+		},
 		{
 			name:  "Equality",
 			input: "a == b",
@@ -2,6 +2,7 @@ package compiler
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"gocpu/pkg/asm"
 	"gocpu/pkg/cpu"
@@ -200,3 +201,74 @@ func TestLogicalPrecedence(t *testing.T) {
 		t.Errorf("Precedence error: (1 || 0) && 0 should be 0, got %d", regs2[0])
 	}
 }
+
+func TestTernary_Codegen(t *testing.T) {
+	stmts := []Stmt{
+		&FunctionDecl{Name: "main", ReturnType: "int", Body: &BlockStmt{Stmts: []Stmt{
+			&ReturnStmt{Expr: &TernaryExpr{Cond: &Literal{Value: 1}, Then: &Literal{Value: 10}, Else: &Literal{Value: 20}}},
+		}}},
+	}
+	code, err := Generate(stmts, NewSymbolTable())
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	// Cond; JZ else; then; JMP end; else:; else-value; end:
+	expected := []string{
+		"    LDI R0, 1",
+		"    SUB R0, R1",
+		"    JZ  L1",
+		"    LDI R0, 10",
+		"    JMP L2",
+		"L1:",
+		"    LDI R0, 20",
+		"L2:",
+	}
+	pos := 0
+	for _, want := range expected {
+		idx := strings.Index(code[pos:], want)
+		if idx < 0 {
+			t.Fatalf("expected %q after offset %d in:\n%s", want, pos, code)
+		}
+		pos += idx + len(want)
+	}
+}
+
+func TestTernary_E2E(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"true arm", "int main() { int a = 5; return a ? 10 : 20; }", 10},
+		{"false arm", "int main() { int a = 0; return a ? 10 : 20; }", 20},
+		{"nested", "int main() { int a = 0; int c = 1; return a ? 1 : (c ? 2 : 3); }", 2},
+		{"as assignment", "int main() { int x; int a = 3; x = a > 2 ? a * 2 : a; return x; }", 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, regs[0])
+			}
+		})
+	}
+}
+
+func TestTernary_ShortCircuit(t *testing.T) {
+	// Only the selected arm may execute.
+	src := `
+	int g = 0;
+	int side() { g = g + 1; return 99; }
+	int main() {
+		int a = 1;
+		int r = a ? 7 : side();
+		r = a ? r : side();
+		return g;
+	}
+	`
+	regs := runCode(t, src)
+	if regs[0] != 0 {
+		t.Errorf("Ternary short-circuit failed: unselected arm executed (g=%d)", regs[0])
+	}
+}
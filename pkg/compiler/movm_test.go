@@ -0,0 +1,44 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGlobalCopy_UsesMOVM(t *testing.T) {
+	code, err := compileSource(`int a;
+int b = 5;
+int *p;
+int *q;
+char c;
+char d;
+int main() {
+	a = b;
+	p = q;
+	c = d;
+	return a;
+}`)
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	assertContains(t, code, "MOVM a, b")
+	assertContains(t, code, "MOVM p, q")
+	if strings.Count(code, "MOVM") != 2 {
+		t.Errorf("char copy should not use MOVM:\n%s", code)
+	}
+}
+
+func TestGlobalCopy_Run(t *testing.T) {
+	regs := runCode(t, `int a;
+int b = 1234;
+int c;
+int main() {
+	a = b;
+	c = a;
+	b = 7;
+	return c + b;
+}`)
+	if regs[0] != 1241 {
+		t.Errorf("expected 1241, got %d", regs[0])
+	}
+}
@@ -1,5 +1,10 @@
 package compiler
 
+import (
+	"fmt"
+	"strings"
+)
+
 // eliminateDeadFunctions removes FunctionDecl nodes from the AST that are never called.
 func eliminateDeadFunctions(stmts []Stmt) []Stmt {
 	// 1. Map all function declarations by name
@@ -32,11 +37,7 @@ func eliminateDeadFunctions(stmts []Stmt) []Stmt {
 	// Global initializers might call functions (e.g., int x = init_x();)
 	for _, s := range stmts {
 		if decl, ok := s.(*VariableDecl); ok && decl.Init != nil {
-			calls := make(map[string]bool)
-			findCallsExpr(decl.Init, calls)
-			for call := range calls {
-				addReachable(call)
-			}
+			walkCallsExpr(decl.Init, func(c *FunctionCall) { addReachable(c.Name) })
 		}
 	}
 
@@ -51,11 +52,7 @@ func eliminateDeadFunctions(stmts []Stmt) []Stmt {
 			continue
 		}
 
-		calls := make(map[string]bool)
-		findCallsStmt(fDecl.Body, calls)
-		for call := range calls {
-			addReachable(call)
-		}
+		walkCallsStmt(fDecl.Body, func(c *FunctionCall) { addReachable(c.Name) })
 	}
 
 	// 4. Rebuild the AST, dropping unreachable functions
@@ -72,82 +69,241 @@ func eliminateDeadFunctions(stmts []Stmt) []Stmt {
 	return optimized
 }
 
-// findCallsExpr recursively extracts function call names from an expression.
-func findCallsExpr(e Expr, calls map[string]bool) {
+// walkCallsExpr calls visit for every function call in an expression, in
+// source order.
+func walkCallsExpr(e Expr, visit func(*FunctionCall)) {
 	if e == nil {
 		return
 	}
 	switch n := e.(type) {
 	case *FunctionCall:
-		calls[n.Name] = true
+		visit(n)
 		for _, arg := range n.Args {
-			findCallsExpr(arg, calls)
+			walkCallsExpr(arg, visit)
 		}
 	case *BinaryExpr:
-		findCallsExpr(n.Left, calls)
-		findCallsExpr(n.Right, calls)
+		walkCallsExpr(n.Left, visit)
+		walkCallsExpr(n.Right, visit)
 	case *LogicalExpr:
-		findCallsExpr(n.Left, calls)
-		findCallsExpr(n.Right, calls)
+		walkCallsExpr(n.Left, visit)
+		walkCallsExpr(n.Right, visit)
+	case *TernaryExpr:
+		walkCallsExpr(n.Cond, visit)
+		walkCallsExpr(n.Then, visit)
+		walkCallsExpr(n.Else, visit)
 	case *UnaryExpr:
-		findCallsExpr(n.Right, calls)
+		walkCallsExpr(n.Right, visit)
 	case *PostfixExpr:
-		findCallsExpr(n.Left, calls)
+		walkCallsExpr(n.Left, visit)
 	case *IndexExpr:
-		findCallsExpr(n.Left, calls)
+		walkCallsExpr(n.Left, visit)
 		for _, idx := range n.Indices {
-			findCallsExpr(idx, calls)
+			walkCallsExpr(idx, visit)
 		}
 	case *MemberExpr:
-		findCallsExpr(n.Left, calls)
+		walkCallsExpr(n.Left, visit)
+	case *CastExpr:
+		walkCallsExpr(n.Expr, visit)
 	case *Literal, *StringLiteral, *VarRef:
 		// No function calls here
 	}
 }
 
-// findCallsStmt recursively extracts function call names from a statement.
-func findCallsStmt(s Stmt, calls map[string]bool) {
+// walkCallsStmt calls visit for every function call in a statement, in
+// source order.
+func walkCallsStmt(s Stmt, visit func(*FunctionCall)) {
 	if s == nil {
 		return
 	}
 	switch n := s.(type) {
 	case *VariableDecl:
-		findCallsExpr(n.Init, calls)
+		walkCallsExpr(n.Init, visit)
 	case *Assignment:
-		findCallsExpr(n.Left, calls)
-		findCallsExpr(n.Value, calls)
+		walkCallsExpr(n.Left, visit)
+		walkCallsExpr(n.Value, visit)
 	case *ReturnStmt:
-		findCallsExpr(n.Expr, calls)
+		walkCallsExpr(n.Expr, visit)
 	case *BlockStmt:
 		for _, child := range n.Stmts {
-			findCallsStmt(child, calls)
+			walkCallsStmt(child, visit)
 		}
 	case *IfStmt:
-		findCallsExpr(n.Condition, calls)
-		findCallsStmt(n.Body, calls)
-		findCallsStmt(n.ElseBody, calls)
+		walkCallsExpr(n.Condition, visit)
+		walkCallsStmt(n.Body, visit)
+		walkCallsStmt(n.ElseBody, visit)
 	case *WhileStmt:
-		findCallsExpr(n.Condition, calls)
-		findCallsStmt(n.Body, calls)
+		walkCallsExpr(n.Condition, visit)
+		walkCallsStmt(n.Body, visit)
+	case *DoWhileStmt:
+		walkCallsStmt(n.Body, visit)
+		walkCallsExpr(n.Condition, visit)
 	case *ForStmt:
-		findCallsStmt(n.Init, calls)
-		findCallsExpr(n.Cond, calls)
-		findCallsStmt(n.Post, calls)
-		findCallsStmt(n.Body, calls)
+		walkCallsStmt(n.Init, visit)
+		walkCallsExpr(n.Cond, visit)
+		walkCallsStmt(n.Post, visit)
+		walkCallsStmt(n.Body, visit)
 	case *ExprStmt:
-		findCallsExpr(n.Expr, calls)
+		walkCallsExpr(n.Expr, visit)
 	case *SwitchStmt:
-		findCallsExpr(n.Target, calls)
+		walkCallsExpr(n.Target, visit)
 		for _, clause := range n.Cases {
-			findCallsExpr(clause.Value, calls)
+			walkCallsExpr(clause.Value, visit)
 			for _, child := range clause.Body {
-				findCallsStmt(child, calls)
+				walkCallsStmt(child, visit)
 			}
 		}
 		for _, child := range n.Default {
-			findCallsStmt(child, calls)
+			walkCallsStmt(child, visit)
 		}
 	case *StructDecl, *AsmStmt, *FunctionDecl:
 		// No executable function calls inside these raw declarations/statements
 	}
 }
+
+// asmLine is one line of generated assembly, split for the peephole pass.
+type asmLine struct {
+	text string
+	op   string   // upper-case mnemonic; "" for blank lines and comments
+	args []string // operands, without the trailing comment
+	// barrier marks labels and directives. Control can enter at a label and
+	// directives may place data or move the location counter, so no rule
+	// looks across one.
+	barrier bool
+}
+
+func parseAsmLine(text string) asmLine {
+	l := asmLine{text: text}
+	code := strings.TrimSpace(text)
+	if strings.HasPrefix(code, ".") {
+		l.barrier = true
+		return l
+	}
+	if i := strings.Index(code, ";"); i >= 0 {
+		code = strings.TrimSpace(code[:i])
+	}
+	if strings.Contains(code, ":") {
+		l.barrier = true
+		return l
+	}
+	if code == "" {
+		return l
+	}
+	fields := strings.FieldsFunc(code, func(r rune) bool { return r == ' ' || r == '\t' || r == ',' })
+	if len(fields) > 1 && strings.HasPrefix(fields[1], ".") {
+		l.barrier = true // NAME .EQU value
+		return l
+	}
+	l.op = strings.ToUpper(fields[0])
+	l.args = fields[1:]
+	return l
+}
+
+// is reports whether l is the instruction op with exactly the given operand count.
+func (l asmLine) is(op string, nargs int) bool {
+	return l.op == op && len(l.args) == nargs
+}
+
+// optimize runs a peephole pass over the assembly produced by the code
+// generator. The rules are deliberately conservative: each one only looks at
+// neighbouring instructions between two barriers (labels and directives),
+// and only rewrites sequences whose effect on registers, flags and control
+// flow is unchanged:
+//
+//   - MOV Rx, Rx is dropped.
+//   - MOV Rx, Ry followed by MOV Ry, Rx drops the second move.
+//   - PUSH Rx followed by POP Rx is dropped; PUSH Rx, POP Ry becomes MOV Ry, Rx.
+//   - PUSH Rx, LDI Ry, imm, POP Rx (Ry != Rx) drops the push and pop.
+//   - Instructions after an unconditional JMP, JMPR, RET or RETI are
+//     unreachable and dropped, up to the next barrier.
+//
+// Comments and blank lines are kept and never separate a pattern.
+func optimize(asm string) string {
+	var lines []asmLine
+	for _, text := range strings.Split(asm, "\n") {
+		lines = append(lines, parseAsmLine(text))
+	}
+	for changed := true; changed; {
+		lines, changed = peephole(lines)
+	}
+
+	var sb strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(l.text)
+	}
+	return sb.String()
+}
+
+// peephole applies one round of the optimize rules and reports whether
+// anything changed.
+func peephole(lines []asmLine) ([]asmLine, bool) {
+	changed := false
+	drop := make([]bool, len(lines))
+
+	// next returns the index of the first instruction after i, or -1 if a
+	// barrier or the end comes first.
+	next := func(i int) int {
+		for j := i + 1; j < len(lines); j++ {
+			switch {
+			case lines[j].barrier:
+				return -1
+			case lines[j].op != "" && !drop[j]:
+				return j
+			}
+		}
+		return -1
+	}
+
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		if l.op == "" || drop[i] {
+			continue
+		}
+		j := next(i)
+
+		switch {
+		case l.is("MOV", 2) && l.args[0] == l.args[1]:
+			drop[i] = true
+			changed = true
+
+		case l.is("MOV", 2) && j >= 0 && lines[j].is("MOV", 2) &&
+			lines[j].args[0] == l.args[1] && lines[j].args[1] == l.args[0]:
+			drop[j] = true
+			changed = true
+
+		case l.is("PUSH", 1) && j >= 0 && lines[j].is("POP", 1):
+			if dst := lines[j].args[0]; dst != l.args[0] {
+				lines[i] = parseAsmLine(fmt.Sprintf("    MOV %s, %s", dst, l.args[0]))
+			} else {
+				drop[i] = true
+			}
+			drop[j] = true
+			changed = true
+
+		case l.is("PUSH", 1) && j >= 0 && lines[j].is("LDI", 2) && lines[j].args[0] != l.args[0]:
+			if k := next(j); k >= 0 && lines[k].is("POP", 1) && lines[k].args[0] == l.args[0] {
+				drop[i], drop[k] = true, true
+				changed = true
+			}
+
+		case l.op == "JMP" || l.op == "JMPR" || l.op == "RET" || l.op == "RETI":
+			for k := j; k >= 0; k = next(k) {
+				drop[k] = true
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return lines, false
+	}
+	kept := lines[:0]
+	for i, l := range lines {
+		if !drop[i] {
+			kept = append(kept, l)
+		}
+	}
+	return kept, true
+}
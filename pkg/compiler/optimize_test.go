@@ -0,0 +1,136 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOptimize_Rules(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []string
+		after  []string
+	}{
+		{
+			"self move",
+			[]string{"    MOV R0, R0", "    HLT"},
+			[]string{"    HLT"},
+		},
+		{
+			"move back",
+			[]string{"    MOV R0, R1", "    MOV R1, R0", "    HLT"},
+			[]string{"    MOV R0, R1", "    HLT"},
+		},
+		{
+			"push pop same register",
+			[]string{"    PUSH R0", "    POP R0", "    HLT"},
+			[]string{"    HLT"},
+		},
+		{
+			"push pop becomes move",
+			[]string{"    LDI R0, 1", "    PUSH R0", "; comment", "    POP  R4", "    CALL f"},
+			[]string{"    LDI R0, 1", "    MOV R4, R0", "; comment", "    CALL f"},
+		},
+		{
+			"save around constant",
+			[]string{"    PUSH R1", "    LDI R0, 20", "    POP R1", "    ST  [R1], R0"},
+			[]string{"    LDI R0, 20", "    ST  [R1], R0"},
+		},
+		{
+			"constant clobbers saved register",
+			[]string{"    PUSH R1", "    LDI R1, 20", "    POP R1"},
+			[]string{"    PUSH R1", "    LDI R1, 20", "    POP R1"},
+		},
+		{
+			"unreachable after return",
+			[]string{"    RET", "    STSP R2", "; kept", "    POP R2", "    RET", "L0:", "    NOP"},
+			[]string{"    RET", "; kept", "L0:", "    NOP"},
+		},
+		{
+			"applied until nothing changes",
+			[]string{"    PUSH R0", "    PUSH R1", "    POP R1", "    POP R0", "    HLT"},
+			[]string{"    HLT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := optimize(strings.Join(tt.before, "\n"))
+			if want := strings.Join(tt.after, "\n"); got != want {
+				t.Errorf("optimize:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestOptimize_Barriers(t *testing.T) {
+	// None of these patterns may be rewritten: a label or directive sits
+	// between the two halves.
+	tests := [][]string{
+		{"    PUSH R0", "L1:", "    POP R0"},
+		{"    MOV R0, R1", "L1: MOV R1, R0"},
+		{"    PUSH R0", "    .ORG 0x0100", "    POP R0"},
+		{"    JMP __init", "    .ORG 0x0010", "    RETI"},
+		{"    RET", "f:", "    PUSH R2"},
+		{"    RET", "S0: .STRING \"a;b\"", "    NOP"},
+		{"    JMP skip", "FIVE .EQU 5", "skip:", "    LDI R0, FIVE"},
+		{"    PUSH R0", "five .equ 5", "    POP R0"},
+	}
+	for _, lines := range tests {
+		in := strings.Join(lines, "\n")
+		if got := optimize(in); got != in {
+			t.Errorf("optimize changed code across a barrier:\n%s\ngot:\n%s", in, got)
+		}
+	}
+}
+
+func TestOptimize_Codegen(t *testing.T) {
+	src := `
+	int g;
+	int f(int a) {
+		if (a == 0) {
+			return 1;
+		}
+		return a;
+	}
+	int main() {
+		int *p = &g;
+		*p = 20;
+		return f(g);
+	}
+	`
+	code := generateWith(t, src, Options{})
+
+	// *p = 20 keeps the address in R1 instead of saving it on the stack.
+	assertContains(t, code, "    MOV R1, R0\n    LDI R0, 20\n    ST  [R1], R0")
+	// f(g) loads its argument straight into R4.
+	assertContains(t, code, "    MOV R4, R0\n    CALL f")
+	assertNotContains(t, code, "PUSH R1")
+
+	// The epilogue after the final return is unreachable and dropped, but
+	// every label the branches use survives.
+	if n := strings.Count(code, "    RET\n    STSP R2"); n != 0 {
+		t.Errorf("found %d unreachable epilogues", n)
+	}
+	for _, label := range []string{"f:", "main:", "L0:", "L1:", "L2:"} {
+		assertContains(t, code, "\n"+label+"\n")
+	}
+
+	// The optimized program still runs correctly.
+	if got := runWith(t, src, Options{}); got != 20 {
+		t.Errorf("main returned %d, want 20", got)
+	}
+}
+
+func TestOptimize_EquAfterJump(t *testing.T) {
+	src := `
+	int main() {
+		asm("JMP skip");
+		asm("FIVE .EQU 5");
+		asm("skip:");
+		asm("LDI R0, FIVE");
+	}
+	`
+	if got := runWith(t, src, Options{}); got != 5 {
+		t.Errorf("main returned %d, want 5", got)
+	}
+}
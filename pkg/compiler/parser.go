@@ -2,6 +2,7 @@ package compiler
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 )
@@ -36,10 +37,18 @@ type Parser struct {
 	pos            int
 	currentRetType TokenType
 	sourceLines    []string
+	enums          []map[string]uint16 // enumerators seen so far, for array sizes; innermost block last
+	typedefs       map[string]TypeInfo
+	structStack    []*StructDecl // struct bodies being parsed, innermost last
 }
 
 func NewParser(tokens []Token, rawSource string) *Parser {
-	return &Parser{tokens: tokens, sourceLines: strings.Split(rawSource, "\n")}
+	return &Parser{
+		tokens:      tokens,
+		sourceLines: strings.Split(rawSource, "\n"),
+		enums:       []map[string]uint16{make(map[string]uint16)},
+		typedefs:    make(map[string]TypeInfo),
+	}
 }
 
 // fmtError wraps an error message with the source line where the token appears.
@@ -128,7 +137,31 @@ func (p *Parser) expect(tt TokenType) (Token, error) {
 
 // parseExpression is the entry point for expression parsing.
 func (p *Parser) parseExpression() (Expr, error) {
-	return p.parseLogicalOr()
+	return p.parseTernary()
+}
+
+// parseTernary handles cond ? a : b (right-associative)
+func (p *Parser) parseTernary() (Expr, error) {
+	cond, err := p.parseLogicalOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().Type != QUESTION {
+		return cond, nil
+	}
+	p.advance()
+	then, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(COLON); err != nil {
+		return nil, err
+	}
+	els, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return &TernaryExpr{Cond: cond, Then: then, Else: els}, nil
 }
 
 // parseLogicalOr handles ||
@@ -321,26 +354,51 @@ func (p *Parser) parseMultiplicative() (Expr, error) {
 
 // parseTypeName parses a type specification like "int", "char **", "struct Foo *".
 // It returns the base type (token), struct name (if applicable), pointer level, and an error.
-func (p *Parser) parseTypeName() (TokenType, string, int, error) {
+func (p *Parser) parseTypeName() (TokenType, string, int, bool, error) {
 	var baseType TokenType
 	var structName string
 	var ptrLevel int
+	var unsigned bool
 
 	// Skip any leading qualifiers (volatile, const, static, extern).
 	p.skipQualifiers()
 
 	if p.peek().Type == INT || p.peek().Type == CHAR {
 		baseType = p.advance().Type
-	} else if p.peek().Type == STRUCT {
-		p.advance() // consume struct
+	} else if p.peek().Type == UNSIGNED {
+		// "unsigned" or "unsigned int"; same representation as int.
+		p.advance()
+		if p.peek().Type == INT {
+			p.advance()
+		}
+		baseType = INT
+		unsigned = true
+	} else if p.peek().Type == STRUCT || p.peek().Type == UNION {
+		p.advance() // consume struct/union
 		baseType = STRUCT
 		nameTok, err := p.expect(IDENTIFIER)
 		if err != nil {
-			return 0, "", 0, err
+			return 0, "", 0, false, err
 		}
 		structName = nameTok.Lexeme
+	} else if alias, ok := p.typedefName(p.peek()); ok {
+		tok := p.advance()
+		if alias.IsArray {
+			return 0, "", 0, false, fmt.Errorf("line %d: typedef %q names an array type", tok.Line, tok.Lexeme)
+		}
+		switch {
+		case alias.IsStruct:
+			baseType = STRUCT
+		case alias.IsChar:
+			baseType = CHAR
+		default:
+			baseType = INT
+		}
+		structName = alias.StructName
+		ptrLevel = alias.PointerLevel
+		unsigned = alias.IsUnsigned
 	} else {
-		return 0, "", 0, fmt.Errorf("expected type")
+		return 0, "", 0, false, fmt.Errorf("expected type")
 	}
 
 	for p.peek().Type == STAR {
@@ -348,7 +406,7 @@ func (p *Parser) parseTypeName() (TokenType, string, int, error) {
 		ptrLevel++
 	}
 
-	return baseType, structName, ptrLevel, nil
+	return baseType, structName, ptrLevel, unsigned, nil
 }
 
 // parseUnary handles prefix operators &, *, ~, !, and - (unary minus)
@@ -365,10 +423,12 @@ func (p *Parser) parseUnary() (Expr, error) {
 		}
 		if p.peekAt(idx).Type == INT || p.peekAt(idx).Type == CHAR {
 			isCast = true
-		} else if p.peekAt(idx).Type == STRUCT {
+		} else if p.peekAt(idx).Type == STRUCT || p.peekAt(idx).Type == UNION {
 			isCast = true
 		} else if p.peekAt(idx).Type == UNSIGNED {
 			isCast = true
+		} else if _, ok := p.typedefName(p.peekAt(idx)); ok {
+			isCast = true
 		}
 
 		if isCast {
@@ -380,7 +440,7 @@ func (p *Parser) parseUnary() (Expr, error) {
 			// So if we see LPAREN then INT/CHAR/STRUCT, it MUST be a cast.
 
 			p.advance() // consume '('
-			baseType, structName, ptrLevel, err := p.parseTypeName()
+			baseType, structName, ptrLevel, unsigned, err := p.parseTypeName()
 			if err != nil {
 				return nil, err
 			}
@@ -393,8 +453,21 @@ func (p *Parser) parseUnary() (Expr, error) {
 			if err != nil {
 				return nil, err
 			}
-			return &CastExpr{Type: baseType, StructName: structName, PointerLevel: ptrLevel, Expr: right}, nil
+			return &CastExpr{Type: baseType, StructName: structName, PointerLevel: ptrLevel, IsUnsigned: unsigned, Expr: right}, nil
+		}
+	}
+
+	if p.peek().Type == SIZEOF {
+		return p.parseSizeof()
+	}
+
+	if p.peek().Type == PLUS_PLUS || p.peek().Type == MINUS_MINUS {
+		op := p.advance().Type
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
 		}
+		return &PostfixExpr{Left: operand, Op: op, Prefix: true}, nil
 	}
 
 	if p.peek().Type == AND || p.peek().Type == STAR || p.peek().Type == TILDE || p.peek().Type == NOT || p.peek().Type == MINUS {
@@ -408,6 +481,40 @@ func (p *Parser) parseUnary() (Expr, error) {
 	return p.parsePostfix()
 }
 
+// parseSizeof handles sizeof(type) and sizeof expr.
+func (p *Parser) parseSizeof() (Expr, error) {
+	p.advance() // consume sizeof
+
+	if p.peek().Type == LPAREN {
+		idx := 1
+		for isQualifier(p.peekAt(idx).Type) {
+			idx++
+		}
+		_, isAlias := p.typedefName(p.peekAt(idx))
+		switch p.peekAt(idx).Type {
+		case INT, CHAR, UNSIGNED, STRUCT, UNION, IDENTIFIER:
+			if p.peekAt(idx).Type == IDENTIFIER && !isAlias {
+				break
+			}
+			p.advance() // consume '('
+			baseType, structName, ptrLevel, unsigned, err := p.parseTypeName()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(RPAREN); err != nil {
+				return nil, err
+			}
+			return &SizeofExpr{Type: baseType, StructName: structName, PointerLevel: ptrLevel, IsUnsigned: unsigned}, nil
+		}
+	}
+
+	expr, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &SizeofExpr{Expr: expr}, nil
+}
+
 // parsePostfix handles array index [], struct access ., and function calls ()
 func (p *Parser) parsePostfix() (Expr, error) {
 	expr, err := p.parsePrimary()
@@ -440,16 +547,24 @@ func (p *Parser) parsePostfix() (Expr, error) {
 		} else if p.peek().Type == LPAREN {
 			// Function call conversion
 			if varRef, ok := expr.(*VarRef); ok {
-				p.advance() // (
+				line := p.advance().Line // (
 				args, err := p.parseCallArgs()
 				if err != nil {
 					return nil, err
 				}
-				expr = &FunctionCall{Name: varRef.Name, Args: args}
+				expr = &FunctionCall{Name: varRef.Name, Args: args, Line: line}
 			} else {
 				// We don't support computed function calls like (ptr)(args) yet
 				return nil, fmt.Errorf("line %d: expected function name before '('", p.peek().Line)
 			}
+		} else if p.peek().Type == ARROW {
+			// p->member is sugar for (*p).member
+			p.advance() // ->
+			memberTok, err := p.expect(IDENTIFIER)
+			if err != nil {
+				return nil, err
+			}
+			expr = &MemberExpr{Left: &UnaryExpr{Op: STAR, Right: expr}, Member: memberTok.Lexeme}
 		} else if p.peek().Type == PLUS_PLUS || p.peek().Type == MINUS_MINUS {
 			op := p.advance().Type
 			expr = &PostfixExpr{Left: expr, Op: op}
@@ -535,7 +650,14 @@ func (p *Parser) parseInitializerList() (*InitializerList, error) {
 	var elements []Expr
 	if p.peek().Type != RBRACE {
 		for {
-			expr, err := p.parseExpression()
+			var expr Expr
+			var err error
+			if p.peek().Type == LBRACE {
+				// Nested braces for struct members and sub-arrays.
+				expr, err = p.parseInitializerList()
+			} else {
+				expr, err = p.parseExpression()
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -560,6 +682,7 @@ func (p *Parser) parseInitializerList() (*InitializerList, error) {
 // isField: true if parsing struct fields (no initializers allowed).
 func (p *Parser) parseVarDeclInternal(isField bool) (*VariableDecl, error) {
 	var decl VariableDecl
+	var aliasDims []int // dimensions contributed by an array typedef
 
 	// Consume any leading qualifiers (volatile, const, static, extern).
 	p.skipQualifiers()
@@ -591,24 +714,54 @@ func (p *Parser) parseVarDeclInternal(isField bool) (*VariableDecl, error) {
 			p.advance()
 			decl.PointerLevel++
 		}
-	} else if p.peek().Type == STRUCT {
-		p.advance()
+	} else if p.peek().Type == STRUCT || p.peek().Type == UNION {
+		isUnion := p.advance().Type == UNION
 		decl.IsStruct = true
-		nameTok, err := p.expect(IDENTIFIER)
-		if err != nil {
-			return nil, err
+		if p.peek().Type == LBRACE {
+			name, err := p.parseInlineStruct(isUnion)
+			if err != nil {
+				return nil, err
+			}
+			decl.StructName = name
+		} else {
+			nameTok, err := p.expect(IDENTIFIER)
+			if err != nil {
+				return nil, err
+			}
+			decl.StructName = nameTok.Lexeme
 		}
-		decl.StructName = nameTok.Lexeme
-		// Optional *
-		if p.peek().Type == STAR {
-			// Pointer to struct. Treat as scalar int (pointer).
-			// If pointer level >= 1, it's a pointer.
-			decl.IsStruct = false
-			decl.StructName = "" // clear it to treat as scalar
-			for p.peek().Type == STAR {
-				p.advance()
-				decl.PointerLevel++
+		// Optional *: pointer to struct. IsStruct/StructName are kept so members
+		// can be resolved through the pointer; PointerLevel >= 1 marks it as a pointer.
+		for p.peek().Type == STAR {
+			p.advance()
+			decl.PointerLevel++
+		}
+	} else if alias, ok := p.typedefName(p.peek()); ok {
+		tok := p.advance()
+		decl.IsUnsigned = alias.IsUnsigned
+		decl.IsChar = alias.IsChar
+		decl.IsStruct = alias.IsStruct
+		decl.StructName = alias.StructName
+		decl.PointerLevel = alias.PointerLevel
+		for p.peek().Type == STAR {
+			p.advance()
+			decl.PointerLevel++
+		}
+		if alias.IsArray {
+			if decl.PointerLevel > alias.PointerLevel {
+				return nil, fmt.Errorf("line %d: pointer to array typedef %q is not supported", tok.Line, tok.Lexeme)
 			}
+			aliasDims = alias.ArraySizes
+		}
+	} else if p.peek().Type == ENUM {
+		// enum Name x; is stored as a plain int.
+		p.advance()
+		if _, err := p.expect(IDENTIFIER); err != nil {
+			return nil, err
+		}
+		for p.peek().Type == STAR {
+			p.advance()
+			decl.PointerLevel++
 		}
 	} else {
 		return nil, fmt.Errorf("line %d: expected type (int, char, or struct)", p.peek().Line)
@@ -628,6 +781,9 @@ func (p *Parser) parseVarDeclInternal(isField bool) (*VariableDecl, error) {
 		if p.peek().Type == RBRACKET {
 			// Empty size [], allowed if initializer is present (checked later)
 			size = 0
+		} else if v, ok := p.enum(p.peek().Lexeme); ok && p.peek().Type == IDENTIFIER {
+			p.advance()
+			size = int(v)
 		} else {
 			sizeTok, err := p.expect(INTEGER)
 			if err != nil {
@@ -642,6 +798,10 @@ func (p *Parser) parseVarDeclInternal(isField bool) (*VariableDecl, error) {
 			return nil, err
 		}
 	}
+	if len(aliasDims) > 0 {
+		decl.IsArray = true
+		decl.ArraySizes = append(decl.ArraySizes, aliasDims...)
+	}
 
 	if isField {
 		if _, err := p.expect(SEMICOLON); err != nil {
@@ -649,6 +809,7 @@ func (p *Parser) parseVarDeclInternal(isField bool) (*VariableDecl, error) {
 		}
 		return &decl, nil
 	}
+	decl.Line = nameTok.Line
 
 	// For variables, optional initializer
 	if p.peek().Type == ASSIGN {
@@ -667,8 +828,19 @@ func (p *Parser) parseVarDeclInternal(isField bool) (*VariableDecl, error) {
 				decl.ArraySizes[0] = len(initList.Elements)
 			}
 
+		} else if p.peek().Type == STRING && decl.IsArray && decl.IsChar &&
+			decl.PointerLevel == 0 && len(decl.ArraySizes) == 1 {
+			// char msg[] = "hello"; -> char msg[6], including the null.
+			strTok := p.advance()
+			if decl.ArraySizes[0] == 0 {
+				decl.ArraySizes[0] = len(strTok.Lexeme) + 1
+			} else if len(strTok.Lexeme) > decl.ArraySizes[0] {
+				return nil, fmt.Errorf("line %d: initializer-string for char array %q is too long", nameTok.Line, decl.Name)
+			}
+			decl.Init = &StringLiteral{Value: strTok.Lexeme}
+
 		} else {
-			if decl.IsArray || decl.IsStruct {
+			if decl.IsArray || (decl.IsStruct && decl.PointerLevel == 0) {
 				return nil, fmt.Errorf("line %d: array/struct initialization requires '{...}'", nameTok.Line)
 			}
 			init, err := p.parseExpression()
@@ -692,8 +864,11 @@ func (p *Parser) parseVarDecl() (Stmt, error) {
 func (p *Parser) parseStructDecl() (Stmt, error) {
 	// "struct" consumed by caller? No, caller calls this when it sees STRUCT but doesn't consume it?
 	// parseStatement checks peek. If STRUCT, calls parseStructDecl.
-	// So we need to consume STRUCT here.
-	if _, err := p.expect(STRUCT); err != nil {
+	// So we need to consume STRUCT (or UNION) here.
+	isUnion := p.peek().Type == UNION
+	if isUnion {
+		p.advance()
+	} else if _, err := p.expect(STRUCT); err != nil {
 		return nil, err
 	}
 
@@ -702,18 +877,131 @@ func (p *Parser) parseStructDecl() (Stmt, error) {
 		return nil, err
 	}
 
-	if _, err := p.expect(LBRACE); err != nil {
+	decl := &StructDecl{Name: nameTok.Lexeme, IsUnion: isUnion}
+	if err := p.parseStructBody(decl); err != nil {
 		return nil, err
 	}
+	if _, err := p.expect(SEMICOLON); err != nil {
+		return nil, err
+	}
+
+	return decl, nil
+}
+
+// parseStructBody parses { fields... } into decl.
+func (p *Parser) parseStructBody(decl *StructDecl) error {
+	if _, err := p.expect(LBRACE); err != nil {
+		return err
+	}
+
+	p.structStack = append(p.structStack, decl)
+	defer func() { p.structStack = p.structStack[:len(p.structStack)-1] }()
 
-	var fields []VariableDecl
 	for p.peek().Type != RBRACE && p.peek().Type != EOF {
 		// Field declaration: type name;
 		field, err := p.parseVarDeclInternal(true) // true = isField
+		if err != nil {
+			return err
+		}
+		decl.Fields = append(decl.Fields, *field)
+	}
+
+	_, err := p.expect(RBRACE)
+	return err
+}
+
+// parseInlineStruct parses the body of an anonymous struct (or union) used as
+// a field type and returns its synthetic name. The current token is the '{'.
+func (p *Parser) parseInlineStruct(isUnion bool) (string, error) {
+	if len(p.structStack) == 0 {
+		return "", fmt.Errorf("line %d: anonymous struct types are only supported as struct fields", p.peek().Line)
+	}
+	parent := p.structStack[len(p.structStack)-1]
+	inline := &StructDecl{Name: fmt.Sprintf("%s.anon%d", parent.Name, len(parent.Inline)), IsUnion: isUnion}
+	if err := p.parseStructBody(inline); err != nil {
+		return "", err
+	}
+	parent.Inline = append(parent.Inline, inline)
+	return inline.Name, nil
+}
+
+// typedefName returns the aliased type if tok names a typedef.
+func (p *Parser) typedefName(tok Token) (TypeInfo, bool) {
+	if tok.Type != IDENTIFIER {
+		return TypeInfo{}, false
+	}
+	t, ok := p.typedefs[tok.Lexeme]
+	return t, ok
+}
+
+// parseTypedef parses typedef <type> Name; where the part after typedef has
+// the form of a struct field declaration, e.g. typedef int row[8];
+func (p *Parser) parseTypedef() (Stmt, error) {
+	tdTok, err := p.expect(TYPEDEF)
+	if err != nil {
+		return nil, err
+	}
+	decl, err := p.parseVarDeclInternal(true)
+	if err != nil {
+		return nil, err
+	}
+	typ := TypeInfo{
+		IsArray:      decl.IsArray,
+		ArraySizes:   decl.ArraySizes,
+		IsStruct:     decl.IsStruct,
+		StructName:   decl.StructName,
+		IsChar:       decl.IsChar,
+		PointerLevel: decl.PointerLevel,
+		IsUnsigned:   decl.IsUnsigned,
+	}
+	if old, ok := p.typedefs[decl.Name]; ok && !reflect.DeepEqual(old, typ) {
+		return nil, fmt.Errorf("line %d: conflicting types for typedef %q", tdTok.Line, decl.Name)
+	}
+	p.typedefs[decl.Name] = typ
+	return &TypedefDecl{Name: decl.Name, Type: typ}, nil
+}
+
+// parseEnumDecl parses enum [Name] { A, B = 5, C }; and resolves each
+// enumerator's value. Without an explicit "= N" an enumerator is one more
+// than the previous one, starting from 0.
+func (p *Parser) parseEnumDecl() (Stmt, error) {
+	if _, err := p.expect(ENUM); err != nil {
+		return nil, err
+	}
+	decl := &EnumDecl{}
+	if p.peek().Type == IDENTIFIER {
+		decl.Name = p.advance().Lexeme
+	}
+	if _, err := p.expect(LBRACE); err != nil {
+		return nil, err
+	}
+
+	next := uint16(0)
+	for p.peek().Type != RBRACE && p.peek().Type != EOF {
+		nameTok, err := p.expect(IDENTIFIER)
 		if err != nil {
 			return nil, err
 		}
-		fields = append(fields, *field)
+		scope := p.enums[len(p.enums)-1]
+		if _, dup := scope[nameTok.Lexeme]; dup {
+			return nil, fmt.Errorf("line %d: redeclaration of enumerator %q", nameTok.Line, nameTok.Lexeme)
+		}
+		if p.peek().Type == ASSIGN {
+			p.advance()
+			v, err := p.parseEnumValue()
+			if err != nil {
+				return nil, err
+			}
+			next = v
+		}
+		decl.Members = append(decl.Members, Enumerator{Name: nameTok.Lexeme, Value: next})
+		scope[nameTok.Lexeme] = next
+		next++
+
+		if p.peek().Type != COMMA {
+			break
+		}
+		p.advance() // a trailing comma is allowed
 	}
 
 	if _, err := p.expect(RBRACE); err != nil {
@@ -722,16 +1010,68 @@ func (p *Parser) parseStructDecl() (Stmt, error) {
 	if _, err := p.expect(SEMICOLON); err != nil {
 		return nil, err
 	}
+	return decl, nil
+}
+
+// enum returns the value of the innermost visible enumerator called name.
+func (p *Parser) enum(name string) (uint16, bool) {
+	for i := len(p.enums) - 1; i >= 0; i-- {
+		if v, ok := p.enums[i][name]; ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// parseEnumValue parses the explicit value of an enumerator: an optionally
+// negated integer literal or a previously declared enumerator.
+func (p *Parser) parseEnumValue() (uint16, error) {
+	negate := false
+	if p.peek().Type == MINUS {
+		p.advance()
+		negate = true
+	}
+	tok := p.advance()
+	var v uint16
+	switch tok.Type {
+	case INTEGER:
+		n, err := strconv.ParseUint(tok.Lexeme, 0, 16)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: integer %q out of 16-bit range", tok.Line, tok.Lexeme)
+		}
+		v = uint16(n)
+	case IDENTIFIER:
+		n, ok := p.enum(tok.Lexeme)
+		if !ok {
+			return 0, fmt.Errorf("line %d: enumerator value %q is not a constant", tok.Line, tok.Lexeme)
+		}
+		v = n
+	default:
+		return 0, fmt.Errorf("line %d: expected constant enumerator value, got %q", tok.Line, tok.Lexeme)
+	}
+	if negate {
+		v = -v
+	}
+	return v, nil
+}
 
-	return &StructDecl{Name: nameTok.Lexeme, Fields: fields}, nil
+// isAssignOp reports whether t is = or one of the compound assignment operators.
+func isAssignOp(t TokenType) bool {
+	switch t {
+	case ASSIGN, PLUS_ASSIGN, MINUS_ASSIGN, STAR_ASSIGN, SLASH_ASSIGN,
+		PERCENT_ASSIGN, AND_ASSIGN, PIPE_ASSIGN, CARET_ASSIGN, SHL_ASSIGN, SHR_ASSIGN:
+		return true
+	}
+	return false
 }
 
 // parseAssignment parses  lvalue = expr ;
 // The left-hand side expression (lvalue) is passed in.
 func (p *Parser) parseAssignment(left Expr) (Stmt, error) {
-	op := p.advance().Type
+	opTok := p.advance()
+	op := opTok.Type
 	// We expect ASSIGN or Compound Assignment
-	if op != ASSIGN && op != PLUS_ASSIGN && op != MINUS_ASSIGN && op != STAR_ASSIGN && op != SLASH_ASSIGN {
+	if !isAssignOp(op) {
 		return nil, fmt.Errorf("line %d: expected assignment operator, got %s", p.peek().Line, op)
 	}
 
@@ -742,7 +1082,7 @@ func (p *Parser) parseAssignment(left Expr) (Stmt, error) {
 	if _, err := p.expect(SEMICOLON); err != nil {
 		return nil, err
 	}
-	return &Assignment{Left: left, Op: op, Value: val}, nil
+	return &Assignment{Left: left, Op: op, Value: val, Line: opTok.Line}, nil
 }
 
 // parseReturn parses  return expr ;
@@ -772,7 +1112,11 @@ func (p *Parser) parseReturn() (Stmt, error) {
 
 // parseBlock parses { stmt1; stmt2; ... }
 // The leading LBRACE token has already been consumed by parseStatement.
+// Enumerators declared inside the block go out of scope at its end.
 func (p *Parser) parseBlock() (Stmt, error) {
+	p.enums = append(p.enums, make(map[string]uint16))
+	defer func() { p.enums = p.enums[:len(p.enums)-1] }()
+
 	var stmts []Stmt
 	for p.peek().Type != RBRACE && p.peek().Type != EOF {
 		stmt, err := p.parseStatement()
@@ -839,6 +1183,32 @@ func (p *Parser) parseWhile() (Stmt, error) {
 	return &WhileStmt{Condition: cond, Body: body}, nil
 }
 
+// parseDoWhile parses do body while ( cond ) ;
+// The leading DO token has already been consumed by parseStatement.
+func (p *Parser) parseDoWhile() (Stmt, error) {
+	body, err := p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(WHILE); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(LPAREN); err != nil {
+		return nil, err
+	}
+	cond, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(RPAREN); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(SEMICOLON); err != nil {
+		return nil, err
+	}
+	return &DoWhileStmt{Body: body, Condition: cond}, nil
+}
+
 // parseSwitchStmt parses switch ( expr ) { case val: ... default: ... }
 func (p *Parser) parseSwitchStmt() (Stmt, error) {
 	if _, err := p.expect(SWITCH); err != nil {
@@ -926,21 +1296,22 @@ func (p *Parser) parseForStmt() (Stmt, error) {
 
 	var init Stmt
 	if p.peek().Type != SEMICOLON {
+		_, isAlias := p.typedefName(p.peek())
 		if p.peek().Type == INT || p.peek().Type == CHAR || p.peek().Type == UNSIGNED ||
-			isQualifier(p.peek().Type) {
+			isQualifier(p.peek().Type) || isAlias {
 			var err error
 			init, err = p.parseVarDecl()
 			if err != nil {
 				return nil, err
 			}
 		} else {
+			line := p.peek().Line
 			expr, err := p.parseExpression()
 			if err != nil {
 				return nil, err
 			}
 
-			op := p.peek().Type
-			if op == ASSIGN || op == PLUS_ASSIGN || op == MINUS_ASSIGN || op == STAR_ASSIGN || op == SLASH_ASSIGN {
+			if isAssignOp(p.peek().Type) {
 				init, err = p.parseAssignment(expr)
 				if err != nil {
 					return nil, err
@@ -949,7 +1320,7 @@ func (p *Parser) parseForStmt() (Stmt, error) {
 				if _, err := p.expect(SEMICOLON); err != nil {
 					return nil, err
 				}
-				init = &ExprStmt{Expr: expr}
+				init = &ExprStmt{Expr: expr, Line: line}
 			}
 		}
 	} else {
@@ -970,21 +1341,22 @@ func (p *Parser) parseForStmt() (Stmt, error) {
 
 	var post Stmt
 	if p.peek().Type != RPAREN {
+		line := p.peek().Line
 		expr, err := p.parseExpression()
 		if err != nil {
 			return nil, err
 		}
 
 		op := p.peek().Type
-		if op == ASSIGN || op == PLUS_ASSIGN || op == MINUS_ASSIGN || op == STAR_ASSIGN || op == SLASH_ASSIGN {
-			p.advance() // consume op
+		if isAssignOp(op) {
+			opTok := p.advance() // consume op
 			val, err := p.parseExpression()
 			if err != nil {
 				return nil, err
 			}
-			post = &Assignment{Left: expr, Op: op, Value: val}
+			post = &Assignment{Left: expr, Op: op, Value: val, Line: opTok.Line}
 		} else {
-			post = &ExprStmt{Expr: expr}
+			post = &ExprStmt{Expr: expr, Line: line}
 		}
 	}
 
@@ -1017,6 +1389,10 @@ func (p *Parser) parseStatement() (Stmt, error) {
 		p.advance()
 		return p.parseWhile()
 
+	case DO:
+		p.advance()
+		return p.parseDoWhile()
+
 	case FOR:
 		return p.parseForStmt()
 
@@ -1054,10 +1430,31 @@ func (p *Parser) parseStatement() (Stmt, error) {
 		}
 		return &ContinueStmt{}, nil
 
+	case GOTO:
+		p.advance()
+		nameTok, err := p.expect(IDENTIFIER)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(SEMICOLON); err != nil {
+			return nil, err
+		}
+		return &GotoStmt{Name: nameTok.Lexeme, Line: nameTok.Line}, nil
+
 	case INT, CHAR, UNSIGNED, VOLATILE, CONST, STATIC, EXTERN:
 		return p.parseVarDecl()
 
-	case STRUCT:
+	case TYPEDEF:
+		return p.parseTypedef()
+
+	case ENUM:
+		// enum Color { ... }; or enum Color c;
+		if p.peekAt(1).Type == LBRACE || p.peekAt(2).Type == LBRACE {
+			return p.parseEnumDecl()
+		}
+		return p.parseVarDecl()
+
+	case STRUCT, UNION:
 		// Variable declaration: struct Point p;
 		// OR Struct definition: struct Point { ... };
 		// Check lookahead
@@ -1066,24 +1463,33 @@ func (p *Parser) parseStatement() (Stmt, error) {
 		}
 		return p.parseVarDecl()
 
-	case IDENTIFIER, STAR, LPAREN:
+	case IDENTIFIER, STAR, LPAREN, PLUS_PLUS, MINUS_MINUS:
+		// A typedef name at statement start begins a declaration.
+		if _, ok := p.typedefName(tok); ok {
+			return p.parseVarDecl()
+		}
+
+		// label: (only a bare identifier followed by a colon)
+		if tok.Type == IDENTIFIER && p.peekNext().Type == COLON {
+			p.advance()
+			p.advance()
+			return &LabelStmt{Name: tok.Lexeme, Line: tok.Line}, nil
+		}
+
 		// Expression statement or Assignment
+		line := p.peek().Line
 		expr, err := p.parseExpression()
 		if err != nil {
 			return nil, err
 		}
 
-		if p.peek().Type == ASSIGN ||
-			p.peek().Type == PLUS_ASSIGN ||
-			p.peek().Type == MINUS_ASSIGN ||
-			p.peek().Type == STAR_ASSIGN ||
-			p.peek().Type == SLASH_ASSIGN {
+		if isAssignOp(p.peek().Type) {
 			return p.parseAssignment(expr)
 		}
 		if _, err := p.expect(SEMICOLON); err != nil {
 			return nil, err
 		}
-		return &ExprStmt{Expr: expr}, nil
+		return &ExprStmt{Expr: expr, Line: line}, nil
 
 	case RETURN:
 		p.advance()
@@ -1125,6 +1531,16 @@ func (p *Parser) parseFunctionDecl() (Stmt, error) {
 		p.advance()
 		retType = "void"
 		p.currentRetType = VOID
+	} else if p.peek().Type == ENUM && p.peekNext().Type == IDENTIFIER {
+		p.advance()
+		retType = "enum " + p.advance().Lexeme
+		p.currentRetType = INT
+	} else if alias, ok := p.typedefName(p.peek()); ok {
+		retType = p.advance().Lexeme
+		p.currentRetType = INT
+		if alias.IsChar && alias.PointerLevel == 0 {
+			p.currentRetType = CHAR
+		}
 	} else {
 		return nil, fmt.Errorf("line %d: expected return type (int, char, or void)", p.peek().Line)
 	}
@@ -1175,6 +1591,27 @@ func (p *Parser) parseFunctionDecl() (Stmt, error) {
 					p.advance()
 					param.PointerLevel++
 				}
+			} else if p.peek().Type == ENUM && p.peekNext().Type == IDENTIFIER {
+				p.advance()
+				p.advance()
+				for p.peek().Type == STAR {
+					p.advance()
+					param.PointerLevel++
+				}
+			} else if alias, ok := p.typedefName(p.peek()); ok {
+				p.advance()
+				param.IsUnsigned = alias.IsUnsigned
+				param.IsChar = alias.IsChar
+				param.IsStruct = alias.IsStruct
+				param.StructName = alias.StructName
+				param.PointerLevel = alias.PointerLevel
+				if alias.IsArray {
+					param.PointerLevel++ // array parameters decay to pointers
+				}
+				for p.peek().Type == STAR {
+					p.advance()
+					param.PointerLevel++
+				}
 			} else {
 				return nil, fmt.Errorf("line %d: expected parameter type (int or char)", p.peek().Line)
 			}
@@ -1197,6 +1634,12 @@ func (p *Parser) parseFunctionDecl() (Stmt, error) {
 		return nil, err
 	}
 
+	// A prototype: int name(params);
+	if p.peek().Type == SEMICOLON {
+		p.advance()
+		return &FunctionDecl{Name: nameTok.Lexeme, Params: params, ReturnType: retType}, nil
+	}
+
 	if _, err := p.expect(LBRACE); err != nil {
 		return nil, err
 	}
@@ -1272,6 +1715,18 @@ func Parse(tokens []Token, rawSource string) ([]Stmt, error) {
 			if p.peekAt(o+1+pc).Type == IDENTIFIER && p.peekAt(o+2+pc).Type == LPAREN {
 				isFunc = true
 			}
+		} else if firstTok == ENUM && p.peekAt(qc+1).Type == IDENTIFIER {
+			o := qc + 1
+			pc := p.pointerCount(o + 1)
+			if p.peekAt(o+1+pc).Type == IDENTIFIER && p.peekAt(o+2+pc).Type == LPAREN {
+				isFunc = true
+			}
+		} else if _, ok := p.typedefName(p.peekAt(qc)); ok {
+			o := qc
+			pc := p.pointerCount(o + 1)
+			if p.peekAt(o+1+pc).Type == IDENTIFIER && p.peekAt(o+2+pc).Type == LPAREN {
+				isFunc = true
+			}
 		}
 
 		if isFunc {
@@ -1284,7 +1739,7 @@ func Parse(tokens []Token, rawSource string) ([]Stmt, error) {
 		}
 
 		// 2. Check for Struct Definition (qualifiers before struct not common but handle it)
-		if firstTok == STRUCT && p.peekAt(qc+2).Type == LBRACE {
+		if (firstTok == STRUCT || firstTok == UNION) && p.peekAt(qc+2).Type == LBRACE {
 			p.skipQualifiers()
 			s, err := p.parseStructDecl()
 			if err != nil {
@@ -1294,8 +1749,30 @@ func Parse(tokens []Token, rawSource string) ([]Stmt, error) {
 			continue
 		}
 
+		if firstTok == TYPEDEF {
+			p.skipQualifiers()
+			td, err := p.parseTypedef()
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, td)
+			continue
+		}
+
+		// Enum definition: enum Color { ... };
+		if firstTok == ENUM && (p.peekAt(qc+1).Type == LBRACE || p.peekAt(qc+2).Type == LBRACE) {
+			p.skipQualifiers()
+			e, err := p.parseEnumDecl()
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, e)
+			continue
+		}
+
 		// 3. Check for Global Variable Declaration
-		if firstTok == INT || firstTok == CHAR || firstTok == STRUCT || firstTok == UNSIGNED ||
+		_, isAlias := p.typedefName(p.peekAt(qc))
+		if firstTok == INT || firstTok == CHAR || firstTok == STRUCT || firstTok == UNION || firstTok == UNSIGNED || firstTok == ENUM || isAlias ||
 			isQualifier(p.peek().Type) {
 			v, err := p.parseVarDecl()
 			if err != nil {
@@ -1305,7 +1782,17 @@ func Parse(tokens []Token, rawSource string) ([]Stmt, error) {
 			continue
 		}
 
-		// 4. If we hit anything else, it's a naked statement!
+		// 4. static_assert(cond, "msg") is allowed at file scope.
+		if firstTok == IDENTIFIER && p.peek().Lexeme == "static_assert" {
+			s, err := p.parseStatement()
+			if err != nil {
+				return nil, err
+			}
+			stmts = append(stmts, s)
+			continue
+		}
+
+		// 5. If we hit anything else, it's a naked statement!
 		tok := p.peek()
 		return nil, fmt.Errorf("line %d: executable statement %q found outside of function body",
 			tok.Line, tok.Lexeme)
@@ -17,6 +17,7 @@ func TestParse_ForLoop(t *testing.T) {
 			expected: []Stmt{
 				&ForStmt{
 					Init: &VariableDecl{
+						Line: 1,
 						Name: "i",
 						Init: &Literal{Value: 0},
 					},
@@ -26,6 +27,7 @@ func TestParse_ForLoop(t *testing.T) {
 						Right: &Literal{Value: 10},
 					},
 					Post: &ExprStmt{
+						Line: 1,
 						Expr: &PostfixExpr{
 							Left: &VarRef{Name: "i"},
 							Op:   PLUS_PLUS,
@@ -41,6 +43,7 @@ func TestParse_ForLoop(t *testing.T) {
 			expected: []Stmt{
 				&ForStmt{
 					Init: &Assignment{
+						Line: 1,
 						Left: &VarRef{Name: "i"},
 						Op:   ASSIGN,
 						Value: &Literal{Value: 0},
@@ -51,6 +54,7 @@ func TestParse_ForLoop(t *testing.T) {
 						Right: &Literal{Value: 10},
 					},
 					Post: &ExprStmt{
+						Line: 1,
 						Expr: &PostfixExpr{
 							Left: &VarRef{Name: "i"},
 							Op:   PLUS_PLUS,
@@ -78,6 +82,7 @@ func TestParse_ForLoop(t *testing.T) {
 			expected: []Stmt{
 				&ForStmt{
 					Init: &Assignment{
+						Line: 1,
 						Left: &VarRef{Name: "i"},
 						Op:   ASSIGN,
 						Value: &Literal{Value: 0},
@@ -88,6 +93,7 @@ func TestParse_ForLoop(t *testing.T) {
 						Right: &Literal{Value: 10},
 					},
 					Post: &Assignment{
+						Line:  1,
 						Left:  &VarRef{Name: "i"},
 						Op:    PLUS_ASSIGN,
 						Value: &Literal{Value: 2},
@@ -53,10 +53,13 @@ func TestParser_MultiLevelPointers(t *testing.T) {
 	if !ok {
 		t.Errorf("Stmt 2 not VariableDecl")
 	} else {
-		// Pointers to structs are treated as scalars (IsStruct=false, PointerLevel > 0)
+		// Pointers to structs keep their struct type alongside PointerLevel > 0
 		if v3.PointerLevel != 2 {
 			t.Errorf("Stmt 2 expected PointerLevel=2, got %d", v3.PointerLevel)
 		}
+		if !v3.IsStruct || v3.StructName != "Node" {
+			t.Errorf("Stmt 2 expected IsStruct=true StructName=Node, got %v %q", v3.IsStruct, v3.StructName)
+		}
 	}
 
 	// 4. void f(int **a) {}
@@ -73,3 +76,37 @@ func TestParser_MultiLevelPointers(t *testing.T) {
 		}
 	}
 }
+
+func TestParser_StructPointer(t *testing.T) {
+	input := `
+	struct Point *p;
+	int x = p->y;
+	`
+	tokens, err := Lex(input)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, input)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	v, ok := stmts[0].(*VariableDecl)
+	if !ok {
+		t.Fatalf("Stmt 0 not VariableDecl")
+	}
+	if !v.IsStruct || v.StructName != "Point" || v.PointerLevel != 1 {
+		t.Errorf("expected struct Point pointer (level 1), got IsStruct=%v StructName=%q PointerLevel=%d",
+			v.IsStruct, v.StructName, v.PointerLevel)
+	}
+
+	// p->y is sugar for (*p).y
+	x, ok := stmts[1].(*VariableDecl)
+	if !ok {
+		t.Fatalf("Stmt 1 not VariableDecl")
+	}
+	want := &MemberExpr{Left: &UnaryExpr{Op: STAR, Right: &VarRef{Name: "p"}}, Member: "y"}
+	if x.Init.String() != want.String() {
+		t.Errorf("expected %s, got %s", want, x.Init)
+	}
+}
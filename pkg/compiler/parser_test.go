@@ -16,14 +16,14 @@ func TestParse(t *testing.T) {
 			name:  "Variable Declaration",
 			input: "int x = 10;",
 			expected: []Stmt{
-				&VariableDecl{Name: "x", Init: &Literal{Value: 10}},
+				&VariableDecl{Name: "x", Line: 1, Init: &Literal{Value: 10}},
 			},
 		},
 		{
 			name:  "Pointer Declaration",
 			input: "int* p = &x;",
 			expected: []Stmt{
-				&VariableDecl{Name: "p", Init: &UnaryExpr{Op: AND, Right: &VarRef{Name: "x"}}, PointerLevel: 1},
+				&VariableDecl{Name: "p", Line: 1, Init: &UnaryExpr{Op: AND, Right: &VarRef{Name: "x"}}, PointerLevel: 1},
 			},
 		},
 		{
@@ -31,7 +31,7 @@ func TestParse(t *testing.T) {
 			input: "int main() { x = 20; }",
 			expected: []Stmt{
 				&FunctionDecl{ReturnType: "int", Name: "main", Params: nil, Body: &BlockStmt{Stmts: []Stmt{
-					&Assignment{Op: ASSIGN, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 20}},
+					&Assignment{Op: ASSIGN, Line: 1, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 20}},
 				}}},
 			},
 		},
@@ -41,6 +41,7 @@ func TestParse(t *testing.T) {
 			expected: []Stmt{
 				&FunctionDecl{ReturnType: "int", Name: "main", Params: nil, Body: &BlockStmt{Stmts: []Stmt{
 					&Assignment{
+						Line:  1,
 						Op:    ASSIGN,
 						Left:  &UnaryExpr{Op: STAR, Right: &VarRef{Name: "p"}},
 						Value: &Literal{Value: 30},
@@ -54,12 +55,14 @@ func TestParse(t *testing.T) {
 			expected: []Stmt{
 				&FunctionDecl{ReturnType: "int", Name: "main", Params: nil, Body: &BlockStmt{Stmts: []Stmt{
 					&ExprStmt{
+						Line: 1,
 						Expr: &FunctionCall{
 							Name: "foo",
 							Args: []Expr{
 								&Literal{Value: 1},
 								&VarRef{Name: "x"},
 							},
+							Line: 1,
 						},
 					},
 				}}},
@@ -78,7 +81,7 @@ func TestParse(t *testing.T) {
 						},
 						Body: &BlockStmt{
 							Stmts: []Stmt{
-								&Assignment{Op: ASSIGN, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 2}},
+								&Assignment{Op: ASSIGN, Line: 1, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 2}},
 							},
 						},
 					},
@@ -98,12 +101,12 @@ func TestParse(t *testing.T) {
 						},
 						Body: &BlockStmt{
 							Stmts: []Stmt{
-								&Assignment{Op: ASSIGN, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 2}},
+								&Assignment{Op: ASSIGN, Line: 1, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 2}},
 							},
 						},
 						ElseBody: &BlockStmt{
 							Stmts: []Stmt{
-								&Assignment{Op: ASSIGN, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 3}},
+								&Assignment{Op: ASSIGN, Line: 1, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 3}},
 							},
 						},
 					},
@@ -123,7 +126,7 @@ func TestParse(t *testing.T) {
 						},
 						Body: &BlockStmt{
 							Stmts: []Stmt{
-								&Assignment{Op: ASSIGN, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 1}},
+								&Assignment{Op: ASSIGN, Line: 1, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 1}},
 							},
 						},
 					},
@@ -172,6 +175,7 @@ func TestParse(t *testing.T) {
 			expected: []Stmt{
 				&FunctionDecl{ReturnType: "int", Name: "main", Params: nil, Body: &BlockStmt{Stmts: []Stmt{
 					&Assignment{
+						Line: 1,
 						Op:   ASSIGN,
 						Left: &VarRef{Name: "x"},
 						Value: &BinaryExpr{
@@ -193,6 +197,7 @@ func TestParse(t *testing.T) {
 			expected: []Stmt{
 				&FunctionDecl{ReturnType: "int", Name: "main", Params: nil, Body: &BlockStmt{Stmts: []Stmt{
 					&Assignment{
+						Line: 1,
 						Op:   ASSIGN,
 						Left: &VarRef{Name: "x"},
 						Value: &BinaryExpr{
@@ -213,6 +218,7 @@ func TestParse(t *testing.T) {
 			expected: []Stmt{
 				&FunctionDecl{ReturnType: "int", Name: "main", Params: nil, Body: &BlockStmt{Stmts: []Stmt{
 					&Assignment{
+						Line: 1,
 						Op:   ASSIGN,
 						Left: &VarRef{Name: "x"},
 						Value: &BinaryExpr{
@@ -234,6 +240,7 @@ func TestParse(t *testing.T) {
 			expected: []Stmt{
 				&FunctionDecl{ReturnType: "int", Name: "main", Params: nil, Body: &BlockStmt{Stmts: []Stmt{
 					&Assignment{
+						Line: 1,
 						Op:   ASSIGN,
 						Left: &VarRef{Name: "x"},
 						Value: &BinaryExpr{
@@ -255,6 +262,7 @@ func TestParse(t *testing.T) {
 			expected: []Stmt{
 				&FunctionDecl{ReturnType: "int", Name: "main", Params: nil, Body: &BlockStmt{Stmts: []Stmt{
 					&Assignment{
+						Line: 1,
 						Op:   ASSIGN,
 						Left: &VarRef{Name: "x"},
 						Value: &BinaryExpr{
@@ -293,6 +301,7 @@ func TestParse(t *testing.T) {
 			expected: []Stmt{
 				&FunctionDecl{ReturnType: "int", Name: "main", Params: nil, Body: &BlockStmt{Stmts: []Stmt{
 					&Assignment{
+						Line: 1,
 						Op:   ASSIGN,
 						Left: &VarRef{Name: "x"},
 						Value: &BinaryExpr{
@@ -324,12 +333,12 @@ func TestParse(t *testing.T) {
 							{
 								Value: &Literal{Value: 1},
 								Body: []Stmt{
-									&Assignment{Op: ASSIGN, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 2}},
+									&Assignment{Op: ASSIGN, Line: 1, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 2}},
 								},
 							},
 						},
 						Default: []Stmt{
-							&Assignment{Op: ASSIGN, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 3}},
+							&Assignment{Op: ASSIGN, Line: 1, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 3}},
 						},
 					},
 				}}},
@@ -366,49 +375,49 @@ func TestParse_BitwiseAndShift(t *testing.T) {
 			name:  "Bitwise AND",
 			input: "int x = a & b;",
 			expected: []Stmt{
-				&VariableDecl{Name: "x", Init: &BinaryExpr{Op: AND, Left: &VarRef{Name: "a"}, Right: &VarRef{Name: "b"}}},
+				&VariableDecl{Name: "x", Line: 1, Init: &BinaryExpr{Op: AND, Left: &VarRef{Name: "a"}, Right: &VarRef{Name: "b"}}},
 			},
 		},
 		{
 			name:  "Bitwise OR",
 			input: "int x = a | b;",
 			expected: []Stmt{
-				&VariableDecl{Name: "x", Init: &BinaryExpr{Op: PIPE, Left: &VarRef{Name: "a"}, Right: &VarRef{Name: "b"}}},
+				&VariableDecl{Name: "x", Line: 1, Init: &BinaryExpr{Op: PIPE, Left: &VarRef{Name: "a"}, Right: &VarRef{Name: "b"}}},
 			},
 		},
 		{
 			name:  "Bitwise XOR",
 			input: "int x = a ^ b;",
 			expected: []Stmt{
-				&VariableDecl{Name: "x", Init: &BinaryExpr{Op: CARET, Left: &VarRef{Name: "a"}, Right: &VarRef{Name: "b"}}},
+				&VariableDecl{Name: "x", Line: 1, Init: &BinaryExpr{Op: CARET, Left: &VarRef{Name: "a"}, Right: &VarRef{Name: "b"}}},
 			},
 		},
 		{
 			name:  "Bitwise NOT",
 			input: "int x = ~a;",
 			expected: []Stmt{
-				&VariableDecl{Name: "x", Init: &UnaryExpr{Op: TILDE, Right: &VarRef{Name: "a"}}},
+				&VariableDecl{Name: "x", Line: 1, Init: &UnaryExpr{Op: TILDE, Right: &VarRef{Name: "a"}}},
 			},
 		},
 		{
 			name:  "Modulo",
 			input: "int x = a % b;",
 			expected: []Stmt{
-				&VariableDecl{Name: "x", Init: &BinaryExpr{Op: PERCENT, Left: &VarRef{Name: "a"}, Right: &VarRef{Name: "b"}}},
+				&VariableDecl{Name: "x", Line: 1, Init: &BinaryExpr{Op: PERCENT, Left: &VarRef{Name: "a"}, Right: &VarRef{Name: "b"}}},
 			},
 		},
 		{
 			name:  "Left Shift",
 			input: "int x = a << 2;",
 			expected: []Stmt{
-				&VariableDecl{Name: "x", Init: &BinaryExpr{Op: SHL_OP, Left: &VarRef{Name: "a"}, Right: &Literal{Value: 2}}},
+				&VariableDecl{Name: "x", Line: 1, Init: &BinaryExpr{Op: SHL_OP, Left: &VarRef{Name: "a"}, Right: &Literal{Value: 2}}},
 			},
 		},
 		{
 			name:  "Right Shift",
 			input: "int x = a >> 2;",
 			expected: []Stmt{
-				&VariableDecl{Name: "x", Init: &BinaryExpr{Op: SHR_OP, Left: &VarRef{Name: "a"}, Right: &Literal{Value: 2}}},
+				&VariableDecl{Name: "x", Line: 1, Init: &BinaryExpr{Op: SHR_OP, Left: &VarRef{Name: "a"}, Right: &Literal{Value: 2}}},
 			},
 		},
 		{
@@ -416,7 +425,7 @@ func TestParse_BitwiseAndShift(t *testing.T) {
 			name:  "Precedence OR lower than AND",
 			input: "int x = a | b & c;",
 			expected: []Stmt{
-				&VariableDecl{Name: "x", Init: &BinaryExpr{
+				&VariableDecl{Name: "x", Line: 1, Init: &BinaryExpr{
 					Op:   PIPE,
 					Left: &VarRef{Name: "a"},
 					Right: &BinaryExpr{Op: AND, Left: &VarRef{Name: "b"}, Right: &VarRef{Name: "c"}},
@@ -428,7 +437,7 @@ func TestParse_BitwiseAndShift(t *testing.T) {
 			name:  "Precedence AND lower than equality",
 			input: "int x = a & b == c;",
 			expected: []Stmt{
-				&VariableDecl{Name: "x", Init: &BinaryExpr{
+				&VariableDecl{Name: "x", Line: 1, Init: &BinaryExpr{
 					Op:   AND,
 					Left: &VarRef{Name: "a"},
 					Right: &BinaryExpr{Op: EQUALS, Left: &VarRef{Name: "b"}, Right: &VarRef{Name: "c"}},
@@ -440,7 +449,7 @@ func TestParse_BitwiseAndShift(t *testing.T) {
 			name:  "Address-of and bitwise AND mixed",
 			input: "int x = &p & 0xFF;",
 			expected: []Stmt{
-				&VariableDecl{Name: "x", Init: &BinaryExpr{
+				&VariableDecl{Name: "x", Line: 1, Init: &BinaryExpr{
 					Op:    AND,
 					Left:  &UnaryExpr{Op: AND, Right: &VarRef{Name: "p"}},
 					Right: &Literal{Value: 0xFF},
@@ -466,6 +475,180 @@ func TestParse_BitwiseAndShift(t *testing.T) {
 	}
 }
 
+func TestParse_CompoundAssignOps(t *testing.T) {
+	tests := []struct {
+		src string
+		op  TokenType
+	}{
+		{"x %= 3;", PERCENT_ASSIGN},
+		{"x &= 3;", AND_ASSIGN},
+		{"x |= 3;", PIPE_ASSIGN},
+		{"x ^= 3;", CARET_ASSIGN},
+		{"x <<= 3;", SHL_ASSIGN},
+		{"x >>= 3;", SHR_ASSIGN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.op.String(), func(t *testing.T) {
+			input := "int main() { " + tt.src + " }"
+			tokens, err := Lex(input)
+			if err != nil {
+				t.Fatalf("Lex failed: %v", err)
+			}
+			stmts, err := Parse(tokens, input)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			expected := []Stmt{
+				&FunctionDecl{ReturnType: "int", Name: "main", Params: nil, Body: &BlockStmt{Stmts: []Stmt{
+					&Assignment{Op: tt.op, Line: 1, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 3}},
+				}}},
+			}
+			if !reflect.DeepEqual(stmts, expected) {
+				t.Errorf("Parse mismatch:\nGot:      %v\nExpected: %v", stmts, expected)
+			}
+		})
+	}
+
+	t.Run("ForPost", func(t *testing.T) {
+		input := "int main() { for (i = 1; i < 100; i <<= 1) { } }"
+		tokens, err := Lex(input)
+		if err != nil {
+			t.Fatalf("Lex failed: %v", err)
+		}
+		stmts, err := Parse(tokens, input)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		loop := stmts[0].(*FunctionDecl).Body.(*BlockStmt).Stmts[0].(*ForStmt)
+		post, ok := loop.Post.(*Assignment)
+		if !ok || post.Op != SHL_ASSIGN {
+			t.Errorf("expected SHL_ASSIGN post statement, got %v", loop.Post)
+		}
+	})
+}
+
+func TestParse_Prefix(t *testing.T) {
+	input := "int x = ++a + b--;"
+	tokens, err := Lex(input)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, input)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	expected := []Stmt{
+		&VariableDecl{Name: "x", Line: 1, Init: &BinaryExpr{
+			Op:    PLUS,
+			Left:  &PostfixExpr{Op: PLUS_PLUS, Left: &VarRef{Name: "a"}, Prefix: true},
+			Right: &PostfixExpr{Op: MINUS_MINUS, Left: &VarRef{Name: "b"}},
+		}},
+	}
+	if !reflect.DeepEqual(stmts, expected) {
+		t.Errorf("Parse mismatch:\nGot:      %v\nExpected: %v", stmts, expected)
+	}
+}
+
+func TestParse_GotoLabel(t *testing.T) {
+	input := "int main() {\nagain:\n  x = 1;\n  goto again;\n}"
+	tokens, err := Lex(input)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, input)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	body := stmts[0].(*FunctionDecl).Body.(*BlockStmt).Stmts
+	expected := []Stmt{
+		&LabelStmt{Name: "again", Line: 2},
+		&Assignment{Op: ASSIGN, Left: &VarRef{Name: "x"}, Value: &Literal{Value: 1}, Line: 3},
+		&GotoStmt{Name: "again", Line: 4},
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("Parse mismatch:\nGot:      %v\nExpected: %v", body, expected)
+	}
+}
+
+func TestParse_Ternary(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Stmt
+	}{
+		{
+			name:  "Simple",
+			input: "int x = a ? b : c;",
+			expected: []Stmt{
+				&VariableDecl{Name: "x", Line: 1, Init: &TernaryExpr{Cond: &VarRef{Name: "a"}, Then: &VarRef{Name: "b"}, Else: &VarRef{Name: "c"}}},
+			},
+		},
+		{
+			name:  "Nested Parenthesised Else",
+			input: "int x = a ? b : (c ? d : e);",
+			expected: []Stmt{
+				&VariableDecl{Name: "x", Line: 1, Init: &TernaryExpr{
+					Cond: &VarRef{Name: "a"},
+					Then: &VarRef{Name: "b"},
+					Else: &TernaryExpr{Cond: &VarRef{Name: "c"}, Then: &VarRef{Name: "d"}, Else: &VarRef{Name: "e"}},
+				}},
+			},
+		},
+		{
+			// Right-associative: a ? b : c ? d : e == a ? b : (c ? d : e)
+			name:  "Nested Right Associative",
+			input: "int x = a ? b : c ? d : e;",
+			expected: []Stmt{
+				&VariableDecl{Name: "x", Line: 1, Init: &TernaryExpr{
+					Cond: &VarRef{Name: "a"},
+					Then: &VarRef{Name: "b"},
+					Else: &TernaryExpr{Cond: &VarRef{Name: "c"}, Then: &VarRef{Name: "d"}, Else: &VarRef{Name: "e"}},
+				}},
+			},
+		},
+		{
+			name:  "Nested Then",
+			input: "int x = a ? b ? c : d : e;",
+			expected: []Stmt{
+				&VariableDecl{Name: "x", Line: 1, Init: &TernaryExpr{
+					Cond: &VarRef{Name: "a"},
+					Then: &TernaryExpr{Cond: &VarRef{Name: "b"}, Then: &VarRef{Name: "c"}, Else: &VarRef{Name: "d"}},
+					Else: &VarRef{Name: "e"},
+				}},
+			},
+		},
+		{
+			// ?: binds looser than ||
+			name:  "Precedence Below LogicalOr",
+			input: "int x = a || b ? 1 : 2;",
+			expected: []Stmt{
+				&VariableDecl{Name: "x", Line: 1, Init: &TernaryExpr{
+					Cond: &LogicalExpr{Op: OR_LOGICAL, Left: &VarRef{Name: "a"}, Right: &VarRef{Name: "b"}},
+					Then: &Literal{Value: 1},
+					Else: &Literal{Value: 2},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Lex(tt.input)
+			if err != nil {
+				t.Fatalf("Lex failed: %v", err)
+			}
+			stmts, err := Parse(tokens, tt.input)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if !reflect.DeepEqual(stmts, tt.expected) {
+				t.Errorf("Parse mismatch:\nGot:      %v\nExpected: %v", stmts, tt.expected)
+			}
+		})
+	}
+}
+
 func TestParserErrors(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -478,6 +661,7 @@ func TestParserErrors(t *testing.T) {
 		{"Unexpected Token", "return;"}, // return expects an expression
 		{"Invalid Factor", "x = +;"},
 		{"Missing Pointer Name", "int* = 10;"},
+		{"Ternary Missing Colon", "int x = a ? b;"},
 		{"Malformed Array", "int arr[;"},
 		{"Anonymous Struct", "struct {} ;"},
 		{"Unnamed Parameter", "int foo(int) { }"},
@@ -51,9 +51,8 @@ func TestPointers(t *testing.T) {
 			}
 			`,
 			contains: []string{
-				"PUSH R1",      // save pointer address
+				"MOV R1, R0",   // pointer address
 				"LDI R0, 20",   // value
-				"POP R1",       // restore pointer address
 				"ST  [R1], R0", // store
 			},
 		},
@@ -14,9 +14,19 @@ type Macro struct {
 	Body string
 }
 
+// condFrame is one level of #ifdef/#ifndef nesting.
+type condFrame struct {
+	parentActive bool // whether the enclosing region is active
+	taken        bool // whether the current branch's condition holds
+	sawElse      bool
+	line         int // line of the opening directive, for error messages
+}
+
 // Preprocess scans the source code for `#include` and `#define` directives.
 // It replaces includes with file content and substitutes defines.
 // It handles nested includes and prevents circular dependencies.
+// `#ifdef`, `#ifndef`, `#else` and `#endif` drop the lines of inactive
+// regions, leaving blank lines so line numbers are preserved.
 func Preprocess(src string, baseDir string) (string, error) {
 	defines := make(map[string]Macro)
 	return preprocessRecursive(src, baseDir, make(map[string]bool), make(map[string]bool), defines)
@@ -25,12 +35,72 @@ func Preprocess(src string, baseDir string) (string, error) {
 func preprocessRecursive(src string, baseDir string, visitedStack map[string]bool, alreadyProcessed map[string]bool, defines map[string]Macro) (string, error) {
 	lines := strings.Split(src, "\n")
 	var result strings.Builder
+	var conds []condFrame
+	active := true
 
-	for _, line := range lines {
+	for idx := 0; idx < len(lines); idx++ {
+		line := lines[idx]
 		trimmed := strings.TrimSpace(line)
+		lineNo := idx + 1
+
+		// Conditionals are tracked even inside inactive regions so that
+		// nesting stays balanced.
+		if directive, arg, ok := conditionalDirective(trimmed); ok {
+			switch directive {
+			case "#ifdef", "#ifndef":
+				if arg == "" {
+					return "", fmt.Errorf("line %d: %s requires a macro name", lineNo, directive)
+				}
+				_, defined := defines[arg]
+				conds = append(conds, condFrame{
+					parentActive: active,
+					taken:        defined == (directive == "#ifdef"),
+					line:         lineNo,
+				})
+			case "#else":
+				if len(conds) == 0 {
+					return "", fmt.Errorf("line %d: #else without #ifdef", lineNo)
+				}
+				top := &conds[len(conds)-1]
+				if top.sawElse {
+					return "", fmt.Errorf("line %d: #else after #else", lineNo)
+				}
+				top.sawElse = true
+				top.taken = !top.taken
+			case "#endif":
+				if len(conds) == 0 {
+					return "", fmt.Errorf("line %d: #endif without #ifdef", lineNo)
+				}
+				conds = conds[:len(conds)-1]
+			}
+			active = true
+			if len(conds) > 0 {
+				top := conds[len(conds)-1]
+				active = top.parentActive && top.taken
+			}
+			result.WriteString("\n")
+			continue
+		}
+
+		if !active {
+			result.WriteString("\n")
+			continue
+		}
 
 		// Handle #define
 		if strings.HasPrefix(trimmed, "#define") {
+			// A trailing backslash continues the definition on the next line.
+			continued := 0
+			for strings.HasSuffix(trimmed, "\\") {
+				trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, "\\"))
+				if idx+1 >= len(lines) {
+					break
+				}
+				idx++
+				continued++
+				trimmed += " " + strings.TrimSpace(lines[idx])
+			}
+
 			// Expected format: #define NAME VALUE or #define NAME(ARGS) VALUE
 			rest := strings.TrimSpace(strings.TrimPrefix(trimmed, "#define"))
 			if rest == "" {
@@ -75,11 +145,30 @@ func preprocessRecursive(src string, baseDir string, visitedStack map[string]boo
 
 			defines[name] = Macro{Args: args, Body: value}
 
-			// Replace with empty line to preserve line count roughly
+			// Replace with empty lines to preserve line count
+			result.WriteString(strings.Repeat("\n", 1+continued))
+			continue
+		}
+
+		// Handle #undef: forget a macro; undefining an unknown name is a no-op
+		if fields := strings.Fields(trimmed); len(fields) > 0 && fields[0] == "#undef" {
+			if len(fields) < 2 {
+				return "", fmt.Errorf("line %d: #undef requires a macro name", lineNo)
+			}
+			delete(defines, fields[1])
 			result.WriteString("\n")
 			continue
 		}
 
+		// Handle #error: abort preprocessing with the given message
+		if strings.HasPrefix(trimmed, "#error") {
+			msg := strings.TrimSpace(strings.TrimPrefix(trimmed, "#error"))
+			if len(msg) >= 2 && msg[0] == '"' && msg[len(msg)-1] == '"' {
+				msg = msg[1 : len(msg)-1]
+			}
+			return "", fmt.Errorf("#error: %s", msg)
+		}
+
 		if strings.HasPrefix(trimmed, "#include") {
 			isSystemInclude := false
 			filename := ""
@@ -180,12 +269,41 @@ func preprocessRecursive(src string, baseDir string, visitedStack map[string]boo
 		result.WriteString(processedLine)
 		result.WriteString("\n")
 	}
+	if len(conds) > 0 {
+		return "", fmt.Errorf("line %d: unterminated #ifdef", conds[len(conds)-1].line)
+	}
 	return result.String(), nil
 }
 
+// conditionalDirective splits a trimmed line holding #ifdef, #ifndef, #else
+// or #endif into the directive and its macro name argument.
+func conditionalDirective(trimmed string) (directive, arg string, ok bool) {
+	fields := strings.Fields(trimmed)
+	if len(fields) == 0 {
+		return "", "", false
+	}
+	switch fields[0] {
+	case "#ifdef", "#ifndef", "#else", "#endif":
+		if len(fields) > 1 {
+			arg = fields[1]
+		}
+		return fields[0], arg, true
+	}
+	return "", "", false
+}
+
 // applyDefines replaces occurrences of keys in defines map with their values in the input string.
 // It ensures that replacements only happen on word boundaries and not inside string/char literals.
 func applyDefines(input string, defines map[string]Macro) string {
+	return expandMacros(input, defines, make(map[string]bool))
+}
+
+// expandMacros does the work of applyDefines. Replacement text is rescanned
+// for further macros, except for names in expanding: a macro is disabled
+// while its own body is being expanded, so self-referential and mutually
+// recursive macros terminate. A nil expanding set inserts replacement text
+// without rescanning it, which is how macro parameters are substituted.
+func expandMacros(input string, defines map[string]Macro, expanding map[string]bool) string {
 	if len(defines) == 0 {
 		return input
 	}
@@ -238,7 +356,7 @@ func applyDefines(input string, defines map[string]Macro) string {
 					i++
 				}
 				word := input[start:i]
-				if macro, ok := defines[word]; ok {
+				if macro, ok := defines[word]; ok && !expanding[word] {
 					// Found a macro. Check if it's function-like.
 					if len(macro.Args) > 0 {
 						// Function-like macro expansion
@@ -279,12 +397,12 @@ func applyDefines(input string, defines map[string]Macro) string {
 									body := macro.Body
 									argMap := make(map[string]Macro, len(macro.Args))
 									for k, argName := range macro.Args {
-										argMap[argName] = Macro{Body: args[k]}
+										// Arguments are fully expanded before substitution.
+										argMap[argName] = Macro{Body: expandMacros(args[k], defines, expanding)}
 									}
-									body = applyDefines(body, argMap)
+									body = expandMacros(body, argMap, nil)
 
-									expanded := applyDefines(body, defines)
-									sb.WriteString(expanded)
+									sb.WriteString(expandBody(word, body, defines, expanding))
 
 									i = j
 									continue
@@ -295,7 +413,7 @@ func applyDefines(input string, defines map[string]Macro) string {
 						sb.WriteString(word)
 					} else {
 						// Simple macro
-						sb.WriteString(macro.Body)
+						sb.WriteString(expandBody(word, macro.Body, defines, expanding))
 					}
 				} else {
 					sb.WriteString(word)
@@ -309,6 +427,16 @@ func applyDefines(input string, defines map[string]Macro) string {
 	return sb.String()
 }
 
+// expandBody rescans the replacement text of macro name with name disabled.
+func expandBody(name, body string, defines map[string]Macro, expanding map[string]bool) string {
+	if expanding == nil {
+		return body
+	}
+	expanding[name] = true
+	defer delete(expanding, name)
+	return expandMacros(body, defines, expanding)
+}
+
 func isIdentStart(r rune) bool {
 	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_'
 }
@@ -3,6 +3,7 @@ package compiler
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestPreprocessor_FunctionMacros(t *testing.T) {
@@ -82,3 +83,74 @@ int z = ADD(ADD(1, 2), 3);
 		// So I SHOULD implement recursion.
 	}
 }
+
+func TestPreprocessor_LineContinuation(t *testing.T) {
+	input := `#define CLAMP(v, lo, hi) \
+	((v) < (lo) ? (lo) : \
+	 (v) > (hi) ? (hi) : (v))
+int c = CLAMP(x, 0, 9);
+#define TWO 1 + \
+1
+int t = TWO;`
+	out, err := Preprocess(input, ".")
+	if err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+
+	lines := strings.Split(out, "\n")
+	if len(lines) < 7 {
+		t.Fatalf("expected line count to be preserved, got %q", out)
+	}
+	if want := "int c = ((x) < (0) ? (0) : (x) > (9) ? (9) : (x));"; lines[3] != want {
+		t.Errorf("line 4 = %q, want %q", lines[3], want)
+	}
+	if want := "int t = 1 + 1;"; lines[6] != want {
+		t.Errorf("line 7 = %q, want %q", lines[6], want)
+	}
+}
+
+func TestPreprocessor_SelfReferentialMacros(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"object-like self reference", "#define A A\nint x = A;", "int x = A;"},
+		{"mutual recursion", "#define A B\n#define B A\nint x = A + B;", "int x = B + B;"},
+		{"function-like self reference", "#define F(x) F(x + 1)\nint y = F(2);", "int y = F(2 + 1);"},
+		{"self reference in argument", "#define F(x) (x * 2)\nint y = F(F(3));", "int y = ((3 * 2) * 2);"},
+		{"rescan expands other macros", "#define DOUBLE(x) (x + x)\n#define ONE 1\nint z = DOUBLE(ONE);", "int z = (1 + 1);"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan struct{})
+			var out string
+			var err error
+			go func() {
+				out, err = Preprocess(tt.input, ".")
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(2 * time.Second):
+				t.Fatal("macro expansion did not terminate")
+			}
+			if err != nil {
+				t.Fatalf("Preprocess failed: %v", err)
+			}
+			if !strings.Contains(out, tt.expected) {
+				t.Errorf("expected %q in output, got %q", tt.expected, out)
+			}
+		})
+	}
+}
+
+func TestPreprocessor_NestedMacroArguments(t *testing.T) {
+	out, err := Preprocess("#define ADD(x, y) x + y\nint z = ADD(ADD(1, 2), 3);", ".")
+	if err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	if !strings.Contains(out, "int z = 1 + 2 + 3;") {
+		t.Errorf("nested call not expanded: %q", out)
+	}
+}
@@ -87,3 +87,140 @@ char c = 'C';
 		})
 	}
 }
+
+func TestPreprocessError(t *testing.T) {
+	src := `
+#define A 1
+#error "bad config"
+int x = A;
+`
+	_, err := Preprocess(src, ".")
+	if err == nil {
+		t.Fatal("expected #error to abort preprocessing")
+	}
+	if !strings.Contains(err.Error(), "bad config") {
+		t.Errorf("expected error to contain %q, got %q", "bad config", err.Error())
+	}
+
+	// Unquoted messages are passed through as-is.
+	_, err = Preprocess("#error unsupported target\n", ".")
+	if err == nil || !strings.Contains(err.Error(), "unsupported target") {
+		t.Errorf("expected unquoted #error message, got %v", err)
+	}
+}
+
+func TestPreprocessConditionals(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected string
+	}{
+		{
+			name: "Simple Guard",
+			src: `#define DEBUG
+#ifdef DEBUG
+int debug = 1;
+#endif
+#ifdef RELEASE
+int release = 1;
+#endif`,
+			expected: "\n\nint debug = 1;\n\n\n\n\n",
+		},
+		{
+			name: "Ifndef And Else",
+			src: `#ifndef WIDTH
+#define WIDTH 40
+#else
+#define WIDTH 80
+#endif
+int w = WIDTH;`,
+			expected: "\n\n\n\n\nint w = 40;\n",
+		},
+		{
+			name: "Nested Guards",
+			src: `#define OUTER
+#ifdef OUTER
+#ifdef INNER
+int a = 1;
+#else
+int a = 2;
+#endif
+#else
+#ifdef INNER
+int a = 3;
+#else
+int a = 4;
+#endif
+#endif`,
+			expected: "\n\n\n\n\nint a = 2;\n\n\n\n\n\n\n\n\n",
+		},
+		{
+			name: "Inactive Error Ignored",
+			src: `#ifdef MISSING
+#error "not reached"
+#include "missing.h"
+#endif
+int ok;`,
+			expected: "\n\n\n\nint ok;\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Preprocess(tt.src, ".")
+			if err != nil {
+				t.Fatalf("Preprocess failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Preprocess() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPreprocessConditionalErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{"Unterminated Ifdef", "int x;\n#ifdef A\n#ifdef B\n#endif\n", "line 2: unterminated #ifdef"},
+		{"Endif Without Ifdef", "#endif\n", "#endif without #ifdef"},
+		{"Else Without Ifdef", "#else\n", "#else without #ifdef"},
+		{"Duplicate Else", "#ifdef A\n#else\n#else\n#endif\n", "#else after #else"},
+		{"Missing Name", "#ifdef\n#endif\n", "#ifdef requires a macro name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Preprocess(tt.src, ".")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestPreprocessUndef(t *testing.T) {
+	src := `#define LIMIT 10
+int a = LIMIT;
+#undef LIMIT
+int b = LIMIT;
+#ifdef LIMIT
+int c = 1;
+#endif
+#undef NEVER_DEFINED
+#define LIMIT 20
+int d = LIMIT;`
+	got, err := Preprocess(src, ".")
+	if err != nil {
+		t.Fatalf("Preprocess failed: %v", err)
+	}
+	expected := "\nint a = 10;\n\nint b = LIMIT;\n\n\n\n\n\nint d = 20;\n"
+	if got != expected {
+		t.Errorf("Preprocess() = %q, want %q", got, expected)
+	}
+
+	if _, err := Preprocess("#undef\n", "."); err == nil || !strings.Contains(err.Error(), "#undef requires a macro name") {
+		t.Errorf("expected missing name error, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+)
+
+func compileSource(src string) (string, error) {
+	tokens, err := Lex(src)
+	if err != nil {
+		return "", err
+	}
+	stmts, err := Parse(tokens, src)
+	if err != nil {
+		return "", err
+	}
+	return Generate(stmts, NewSymbolTable())
+}
+
+func TestStaticAssert(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{
+			name: "struct size passes",
+			src: `struct P { int x; int y; };
+			static_assert(sizeof(struct P) == 4, "size");
+			int main() { return 0; }`,
+		},
+		{
+			name: "struct size fails",
+			src: `struct P { int x; int y; char c; };
+			static_assert(sizeof(struct P) == 4, "size");
+			int main() { return 0; }`,
+			wantErr: "static assertion failed: size",
+		},
+		{
+			name: "inside function",
+			src:  `int main() { int buf[8]; static_assert(sizeof(buf) == 16 && 1 < 2, "buf"); return 0; }`,
+		},
+		{
+			name:    "inside function fails",
+			src:     `int main() { static_assert(sizeof(char) - 1, "char size"); return 0; }`,
+			wantErr: "static assertion failed: char size",
+		},
+		{
+			name:    "not constant",
+			src:     `int g = 1; int main() { static_assert(g, "g"); return 0; }`,
+			wantErr: "not a compile-time constant",
+		},
+		{
+			name:    "message must be string",
+			src:     `int main() { static_assert(1, 2); return 0; }`,
+			wantErr: "string literal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileSource(tt.src)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestStaticAssert_EmitsNoCode(t *testing.T) {
+	regs := runCode(t, `int main() { int x = 7; static_assert(1, "ok"); return x; }`)
+	if regs[0] != 7 {
+		t.Errorf("expected 7, got %d", regs[0])
+	}
+}
@@ -8,6 +8,7 @@ import (
 func TestStringLiteral(t *testing.T) {
 	// Source code with string literals
 	src := `
+		void print(char *s);
 		int main() {
 			print("Hello");
 			print("World");
@@ -63,6 +64,7 @@ func TestStringLiteral(t *testing.T) {
 
 func TestStringLiteralEscape(t *testing.T) {
 	src := `
+		void print(char *s);
 		int main() {
 			print("Line1\nLine2");
 		}
@@ -91,3 +93,15 @@ func TestStringLiteralEscape(t *testing.T) {
 		t.Errorf("Expected %s, not found in:\n%s", expected, code)
 	}
 }
+
+func TestStringLiteral_EscapedBytes_E2E(t *testing.T) {
+	// Each byte must survive the trip through the assembler's .STRING.
+	src := `int main() {
+		char *s = "\x1b\"\\\xff\tA";
+		return s[0] + s[1] + s[2] + s[3] + s[4] + s[5] + s[6];
+	}`
+	regs := runCode(t, src)
+	if want := uint16(0x1b + '"' + '\\' + 0xff + '\t' + 'A'); regs[0] != want {
+		t.Errorf("expected %d, got %d", want, regs[0])
+	}
+}
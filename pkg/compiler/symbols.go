@@ -0,0 +1,48 @@
+package compiler
+
+import (
+	"sort"
+
+	"gocpu/pkg/asm"
+)
+
+// SymbolMap records where a compiled program's globals and functions live
+// in memory. It marshals to JSON for external debuggers.
+type SymbolMap struct {
+	Globals   []SymbolAddress `json:"globals"`
+	Functions []SymbolAddress `json:"functions"`
+}
+
+// SymbolAddress is one entry of a SymbolMap. Size is the size in bytes of a
+// global and is omitted for functions.
+type SymbolAddress struct {
+	Name    string `json:"name"`
+	Address uint16 `json:"address"`
+	Size    int    `json:"size,omitempty"`
+}
+
+// buildSymbolMap resolves the labels of the symbol table's globals and of
+// every generated function (the keys of frameSizes) against the assembled
+// program. Entries are sorted by address.
+func buildSymbolMap(syms *SymbolTable, frameSizes map[string]int, a *asm.Assembler) *SymbolMap {
+	m := &SymbolMap{Globals: []SymbolAddress{}, Functions: []SymbolAddress{}}
+	for name, sym := range syms.globals {
+		if addr, ok := a.LabelAddress(sym.Label); ok {
+			m.Globals = append(m.Globals, SymbolAddress{Name: name, Address: addr, Size: sym.Size})
+		}
+	}
+	for name := range frameSizes {
+		if addr, ok := a.LabelAddress(name); ok {
+			m.Functions = append(m.Functions, SymbolAddress{Name: name, Address: addr})
+		}
+	}
+	for _, list := range [][]SymbolAddress{m.Globals, m.Functions} {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Address != list[j].Address {
+				return list[i].Address < list[j].Address
+			}
+			return list[i].Name < list[j].Name
+		})
+	}
+	return m
+}
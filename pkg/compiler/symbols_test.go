@@ -0,0 +1,56 @@
+package compiler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompileWithSymbols(t *testing.T) {
+	src := `int counter = 5;
+char buf[10];
+int bump(int n) { counter = counter + n; return counter; }
+int main() { return bump(2); }`
+	_, code, symbols, err := CompileWithSymbols(src, ".")
+	if err != nil {
+		t.Fatalf("CompileWithSymbols failed: %v", err)
+	}
+
+	find := func(list []SymbolAddress, name string) (SymbolAddress, bool) {
+		for _, s := range list {
+			if s.Name == name {
+				return s, true
+			}
+		}
+		return SymbolAddress{}, false
+	}
+
+	counter, ok := find(symbols.Globals, "counter")
+	if !ok {
+		t.Fatalf("global counter missing from %+v", symbols.Globals)
+	}
+	if counter.Size != 2 {
+		t.Errorf("counter size = %d, want 2", counter.Size)
+	}
+	// The initialised global's data word sits at its address.
+	if got := uint16(code[counter.Address]) | uint16(code[counter.Address+1])<<8; got != 5 {
+		t.Errorf("word at counter's address 0x%04X = %d, want 5", counter.Address, got)
+	}
+	if buf, ok := find(symbols.Globals, "buf"); !ok || buf.Size != 10 {
+		t.Errorf("buf = %+v, %v; want size 10", buf, ok)
+	}
+
+	for _, name := range []string{"main", "bump"} {
+		if _, ok := find(symbols.Functions, name); !ok {
+			t.Errorf("function %s missing from %+v", name, symbols.Functions)
+		}
+	}
+
+	data, err := json.Marshal(symbols)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `{"name":"counter","address":`) {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+}
@@ -29,9 +29,11 @@ type FieldInfo struct {
 }
 
 type StructDef struct {
-	Name   string
-	Fields map[string]FieldInfo
-	Size   int
+	Name    string
+	Fields  map[string]FieldInfo
+	Order   []string // field names in declaration order
+	Size    int
+	IsUnion bool // every field at offset 0; Size is the largest field
 }
 
 type Symbol struct {
@@ -56,18 +58,29 @@ type SymbolTable struct {
 	nextLocal int16
 
 	structs map[string]StructDef
+
+	// Named integer constants (enumerators). They occupy no storage.
+	// localConsts holds those declared in blocks, parallel to locals.
+	consts      map[string]uint16
+	localConsts []map[string]uint16
+
+	// Type aliases declared with typedef.
+	typedefs map[string]TypeInfo
 }
 
 func NewSymbolTable() *SymbolTable {
 	return &SymbolTable{
-		globals: make(map[string]Symbol),
-		structs: make(map[string]StructDef),
+		globals:  make(map[string]Symbol),
+		structs:  make(map[string]StructDef),
+		consts:   make(map[string]uint16),
+		typedefs: make(map[string]TypeInfo),
 	}
 }
 
 func (s *SymbolTable) EnterFunction() {
 	// Initialize stack with one scope (function body).
 	s.locals = []map[string]Symbol{make(map[string]Symbol)}
+	s.localConsts = []map[string]uint16{make(map[string]uint16)}
 	// FP points to saved FP (which is 2 bytes). Locals start at FP.
 	// We allocate downwards.
 	s.nextLocal = 0
@@ -78,16 +91,19 @@ func (s *SymbolTable) EnterScope() {
 		panic("EnterScope called outside function")
 	}
 	s.locals = append(s.locals, make(map[string]Symbol))
+	s.localConsts = append(s.localConsts, make(map[string]uint16))
 }
 
 func (s *SymbolTable) ExitScope() {
 	if len(s.locals) > 0 {
 		s.locals = s.locals[:len(s.locals)-1]
+		s.localConsts = s.localConsts[:len(s.localConsts)-1]
 	}
 }
 
 func (s *SymbolTable) ExitFunction() {
 	s.locals = nil
+	s.localConsts = nil
 }
 
 func (s *SymbolTable) DefineParam(decl VariableDecl, paramIndex int) {
@@ -154,6 +170,55 @@ func (s *SymbolTable) GetStruct(name string) (StructDef, bool) {
 	return d, ok
 }
 
+// GetUnion is GetStruct restricted to definitions made with union. Struct
+// and union tags share one namespace, as in C.
+func (s *SymbolTable) GetUnion(name string) (StructDef, bool) {
+	d, ok := s.structs[name]
+	if !ok || !d.IsUnion {
+		return StructDef{}, false
+	}
+	return d, true
+}
+
+// DefineConst registers a named integer constant such as an enumerator in
+// the current scope; inside a function it is dropped when the scope exits.
+// Redefining a constant with the same value (e.g. the same enum included by
+// two files) is allowed; it returns false if name already has another value.
+func (s *SymbolTable) DefineConst(name string, val uint16) bool {
+	consts := s.consts
+	if len(s.localConsts) > 0 {
+		consts = s.localConsts[len(s.localConsts)-1]
+	}
+	if old, ok := consts[name]; ok && old != val {
+		return false
+	}
+	consts[name] = val
+	return true
+}
+
+// Const returns the value of the innermost named constant and whether it
+// exists.
+func (s *SymbolTable) Const(name string) (uint16, bool) {
+	for i := len(s.localConsts) - 1; i >= 0; i-- {
+		if v, ok := s.localConsts[i][name]; ok {
+			return v, true
+		}
+	}
+	v, ok := s.consts[name]
+	return v, ok
+}
+
+// DefineTypedef records name as an alias for typ.
+func (s *SymbolTable) DefineTypedef(name string, typ TypeInfo) {
+	s.typedefs[name] = typ
+}
+
+// Typedef returns the type aliased by name and whether it exists.
+func (s *SymbolTable) Typedef(name string) (TypeInfo, bool) {
+	t, ok := s.typedefs[name]
+	return t, ok
+}
+
 // Allocate assigns the next free address/offset to name in the CURRENT scope.
 // If name is already in the current scope, existing symbol is returned.
 func (s *SymbolTable) Allocate(name string, typeInfo TypeInfo, size int) (Symbol, bool) {
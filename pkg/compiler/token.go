@@ -21,6 +21,7 @@ const (
 	IF       // "if"
 	ELSE     // "else"
 	WHILE    // "while"
+	DO       // "do"
 	RETURN   // "return"
 	STRUCT   // "struct"
 	FOR      // "for"
@@ -30,6 +31,11 @@ const (
 	DEFAULT  // "default"
 	BREAK    // "break"
 	CONTINUE // "continue"
+	GOTO     // "goto"
+	SIZEOF   // "sizeof"
+	ENUM     // "enum"
+	TYPEDEF  // "typedef"
+	UNION    // "union"
 
 	// Paired delimiters
 	LBRACE   // {
@@ -41,9 +47,11 @@ const (
 
 	// Punctuation
 	DOT       // .
+	ARROW     // ->
 	SEMICOLON // ;
 	COMMA     // ,
 	COLON     // :
+	QUESTION  // ?
 
 	// Arithmetic operators
 	PLUS        // +
@@ -65,11 +73,17 @@ const (
 	MINUS_MINUS // --
 
 	// Assignment / comparison  (order matters: ASSIGN before EQUALS)
-	ASSIGN       // =
-	PLUS_ASSIGN  // +=
-	MINUS_ASSIGN // -=
-	STAR_ASSIGN  // *=
-	SLASH_ASSIGN // /=
+	ASSIGN         // =
+	PLUS_ASSIGN    // +=
+	MINUS_ASSIGN   // -=
+	STAR_ASSIGN    // *=
+	SLASH_ASSIGN   // /=
+	PERCENT_ASSIGN // %=
+	AND_ASSIGN     // &=
+	PIPE_ASSIGN    // |=
+	CARET_ASSIGN   // ^=
+	SHL_ASSIGN     // <<=
+	SHR_ASSIGN     // >>=
 
 	EQUALS  // ==
 	NOT_EQ  // !=
@@ -91,68 +105,82 @@ const (
 // tokenNames is indexed by TokenType; the compiler enforces the length via the
 // blank identifier check in init() below.
 var tokenNames = [...]string{
-	EOF:          "EOF",
-	IDENTIFIER:   "IDENTIFIER",
-	INTEGER:      "INTEGER",
-	STRING:       "STRING",
-	INT:          "INT",
-	CHAR:         "CHAR",
-	UNSIGNED:     "UNSIGNED",
-	VOID:         "VOID",
-	IF:           "IF",
-	ELSE:         "ELSE",
-	WHILE:        "WHILE",
-	RETURN:       "RETURN",
-	STRUCT:       "STRUCT",
-	FOR:          "FOR",
-	ASM:          "ASM",
-	SWITCH:       "SWITCH",
-	CASE:         "CASE",
-	DEFAULT:      "DEFAULT",
-	BREAK:        "BREAK",
-	CONTINUE:     "CONTINUE",
-	LBRACE:       "LBRACE",
-	RBRACE:       "RBRACE",
-	LPAREN:       "LPAREN",
-	RPAREN:       "RPAREN",
-	LBRACKET:     "LBRACKET",
-	RBRACKET:     "RBRACKET",
-	DOT:          "DOT",
-	SEMICOLON:    "SEMICOLON",
-	COMMA:        "COMMA",
-	COLON:        "COLON",
-	PLUS:         "PLUS",
-	MINUS:        "MINUS",
-	STAR:         "STAR",
-	SLASH:        "SLASH",
-	AND:          "AND",
-	PIPE:         "PIPE",
-	CARET:        "CARET",
-	TILDE:        "TILDE",
-	PERCENT:      "PERCENT",
-	SHL_OP:       "SHL_OP",
-	SHR_OP:       "SHR_OP",
-	AND_LOGICAL:  "AND_LOGICAL",
-	OR_LOGICAL:   "OR_LOGICAL",
-	NOT:          "NOT",
-	PLUS_PLUS:    "PLUS_PLUS",
-	MINUS_MINUS:  "MINUS_MINUS",
-	ASSIGN:       "ASSIGN",
-	PLUS_ASSIGN:  "PLUS_ASSIGN",
-	MINUS_ASSIGN: "MINUS_ASSIGN",
-	STAR_ASSIGN:  "STAR_ASSIGN",
-	SLASH_ASSIGN: "SLASH_ASSIGN",
-	EQUALS:       "EQUALS",
-	NOT_EQ:       "NOT_EQ",
-	LESS:         "LESS",
-	GREATER:      "GREATER",
-	UNSIGNED_LIT: "UNSIGNED_LIT",
-	LESS_EQ:      "LESS_EQ",
-	GREATER_EQ:   "GREATER_EQ",
-	VOLATILE:     "VOLATILE",
-	CONST:        "CONST",
-	STATIC:       "STATIC",
-	EXTERN:       "EXTERN",
+	EOF:            "EOF",
+	IDENTIFIER:     "IDENTIFIER",
+	INTEGER:        "INTEGER",
+	STRING:         "STRING",
+	INT:            "INT",
+	CHAR:           "CHAR",
+	UNSIGNED:       "UNSIGNED",
+	VOID:           "VOID",
+	IF:             "IF",
+	ELSE:           "ELSE",
+	WHILE:          "WHILE",
+	DO:             "DO",
+	RETURN:         "RETURN",
+	STRUCT:         "STRUCT",
+	FOR:            "FOR",
+	ASM:            "ASM",
+	SWITCH:         "SWITCH",
+	CASE:           "CASE",
+	DEFAULT:        "DEFAULT",
+	BREAK:          "BREAK",
+	CONTINUE:       "CONTINUE",
+	GOTO:           "GOTO",
+	SIZEOF:         "SIZEOF",
+	ENUM:           "ENUM",
+	TYPEDEF:        "TYPEDEF",
+	UNION:          "UNION",
+	LBRACE:         "LBRACE",
+	RBRACE:         "RBRACE",
+	LPAREN:         "LPAREN",
+	RPAREN:         "RPAREN",
+	LBRACKET:       "LBRACKET",
+	RBRACKET:       "RBRACKET",
+	DOT:            "DOT",
+	ARROW:          "ARROW",
+	SEMICOLON:      "SEMICOLON",
+	COMMA:          "COMMA",
+	COLON:          "COLON",
+	QUESTION:       "QUESTION",
+	PLUS:           "PLUS",
+	MINUS:          "MINUS",
+	STAR:           "STAR",
+	SLASH:          "SLASH",
+	AND:            "AND",
+	PIPE:           "PIPE",
+	CARET:          "CARET",
+	TILDE:          "TILDE",
+	PERCENT:        "PERCENT",
+	SHL_OP:         "SHL_OP",
+	SHR_OP:         "SHR_OP",
+	AND_LOGICAL:    "AND_LOGICAL",
+	OR_LOGICAL:     "OR_LOGICAL",
+	NOT:            "NOT",
+	PLUS_PLUS:      "PLUS_PLUS",
+	MINUS_MINUS:    "MINUS_MINUS",
+	ASSIGN:         "ASSIGN",
+	PLUS_ASSIGN:    "PLUS_ASSIGN",
+	MINUS_ASSIGN:   "MINUS_ASSIGN",
+	STAR_ASSIGN:    "STAR_ASSIGN",
+	SLASH_ASSIGN:   "SLASH_ASSIGN",
+	PERCENT_ASSIGN: "PERCENT_ASSIGN",
+	AND_ASSIGN:     "AND_ASSIGN",
+	PIPE_ASSIGN:    "PIPE_ASSIGN",
+	CARET_ASSIGN:   "CARET_ASSIGN",
+	SHL_ASSIGN:     "SHL_ASSIGN",
+	SHR_ASSIGN:     "SHR_ASSIGN",
+	EQUALS:         "EQUALS",
+	NOT_EQ:         "NOT_EQ",
+	LESS:           "LESS",
+	GREATER:        "GREATER",
+	UNSIGNED_LIT:   "UNSIGNED_LIT",
+	LESS_EQ:        "LESS_EQ",
+	GREATER_EQ:     "GREATER_EQ",
+	VOLATILE:       "VOLATILE",
+	CONST:          "CONST",
+	STATIC:         "STATIC",
+	EXTERN:         "EXTERN",
 }
 
 func (tt TokenType) String() string {
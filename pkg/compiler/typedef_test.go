@@ -0,0 +1,140 @@
+package compiler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse_TypedefResolvesAlias(t *testing.T) {
+	input := `struct Point { int x; int y; };
+typedef struct Point Vec;
+typedef int word;
+typedef char *bytes;
+Vec origin;
+word count = 3;
+Vec *cursor;`
+	tokens, err := Lex(input)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, input)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(stmts) != 7 {
+		t.Fatalf("expected 7 statements, got %d: %v", len(stmts), stmts)
+	}
+
+	expected := []Stmt{
+		&TypedefDecl{Name: "Vec", Type: TypeInfo{IsStruct: true, StructName: "Point"}},
+		&TypedefDecl{Name: "word", Type: TypeInfo{}},
+		&TypedefDecl{Name: "bytes", Type: TypeInfo{IsChar: true, PointerLevel: 1}},
+		&VariableDecl{Name: "origin", Line: 5, IsStruct: true, StructName: "Point"},
+		&VariableDecl{Name: "count", Line: 6, Init: &Literal{Value: 3}},
+		&VariableDecl{Name: "cursor", Line: 7, IsStruct: true, StructName: "Point", PointerLevel: 1},
+	}
+	if !reflect.DeepEqual(stmts[1:], expected) {
+		t.Errorf("Parse mismatch:\nGot:      %v\nExpected: %v", stmts[1:], expected)
+	}
+}
+
+func TestGenerate_TypedefRecordedInSymbolTable(t *testing.T) {
+	src := "typedef char byte; int main() { byte b = 1; return b; }"
+	tokens, err := Lex(src)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, src)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	syms := NewSymbolTable()
+	if _, err := Generate(stmts, syms); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if typ, ok := syms.Typedef("byte"); !ok || !typ.IsChar {
+		t.Errorf("Typedef(byte) = %+v, %v; want char alias", typ, ok)
+	}
+}
+
+func TestTypedef_E2E(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"scalar alias", `
+		typedef int word;
+		word twice(word v) { return v * 2; }
+		int main() { word w = 21; return twice(w); }`, 42},
+		{"struct alias", `
+		struct Point { int x; int y; };
+		typedef struct Point Vec;
+		Vec g;
+		int main() {
+			Vec v;
+			Vec *p = &g;
+			v.x = 3;
+			p->y = 4;
+			return v.x * 10 + g.y + sizeof(Vec);
+		}`, 38},
+		{"char alias stores bytes", `
+		typedef char byte;
+		int main() { byte b = 255; b = b + 2; return b; }`, 1},
+		{"array alias", `
+		typedef int row[3];
+		int main() {
+			row r;
+			row grid[2];
+			r[2] = 5;
+			grid[1][2] = 7;
+			return sizeof(grid) * 10 + r[2] + grid[1][2];
+		}`, 132},
+		{"alias in cast and for loop", `
+		typedef unsigned int uword;
+		int main() {
+			int sum = 0;
+			for (uword i = 0; i < 4; i++) { sum = sum + i; }
+			return (uword)sum;
+		}`, 6},
+		{"unsigned typedef cast", `
+		typedef unsigned int u16;
+		int main() {
+			int a = -2;
+			return (u16)a / 2;
+		}`, 0x7FFF},
+		{"unsigned typedef variable", `
+		typedef unsigned int u16;
+		int main() {
+			u16 a = 0xFFFE;
+			u16 b = 2;
+			return a / b + (a > 1);
+		}`, 0x8000},
+		{"local typedef", `
+		int main() {
+			typedef char small;
+			small s = 7;
+			return s;
+		}`, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, regs[0])
+			}
+		})
+	}
+}
+
+func TestParse_TypedefConflict(t *testing.T) {
+	src := "typedef int word; typedef char word;"
+	tokens, err := Lex(src)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	if _, err := Parse(tokens, src); err == nil || !strings.Contains(err.Error(), `conflicting types for typedef "word"`) {
+		t.Errorf("expected conflicting typedef error, got %v", err)
+	}
+}
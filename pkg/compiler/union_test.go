@@ -0,0 +1,92 @@
+package compiler
+
+import "testing"
+
+func TestUnion_Layout(t *testing.T) {
+	src := `union Word { int w; char b[2]; char big[5]; };
+	int main() { return 0; }`
+	tokens, err := Lex(src)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, src)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if decl := stmts[0].(*StructDecl); !decl.IsUnion {
+		t.Fatalf("expected a union declaration, got %v", decl)
+	}
+	syms := NewSymbolTable()
+	if _, err := Generate(stmts, syms); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	def, ok := syms.GetUnion("Word")
+	if !ok {
+		t.Fatal("GetUnion(Word) not found")
+	}
+	if def.Size != 5 {
+		t.Errorf("union size = %d, want 5 (largest field)", def.Size)
+	}
+	for name, f := range def.Fields {
+		if f.Offset != 0 {
+			t.Errorf("field %s at offset %d, want 0", name, f.Offset)
+		}
+	}
+	if _, ok := syms.GetStruct("Word"); !ok {
+		t.Error("unions share the struct tag namespace")
+	}
+}
+
+func TestUnion_GetUnionRejectsStruct(t *testing.T) {
+	syms := NewSymbolTable()
+	syms.DefineStruct(StructDef{Name: "P", Fields: map[string]FieldInfo{}})
+	if _, ok := syms.GetUnion("P"); ok {
+		t.Error("GetUnion should not return a struct definition")
+	}
+}
+
+func TestUnion_E2E(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected uint16
+	}{
+		{"int and char array alias", `
+		union Word { int w; char b[2]; };
+		int main() {
+			union Word u;
+			u.w = 0x1234;
+			// Little-endian: b[0] is the low byte.
+			return u.b[0] * 256 + u.b[1];
+		}`, 0x3412},
+		{"write bytes read word", `
+		union Word { int w; char b[2]; };
+		union Word g;
+		int main() {
+			g.b[0] = 0xCD;
+			g.b[1] = 0xAB;
+			return g.w;
+		}`, 0xABCD},
+		{"union inside struct", `
+		struct Packet {
+			int kind;
+			union { int word; char bytes[2]; } data;
+		};
+		int main() {
+			struct Packet p;
+			p.kind = 1;
+			p.data.word = 0x0102;
+			return p.data.bytes[1] * 10 + sizeof(struct Packet) + sizeof(union Word2);
+		}
+		union Word2 { int a; int b; char c; };`, 10 + 4 + 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regs := runCode(t, tt.src)
+			if regs[0] != tt.expected {
+				t.Errorf("expected 0x%04X, got 0x%04X", tt.expected, regs[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+)
+
+// collectWarnings compiles src and returns every warning reported.
+func collectWarnings(t *testing.T, src string) []Warning {
+	t.Helper()
+	tokens, err := Lex(src)
+	if err != nil {
+		t.Fatalf("Lex failed: %v", err)
+	}
+	stmts, err := Parse(tokens, src)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	var warnings []Warning
+	_, err = GenerateWithOptions(stmts, NewSymbolTable(), Options{
+		Warn: func(w Warning) { warnings = append(warnings, w) },
+	})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	return warnings
+}
+
+func TestNarrowingWarnings(t *testing.T) {
+	src := `int someInt = 5;
+int *ptr;
+int main() {
+	char c = 300;
+	char d = someInt;
+	char e = (char)someInt;
+	char f = 'A';
+	char g = -1;
+	int n = ptr;
+	int m = (int)ptr;
+	e = someInt;
+	e = d;
+	return 0;
+}`
+	warnings := collectWarnings(t, src)
+
+	want := []struct {
+		line int
+		msg  string
+	}{
+		{4, "changes value from 300 to 44"},
+		{5, "may truncate value"},
+		{9, "assigning pointer to int without a cast"},
+		{11, "may truncate value"},
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("got %d warnings, want %d: %v", len(warnings), len(want), warnings)
+	}
+	for i, w := range want {
+		if warnings[i].Line != w.line {
+			t.Errorf("warning %d: line = %d, want %d (%s)", i, warnings[i].Line, w.line, warnings[i])
+		}
+		if !strings.Contains(warnings[i].Msg, w.msg) {
+			t.Errorf("warning %d: %q does not mention %q", i, warnings[i].Msg, w.msg)
+		}
+	}
+}
+
+func TestNarrowingWarnings_Globals(t *testing.T) {
+	warnings := collectWarnings(t, `char big = 1000;
+char ok = 200;
+int main() { return 0; }`)
+	if len(warnings) != 1 {
+		t.Fatalf("got %d warnings, want 1: %v", len(warnings), warnings)
+	}
+	if got := warnings[0].String(); got != "line 1: warning: implicit conversion from int to char changes value from 1000 to 232" {
+		t.Errorf("unexpected warning: %s", got)
+	}
+}
+
+func TestNoEffectWarnings(t *testing.T) {
+	src := `int foo() { return 1; }
+int main() {
+	int x = 1;
+	int *p = &x;
+	x + 1;
+	foo();
+	x++;
+	--x;
+	x == 2;
+	(-x);
+	*p;
+	foo() + 1;
+	for (x = 0; x < 3; x + 1) { break; }
+	return 0;
+}`
+	warnings := collectWarnings(t, src)
+
+	wantLines := []int{5, 9, 10, 13}
+	if len(warnings) != len(wantLines) {
+		t.Fatalf("got %d warnings, want %d: %v", len(warnings), len(wantLines), warnings)
+	}
+	for i, line := range wantLines {
+		if warnings[i].Line != line {
+			t.Errorf("warning %d: line = %d, want %d (%s)", i, warnings[i].Line, line, warnings[i])
+		}
+		if !strings.Contains(warnings[i].Msg, "statement has no effect") {
+			t.Errorf("warning %d: unexpected message %q", i, warnings[i].Msg)
+		}
+	}
+}
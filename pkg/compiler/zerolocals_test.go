@@ -0,0 +1,71 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+
+	"gocpu/pkg/asm"
+	"gocpu/pkg/cpu"
+)
+
+const zeroLocalsSrc = `
+void scribble() {
+	int junk[4];
+	junk[0] = 111;
+	junk[1] = 222;
+	junk[2] = 333;
+	junk[3] = 444;
+}
+int probe() {
+	int a;
+	char c;
+	int b[3];
+	return a + c + b[0] + b[1] + b[2];
+}
+int main() {
+	scribble();
+	return probe();
+}
+`
+
+// runWith compiles src with opts and runs it until HLT, returning R0.
+func runWith(t *testing.T, src string, opts Options) uint16 {
+	t.Helper()
+	code := generateWith(t, src, opts)
+	mc, _, err := asm.Assemble(code)
+	if err != nil {
+		t.Fatalf("Assemble failed: %v", err)
+	}
+	vm := cpu.NewCPU()
+	copy(vm.Memory[:], mc)
+	for i := 0; i < 10000 && !vm.Halted; i++ {
+		vm.Step()
+	}
+	if !vm.Halted {
+		t.Fatal("program did not halt")
+	}
+	return vm.Regs[0]
+}
+
+func TestZeroLocals(t *testing.T) {
+	// scribble leaves its values on the stack, where probe's locals land.
+	if got := runWith(t, zeroLocalsSrc, Options{}); got == 0 {
+		t.Errorf("without ZeroLocals probe read 0; expected leftover stack data")
+	}
+	if got := runWith(t, zeroLocalsSrc, Options{ZeroLocals: true}); got != 0 {
+		t.Errorf("with ZeroLocals probe read %d, want 0", got)
+	}
+}
+
+func TestZeroLocals_Codegen(t *testing.T) {
+	code := generateWith(t, `int main() { char c; int x; return x; }`, Options{ZeroLocals: true})
+	// 3 bytes of locals are rounded up to 4 so FILL clears 2 whole words.
+	assertContains(t, code, "LDI R1, 4")
+	assertContains(t, code, "LDI R1, 2")
+	assertContains(t, code, "FILL R3, R1, R0")
+
+	off := generateWith(t, `int main() { int x; return x; }`, Options{})
+	if strings.Contains(off, "FILL") {
+		t.Errorf("FILL emitted without Options.ZeroLocals:\n%s", off)
+	}
+}
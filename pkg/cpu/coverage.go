@@ -0,0 +1,57 @@
+package cpu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CoverageReport disassembles code from address 0 and marks every
+// instruction that PCHistogram saw executed with "*". Instructions that
+// never ran are left unmarked, and the report ends with a summary line.
+// PCHistogram must have been enabled before the run for the report to show
+// any coverage.
+func (c *CPU) CoverageReport(code []byte) string {
+	var sb strings.Builder
+	total, covered := 0, 0
+	for pc := 0; pc < len(code); {
+		text, size := DisassembleAt(code, pc)
+		mark := " "
+		if c.PCHistogram[uint16(pc)] > 0 {
+			mark = "*"
+			covered++
+		}
+		total++
+		fmt.Fprintf(&sb, "%s %04X  %s\n", mark, pc, text)
+		pc += size
+	}
+	fmt.Fprintf(&sb, "coverage: %d/%d instructions executed\n", covered, total)
+	return sb.String()
+}
+
+// Counters returns a snapshot of the profiling counters: "cycles" and
+// "instructions" (currently equal, as every instruction takes one cycle),
+// "max_call_depth", the deepest CALL nesting reached, and, when PCHistogram
+// is enabled, "distinct_pcs", the number of addresses executed.
+func (c *CPU) Counters() map[string]uint64 {
+	counters := map[string]uint64{
+		"cycles":         c.Cycles,
+		"instructions":   c.Cycles,
+		"max_call_depth": uint64(c.maxSubroutineDepth),
+	}
+	if c.PCHistogram != nil {
+		counters["distinct_pcs"] = uint64(len(c.PCHistogram))
+	}
+	return counters
+}
+
+// ResetCounters zeroes the profiling counters: Cycles, the maximum call
+// depth and PCHistogram, which stays enabled if it was. The current call
+// depth is kept so that returns from calls already in progress still
+// balance.
+func (c *CPU) ResetCounters() {
+	c.Cycles = 0
+	c.maxSubroutineDepth = c.subroutineDepth
+	if c.PCHistogram != nil {
+		c.PCHistogram = make(map[uint16]uint64)
+	}
+}
@@ -0,0 +1,96 @@
+package cpu
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCoverageReport_NeverTakenBranch(t *testing.T) {
+	c := NewCPU()
+	c.PCHistogram = make(map[uint16]uint64)
+	loadProgram(c,
+		EncodeInstruction(OpLDI, 0, 0, 0), 5, // 0000: LDI R0, 5
+		EncodeInstruction(OpLDI, 1, 0, 0), 5, // 0004: LDI R1, 5
+		EncodeInstruction(OpSUB, 0, 1, 0),         // 0008: SUB R0, R1 (Z set)
+		EncodeInstruction(OpJNZ, 0, 0, 0), 0x0010, // 000A: JNZ never
+		EncodeInstruction(OpHLT, 0, 0, 0), // 000E: HLT
+		// never:
+		EncodeInstruction(OpLDI, 2, 0, 0), 0xBEEF, // 0010: LDI R2, 0xBEEF
+		EncodeInstruction(OpHLT, 0, 0, 0), // 0014: HLT
+	)
+	c.Run()
+
+	report := c.CoverageReport(c.Memory[:0x16])
+	for _, want := range []string{
+		"* 0000  LDI R0, 0x0005\n",
+		"* 0008  SUB R0, R1\n",
+		"* 000A  JNZ 0x0010\n",
+		"* 000E  HLT\n",
+		"  0010  LDI R2, 0xBEEF\n",
+		"  0014  HLT\n",
+		"coverage: 5/7 instructions executed\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+	if got := c.PCHistogram[0x0010]; got != 0 {
+		t.Errorf("PCHistogram[0x0010] = %d, want 0", got)
+	}
+}
+
+func TestCoverageReport_UnknownOpcode(t *testing.T) {
+	c := NewCPU()
+	report := c.CoverageReport([]byte{0xFF, 0xFF})
+	if !strings.Contains(report, "  0000  .WORD 0xFFFF\n") {
+		t.Errorf("unknown opcode should be shown as data:\n%s", report)
+	}
+}
+
+func TestCounters_Reset(t *testing.T) {
+	c := NewCPU()
+	c.PCHistogram = make(map[uint16]uint64)
+	loadProgram(c,
+		EncodeInstruction(OpCALL, 0, 0, 0), 0x0006, // 0000: CALL outer
+		EncodeInstruction(OpHLT, 0, 0, 0), // 0004: HLT
+		// outer:
+		EncodeInstruction(OpCALL, 0, 0, 0), 0x000C, // 0006: CALL inner
+		EncodeInstruction(OpRET, 0, 0, 0), // 000A: RET
+		// inner:
+		EncodeInstruction(OpRET, 0, 0, 0), // 000C: RET
+	)
+	c.Run()
+
+	got := c.Counters()
+	want := map[string]uint64{"cycles": 5, "instructions": 5, "max_call_depth": 2, "distinct_pcs": 5}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Counters() = %v, want %v", got, want)
+	}
+
+	c.ResetCounters()
+	for name, v := range c.Counters() {
+		if v != 0 {
+			t.Errorf("after ResetCounters %s = %d, want 0", name, v)
+		}
+	}
+	if c.PCHistogram == nil {
+		t.Error("ResetCounters disabled PCHistogram")
+	}
+}
+
+func TestCounters_CPUReset(t *testing.T) {
+	// A reset taken inside a subroutine must not leave stale nesting behind.
+	c := NewCPU()
+	loadProgram(c,
+		EncodeInstruction(OpCALL, 0, 0, 0), 0x0006, // 0000: CALL sub
+		EncodeInstruction(OpHLT, 0, 0, 0), // 0004: HLT
+		EncodeInstruction(OpHLT, 0, 0, 0), // 0006: sub: HLT
+	)
+	c.Run()
+	c.Reset()
+	c.ResetCounters()
+	if got := c.Counters()["max_call_depth"]; got != 0 {
+		t.Errorf("max_call_depth after Reset = %d, want 0", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/bits"
 	"os"
 
 	"gocpu/pkg/vfs"
@@ -49,6 +50,75 @@ const (
 	OpIDIV uint16 = 0x22
 	OpJC   uint16 = 0x23
 	OpJNC  uint16 = 0x24
+
+	// Saturating arithmetic: clamp instead of wrapping on overflow.
+	OpADDS  uint16 = 0x25 // signed, clamps to [0x8000, 0x7FFF]
+	OpSUBS  uint16 = 0x26 // signed, clamps to [0x8000, 0x7FFF]
+	OpADDUS uint16 = 0x27 // unsigned, clamps to [0, 0xFFFF]
+	OpSUBUS uint16 = 0x28 // unsigned, clamps to [0, 0xFFFF]
+
+	OpJMPR uint16 = 0x29 // jump to the address held in a register
+
+	// Byte ALU: operate on the low 8 bits, zero the high byte, and set Z/N
+	// (N from bit 7) on the 8-bit result. ADDB/SUBB set C on carry/borrow
+	// out of bit 7.
+	OpADDB uint16 = 0x2A
+	OpSUBB uint16 = 0x2B
+	OpANDB uint16 = 0x2C
+
+	// Flags register transfer: LDF packs the flags into Rx, STF unpacks Rx
+	// into the flags (see the Flag* bit masks).
+	OpLDF uint16 = 0x2D
+	OpSTF uint16 = 0x2E
+
+	OpSWAP uint16 = 0x2F // exchange Rx and Ry; flags unchanged
+
+	// Minimum/maximum: Rx = min(Rx, Ry) or max(Rx, Ry); set Z/N on the
+	// result. MIN/MAX compare as signed, MINU/MAXU as unsigned.
+	OpMIN  uint16 = 0x30
+	OpMAX  uint16 = 0x31
+	OpMINU uint16 = 0x32
+	OpMAXU uint16 = 0x33
+
+	OpCMP uint16 = 0x34 // set Z/N/C from Rx - Ry like SUB; registers unchanged
+
+	// Rotates: Rx rotated by Ry mod 16 bits, without going through C; set
+	// Z/N on the result.
+	OpROL uint16 = 0x35
+	OpROR uint16 = 0x36
+
+	// Carry-chained arithmetic for multi-word values: Rx = Rx + Ry + C and
+	// Rx = Rx - Ry - C, where C is the carry (ADC) or borrow (SBC) left by the
+	// previous ADD/ADC or SUB/SBC. Set Z/N/C like ADD and SUB.
+	OpADC uint16 = 0x37
+	OpSBC uint16 = 0x38
+
+	// Branch on the signed overflow flag V.
+	OpJV  uint16 = 0x39
+	OpJNV uint16 = 0x3A
+
+	// Indexed load/store with a 16-bit displacement word after the
+	// instruction: LDX Rx, [Ry + imm] and STX [Rx + imm], Ry.
+	OpLDX uint16 = 0x3B
+	OpSTX uint16 = 0x3C
+
+	// MOVM dst, src copies the word at address src to address dst. Both
+	// addresses follow the instruction, dst first; flags are unchanged.
+	OpMOVM uint16 = 0x3D
+)
+
+// Flag bit positions used by LDF, STF, Flags and SetFlags.
+const (
+	FlagZ  uint16 = 1 << 0
+	FlagN  uint16 = 1 << 1
+	FlagC  uint16 = 1 << 2
+	FlagV  uint16 = 1 << 3
+	FlagIE uint16 = 1 << 4
+
+	// FlagSetIE makes SetFlags (and STF) load IE from FlagIE. Without it IE
+	// is left unchanged, so restoring saved flags cannot enable interrupts
+	// by accident.
+	FlagSetIE uint16 = 1 << 15
 )
 
 const (
@@ -58,6 +128,18 @@ const (
 	RegD uint16 = 3
 )
 
+// ErrUnalignedAccess is the fault raised when StrictAlign is set and a word
+// LD/ST targets an odd address.
+var ErrUnalignedAccess = errors.New("unaligned word access")
+
+// ErrBlockOutOfRange is the fault raised when StrictBounds is set and a FILL
+// or COPY would run past the end of memory.
+var ErrBlockOutOfRange = errors.New("block transfer past end of memory")
+
+// ErrStackOverflow is the fault raised when a push would take SP below
+// StackLimit.
+var ErrStackOverflow = errors.New("stack overflow")
+
 type CPU struct {
 	Regs [8]uint16
 
@@ -68,18 +150,32 @@ type CPU struct {
 	N  bool
 	C  bool
 	IE bool
+	// V is signed overflow from ADD, SUB, ADC, SBC and CMP.
+	V bool
 
 	Waiting bool
 
 	InterruptPending bool
+	// interruptCause holds the latched InterruptCauseReg bits (keyboard and
+	// software); see interruptCauses.
+	interruptCause uint16
+	// pendingLevels has bit n set while an interrupt of priority n awaits
+	// dispatch; interruptLevels stacks the priorities of the handlers
+	// running, innermost last. See dispatchInterrupt.
+	pendingLevels   uint16
+	interruptLevels []int
 
 	Memory [65536]byte
 
 	TextVRAM       [1024]uint16
 	TextVRAM_Front [1024]uint16
 
-	GraphicsBanks      [4][16384]byte
-	GraphicsBanksFront [4][16384]byte
+	// GraphicsBanks and GraphicsBanksFront hold one 16 KB bitmap per bank.
+	// Both have the same power-of-two length (Options.GraphicsBanks), and
+	// bank numbers written to 0xFF02/0xFF06 are masked to fit. A CPU built
+	// without NewCPU gets DefaultGraphicsBanks of each on first use.
+	GraphicsBanks      [][16384]byte
+	GraphicsBanksFront [][16384]byte
 	CurrentBank        uint16
 	DisplayBank        uint16
 
@@ -94,6 +190,9 @@ type CPU struct {
 	BufferedMode bool
 	// ColorMode8bpp selects 8bpp unpacked VRAM mode (bit 3 of 0xFF05).
 	ColorMode8bpp bool
+	// gfxWidth and gfxHeight are the bitmap resolution set through
+	// GfxWidthReg/GfxHeightReg; 0 means the 128x128 default.
+	gfxWidth, gfxHeight uint16
 
 	// Palette is the 256-entry Color Look-Up Table (CLUT) in RGB565 format.
 	Palette [256]uint16
@@ -101,12 +200,40 @@ type CPU struct {
 	PaletteIndex uint16
 
 	KeyBuffer []uint16
+	// CoalesceKeyInterrupts makes PushKey raise an interrupt only when the
+	// key buffer goes from empty to non-empty, so a burst of keys costs one
+	// interrupt and the handler should drain the buffer. Off by default:
+	// every key raises its own interrupt.
+	CoalesceKeyInterrupts bool
 
 	Halted bool
 
+	// StrictAlign makes a 16-bit LD/ST to an odd address fault instead of
+	// being split into two byte accesses. Off by default.
+	StrictAlign bool
+	// StrictBounds makes a FILL/COPY that would run past 0xFFFF fault
+	// instead of being clipped at the end of memory. Off by default.
+	StrictBounds bool
+	// StackLimit is the lowest address the stack may grow down to. A PUSH,
+	// CALL or interrupt dispatch that would move SP below it faults the CPU
+	// with ErrStackOverflow instead of writing, and sets StackOverflow.
+	// Zero, the default, disables the check.
+	StackLimit uint16
+	// StackOverflow is set when the StackLimit check faults the CPU.
+	StackOverflow bool
+	// Fault records why the CPU stopped when an instruction faults. The CPU
+	// is halted whenever Fault is set.
+	Fault error
+
+	// protected lists the write-protected address ranges. See WriteProtect.
+	protected []memRange
+
 	// Output is where MMIO writes (0xFF00, 0xFF01) are sent.
 	// If nil, os.Stdout is used.
 	Output io.Writer
+	// OutputTranslateCRLF expands each newline written to 0xFF00 into
+	// "\r\n", for hosts whose terminals expect CRLF. Off by default.
+	OutputTranslateCRLF bool
 
 	Disk        *vfs.VirtualDisk
 	StoragePath string
@@ -121,6 +248,7 @@ type CPU struct {
 	vfsLength   uint16
 	vfsStatus   uint16
 	vfsFreeHigh uint16
+	vfsOffset   uint32 // VFSOffsetLoReg/VFSOffsetHiReg
 
 	// MDU State
 	mathA         uint16
@@ -128,8 +256,53 @@ type CPU struct {
 	mathRes       uint16
 	mathRemainder uint16
 
+	// Watchdog State (0xFF25-0xFF26)
+	WatchdogTimeout uint16
+	watchdogCounter uint16
+	WatchdogResets  uint16
+
+	// Mailbox State (0xFF27-0xFF29)
+	mailboxAddr  uint16
+	mailboxSize  uint16
+	mailboxReady bool
+
+	// Timer State (0xFF2C-0xFF2E)
+	timerReload  uint16
+	timerCounter uint16
+	timerEnabled bool
+	timerExpired bool
+
 	CallDepth int
 
+	// MMIOLogger, when non-nil, is called for every read and write of an
+	// MMIO register or expansion bus address, with the value transferred.
+	MMIOLogger func(addr uint16, val uint16, write bool)
+
+	// watchpoints holds the callbacks registered with AddWatchpoint.
+	watchpoints map[uint16][]watchpoint
+
+	// PCHistogram, when non-nil, counts how many times Step executed the
+	// instruction at each address. See CoverageReport.
+	PCHistogram map[uint16]uint64
+
+	// StepHook, when non-nil, is called by Step with the address and first
+	// word of each instruction after it is fetched and before it executes.
+	// It fires once per executed instruction, so steps that only tick
+	// peripherals (halted, waiting in WFI, watchdog reset) do not call it.
+	// When an interrupt is dispatched, the first call is for the handler's
+	// first instruction.
+	StepHook func(pc uint16, instr uint16)
+	// Breakpoints holds the addresses RunUntilBreak stops at.
+	Breakpoints map[uint16]bool
+
+	// Cycles counts the instructions Step has executed. Its low and high
+	// 16 bits are readable at CycleCountLoReg and CycleCountHiReg.
+	Cycles uint64
+
+	// Subroutine nesting seen by CALL/RET, for Counters.
+	subroutineDepth    int
+	maxSubroutineDepth int
+
 	Peripherals       [16]Peripheral
 	PeripheralIntMask uint16
 }
@@ -140,20 +313,25 @@ type CPUState struct {
 	TextResolutionMode uint16
 	CurrentBank        uint16
 	DisplayBank        uint16
-	Z, N, C, IE        bool
+	Z, N, C, IE, V     bool
 	Waiting            bool
 	InterruptPending   bool
+	InterruptCause     uint16
+	PendingLevels      uint16
+	InterruptLevels    []int
 	GraphicsEnabled    bool
 	TextOverlay        bool
 	BufferedMode       bool
 	ColorMode8bpp      bool
+	GfxWidth           uint16
+	GfxHeight          uint16
 	Palette            [256]uint16
 	PaletteIndex       uint16
 	Memory             [65536]byte
 	TextVRAM           [1024]uint16
 	TextVRAM_Front     [1024]uint16
-	GraphicsBanks      [4][16384]byte
-	GraphicsBanksFront [4][16384]byte
+	GraphicsBanks      [][16384]byte
+	GraphicsBanksFront [][16384]byte
 
 	// MDU State
 	MathA             uint16
@@ -161,6 +339,24 @@ type CPUState struct {
 	MathRes           uint16
 	MathRemainder     uint16
 	PeripheralIntMask uint16
+
+	// Watchdog State
+	WatchdogTimeout uint16
+	WatchdogCounter uint16
+	WatchdogResets  uint16
+
+	// Mailbox State
+	MailboxAddr  uint16
+	MailboxSize  uint16
+	MailboxReady bool
+
+	// Timer State
+	TimerReload  uint16
+	TimerCounter uint16
+	TimerEnabled bool
+	TimerExpired bool
+
+	Cycles uint64
 }
 
 func (c *CPU) getState() CPUState {
@@ -174,25 +370,42 @@ func (c *CPU) getState() CPUState {
 		Z:                  c.Z,
 		N:                  c.N,
 		C:                  c.C,
+		V:                  c.V,
 		IE:                 c.IE,
 		Waiting:            c.Waiting,
 		InterruptPending:   c.InterruptPending,
+		InterruptCause:     c.interruptCause,
+		PendingLevels:      c.pendingLevels,
+		InterruptLevels:    append([]int(nil), c.interruptLevels...),
 		GraphicsEnabled:    c.GraphicsEnabled,
 		TextOverlay:        c.TextOverlay,
 		BufferedMode:       c.BufferedMode,
 		ColorMode8bpp:      c.ColorMode8bpp,
+		GfxWidth:           c.gfxWidth,
+		GfxHeight:          c.gfxHeight,
 		Palette:            c.Palette,
 		PaletteIndex:       c.PaletteIndex,
 		Memory:             c.Memory,
 		TextVRAM:           c.TextVRAM,
 		TextVRAM_Front:     c.TextVRAM_Front,
-		GraphicsBanks:      c.GraphicsBanks,
-		GraphicsBanksFront: c.GraphicsBanksFront,
+		GraphicsBanks:      append([][16384]byte(nil), c.GraphicsBanks...),
+		GraphicsBanksFront: append([][16384]byte(nil), c.GraphicsBanksFront...),
 		MathA:              c.mathA,
 		MathOp:             c.mathOp,
 		MathRes:            c.mathRes,
 		MathRemainder:      c.mathRemainder,
 		PeripheralIntMask:  c.PeripheralIntMask,
+		WatchdogTimeout:    c.WatchdogTimeout,
+		WatchdogCounter:    c.watchdogCounter,
+		WatchdogResets:     c.WatchdogResets,
+		MailboxAddr:        c.mailboxAddr,
+		MailboxSize:        c.mailboxSize,
+		MailboxReady:       c.mailboxReady,
+		TimerReload:        c.timerReload,
+		TimerCounter:       c.timerCounter,
+		TimerEnabled:       c.timerEnabled,
+		TimerExpired:       c.timerExpired,
+		Cycles:             c.Cycles,
 	}
 }
 
@@ -206,25 +419,42 @@ func (c *CPU) restoreState(state CPUState) {
 	c.Z = state.Z
 	c.N = state.N
 	c.C = state.C
+	c.V = state.V
 	c.IE = state.IE
 	c.Waiting = state.Waiting
 	c.InterruptPending = state.InterruptPending
+	c.interruptCause = state.InterruptCause
+	c.pendingLevels = state.PendingLevels
+	c.interruptLevels = append([]int(nil), state.InterruptLevels...)
 	c.GraphicsEnabled = state.GraphicsEnabled
 	c.TextOverlay = state.TextOverlay
 	c.BufferedMode = state.BufferedMode
 	c.ColorMode8bpp = state.ColorMode8bpp
+	c.gfxWidth = state.GfxWidth
+	c.gfxHeight = state.GfxHeight
 	c.Palette = state.Palette
 	c.PaletteIndex = state.PaletteIndex
 	c.Memory = state.Memory
 	c.TextVRAM = state.TextVRAM
 	c.TextVRAM_Front = state.TextVRAM_Front
-	c.GraphicsBanks = state.GraphicsBanks
-	c.GraphicsBanksFront = state.GraphicsBanksFront
+	c.GraphicsBanks = append([][16384]byte(nil), state.GraphicsBanks...)
+	c.GraphicsBanksFront = append([][16384]byte(nil), state.GraphicsBanksFront...)
 	c.mathA = state.MathA
 	c.mathOp = state.MathOp
 	c.mathRes = state.MathRes
 	c.mathRemainder = state.MathRemainder
 	c.PeripheralIntMask = state.PeripheralIntMask
+	c.WatchdogTimeout = state.WatchdogTimeout
+	c.watchdogCounter = state.WatchdogCounter
+	c.WatchdogResets = state.WatchdogResets
+	c.mailboxAddr = state.MailboxAddr
+	c.mailboxSize = state.MailboxSize
+	c.mailboxReady = state.MailboxReady
+	c.timerReload = state.TimerReload
+	c.timerCounter = state.TimerCounter
+	c.timerEnabled = state.TimerEnabled
+	c.timerExpired = state.TimerExpired
+	c.Cycles = state.Cycles
 }
 
 func (c *CPU) MountPeripheral(slot uint8, p Peripheral) {
@@ -236,7 +466,7 @@ func (c *CPU) MountPeripheral(slot uint8, p Peripheral) {
 func (c *CPU) TriggerPeripheralInterrupt(slot uint8) {
 	if slot < 16 {
 		c.PeripheralIntMask |= (1 << slot)
-		c.TriggerInterrupt()
+		c.requestInterrupt(IntCausePeripheral)
 	}
 }
 
@@ -267,22 +497,103 @@ var pico8Palette = [16]uint16{
 	0xFE75, // 15 Peach       {0xFF, 0xCC, 0xAA}
 }
 
+// Options configures a CPU created with NewCPUWithOptions.
+type Options struct {
+	// StoragePath, if non-empty, is the directory whose files are loaded
+	// into the VFS on startup.
+	StoragePath string
+	// MemFill is the byte every memory location holds before a program is
+	// loaded. A recognisable pattern such as 0xCD makes reads of
+	// uninitialised memory easy to spot. Zero leaves memory cleared.
+	MemFill byte
+	// GraphicsBanks is the number of 16 KB graphics banks. It must be a
+	// power of two no larger than MaxGraphicsBanks; zero selects
+	// DefaultGraphicsBanks.
+	GraphicsBanks int
+	// Output, if non-nil, becomes CPU.Output.
+	Output io.Writer
+
+	// The fields below are only used by RunProgram.
+
+	// MaxSteps stops the run after this many calls to Step, including
+	// steps spent waiting in WFI. Zero means no limit.
+	MaxSteps uint64
+	// Coverage records executed instructions and fills RunResult.Coverage.
+	Coverage bool
+}
+
+const (
+	// DefaultGraphicsBanks is the bank count used when Options.GraphicsBanks
+	// is zero.
+	DefaultGraphicsBanks = 4
+	// MaxGraphicsBanks is the largest supported bank count.
+	MaxGraphicsBanks = 256
+)
+
 // NewCPU creates a new CPU instance. An optional storagePath may be provided;
 // if non-empty, existing files from that directory are loaded into the VFS on startup.
 func NewCPU(storagePath ...string) *CPU {
+	var opts Options
+	if len(storagePath) > 0 {
+		opts.StoragePath = storagePath[0]
+	}
+	c, _ := NewCPUWithOptions(opts) // the default bank count is always valid
+	return c
+}
+
+// NewCPUWithOptions creates a new CPU instance configured by opts.
+// It returns an error if opts.GraphicsBanks is not a valid bank count.
+func NewCPUWithOptions(opts Options) (*CPU, error) {
+	banks := opts.GraphicsBanks
+	if banks == 0 {
+		banks = DefaultGraphicsBanks
+	}
+	if !validBankCount(banks) {
+		return nil, fmt.Errorf("cpu: GraphicsBanks must be a power of two between 1 and %d, got %d", MaxGraphicsBanks, banks)
+	}
 	c := &CPU{
-		SP:          0xB5FE,
-		TextOverlay: true,
-		Disk:        vfs.NewVirtualDisk(),
+		SP:                 0xB5FE,
+		TextOverlay:        true,
+		Disk:               vfs.NewVirtualDisk(),
+		GraphicsBanks:      make([][16384]byte, banks),
+		GraphicsBanksFront: make([][16384]byte, banks),
+		Output:             opts.Output,
 	}
 	for i, v := range pico8Palette {
 		c.Palette[i] = v
 	}
-	if len(storagePath) > 0 && storagePath[0] != "" {
-		c.StoragePath = storagePath[0]
-		_ = c.Disk.LoadFrom(storagePath[0]) // best-effort bootstrap; ignore errors on first run
+	if opts.MemFill != 0 {
+		for i := range c.Memory {
+			c.Memory[i] = opts.MemFill
+		}
+	}
+	if opts.StoragePath != "" {
+		c.StoragePath = opts.StoragePath
+		_ = c.Disk.LoadFrom(opts.StoragePath) // best-effort bootstrap; ignore errors on first run
+	}
+	return c, nil
+}
+
+func validBankCount(n int) bool {
+	return n > 0 && n <= MaxGraphicsBanks && n&(n-1) == 0
+}
+
+// ensureBanks allocates the default graphics banks for a zero-value CPU,
+// so &CPU{} keeps working now that the banks are slices.
+func (c *CPU) ensureBanks() {
+	if len(c.GraphicsBanks) == 0 {
+		c.GraphicsBanks = make([][16384]byte, DefaultGraphicsBanks)
+	}
+	if len(c.GraphicsBanksFront) == 0 {
+		c.GraphicsBanksFront = make([][16384]byte, len(c.GraphicsBanks))
 	}
-	return c
+}
+
+// bankMask masks a bank number written by the program to the configured
+// bank count.
+func (c *CPU) bankMask() uint16 {
+	c.ensureBanks()
+	return uint16(len(c.GraphicsBanks) - 1)
 }
 
 func (c *CPU) reg(idx uint16) *uint16 {
@@ -297,18 +608,114 @@ func (c *CPU) updateFlags(result uint16) {
 	c.N = (result & 0x8000) != 0
 }
 
-func (c *CPU) TriggerInterrupt() {
-	c.InterruptPending = true
+// Flags returns Z, N, C, V and IE packed into a word.
+func (c *CPU) Flags() uint16 {
+	var f uint16
+	if c.Z {
+		f |= FlagZ
+	}
+	if c.N {
+		f |= FlagN
+	}
+	if c.C {
+		f |= FlagC
+	}
+	if c.V {
+		f |= FlagV
+	}
+	if c.IE {
+		f |= FlagIE
+	}
+	return f
+}
+
+// SetFlags unpacks a word produced by Flags into Z, N, C and V. IE is only
+// changed when FlagSetIE is also set.
+func (c *CPU) SetFlags(f uint16) {
+	c.Z = f&FlagZ != 0
+	c.N = f&FlagN != 0
+	c.C = f&FlagC != 0
+	c.V = f&FlagV != 0
+	if f&FlagSetIE != 0 {
+		c.IE = f&FlagIE != 0
+	}
+}
+
+// addOverflow reports signed overflow for a+b=r: both operands have the same
+// sign and the result's sign differs.
+func addOverflow(a, b, r uint16) bool {
+	return (a^r)&(b^r)&0x8000 != 0
 }
 
+// subOverflow reports signed overflow for a-b=r: the operands' signs differ
+// and the result's sign differs from a.
+func subOverflow(a, b, r uint16) bool {
+	return (a^b)&(a^r)&0x8000 != 0
+}
+
+// setByteResult stores the low 8 bits of res in register idx, clearing the
+// high byte, and sets Z/N from the 8-bit value.
+func (c *CPU) setByteResult(idx uint16, res uint16) {
+	result := res & 0xFF
+	*c.reg(idx) = result
+	c.Z = result == 0
+	c.N = (result & 0x80) != 0
+}
+
+// PushKey appends a key code to the keyboard buffer and raises a keyboard
+// interrupt (see CoalesceKeyInterrupts).
 func (c *CPU) PushKey(val uint16) {
+	wasEmpty := len(c.KeyBuffer) == 0
 	c.KeyBuffer = append(c.KeyBuffer, val)
-	c.TriggerInterrupt()
+	if c.CoalesceKeyInterrupts && !wasEmpty {
+		return
+	}
+	c.interruptCause |= IntCauseKeyboard
+	c.requestInterrupt(IntCauseKeyboard)
+}
+
+// Cycle counter MMIO registers. Reading them returns the low and high 16
+// bits of Cycles; writes are ignored.
+const (
+	CycleCountLoReg uint16 = 0xFF2A
+	CycleCountHiReg uint16 = 0xFF2B
+)
+
+// VFS space MMIO registers. They report Disk.UsedSpace() and FreeSpace() as
+// 32-bit low/high word pairs, live, without issuing the FreeSpace command;
+// writes are ignored.
+const (
+	VFSUsedLoReg uint16 = 0xFF4B
+	VFSUsedHiReg uint16 = 0xFF4C
+	VFSFreeLoReg uint16 = 0xFF4D
+	VFSFreeHiReg uint16 = 0xFF4E
+)
+
+// VFS offset registers: the 32-bit byte offset, as a low/high word pair,
+// where the ReadAt command (10) starts reading.
+const (
+	VFSOffsetLoReg uint16 = 0xFF1A
+	VFSOffsetHiReg uint16 = 0xFF1B
+)
+
+// isMMIO reports whether addr is on the expansion bus (0xFE00-0xFEFF) or
+// in the MMIO register block (0xFF00-0xFF4F).
+func isMMIO(addr uint16) bool {
+	return addr >= 0xFE00 && addr <= 0xFF4F
 }
 
 // Read16 reads a little-endian uint16 from addr and addr+1.
-// MMIO registers (0xFF00-0xFF1F) are read from dedicated struct fields.
+// MMIO registers (0xFF00-0xFF4F) are read from dedicated struct fields.
 func (c *CPU) Read16(addr uint16) uint16 {
+	val := c.read16(addr)
+	if c.MMIOLogger != nil && isMMIO(addr) {
+		c.MMIOLogger(addr, val, false)
+	}
+	c.watch16(addr, val, false)
+	return val
+}
+
+func (c *CPU) read16(addr uint16) uint16 {
 	if addr >= 0xFE00 && addr <= 0xFEFF {
 		slot := uint8((addr - 0xFE00) / 16)
 		offset := (addr - 0xFE00) % 16
@@ -321,6 +728,8 @@ func (c *CPU) Read16(addr uint16) uint16 {
 	switch addr {
 	case 0xFF09:
 		return c.PeripheralIntMask
+	case InterruptCauseReg:
+		return c.interruptCauses()
 	case 0xFF04: // Keyboard buffer – return whole key code atomically
 		if len(c.KeyBuffer) > 0 {
 			val := c.KeyBuffer[0]
@@ -332,6 +741,12 @@ func (c *CPU) Read16(addr uint16) uint16 {
 		return c.PaletteIndex
 	case 0xFF08:
 		return c.Palette[c.PaletteIndex]
+	case GfxWidthReg:
+		w, _ := c.GraphicsSize()
+		return uint16(w)
+	case GfxHeightReg:
+		_, h := c.GraphicsSize()
+		return uint16(h)
 	case 0xFF11:
 		return c.vfsNamePtr
 	case 0xFF12:
@@ -342,21 +757,61 @@ func (c *CPU) Read16(addr uint16) uint16 {
 		return c.vfsStatus
 	case 0xFF15:
 		return c.vfsFreeHigh
+	case VFSUsedLoReg:
+		return uint16(c.Disk.UsedSpace())
+	case VFSUsedHiReg:
+		return uint16(c.Disk.UsedSpace() >> 16)
+	case VFSFreeLoReg:
+		return uint16(c.Disk.FreeSpace())
+	case VFSFreeHiReg:
+		return uint16(c.Disk.FreeSpace() >> 16)
+	case VFSOffsetLoReg:
+		return uint16(c.vfsOffset)
+	case VFSOffsetHiReg:
+		return uint16(c.vfsOffset >> 16)
 	case 0xFF22:
 		return c.mathRes
 	case 0xFF24:
 		return c.mathRemainder
+	case 0xFF25:
+		return c.watchdogCounter
+	case 0xFF26:
+		return c.WatchdogResets
+	case MailboxStatusReg:
+		return c.mailboxStatus()
+	case MailboxAddrReg:
+		return c.mailboxAddr
+	case MailboxSizeReg:
+		return c.mailboxSize
+	case TimerReloadReg:
+		return c.timerReload
+	case TimerControlReg:
+		return c.timerControl()
+	case TimerCountReg:
+		return c.timerCounter
+	case CycleCountLoReg:
+		return uint16(c.Cycles)
+	case CycleCountHiReg:
+		return uint16(c.Cycles >> 16)
 	}
-	lo := uint16(c.ReadByte(addr))
-	hi := uint16(c.ReadByte(addr + 1))
+	lo := uint16(c.readByte(addr))
+	hi := uint16(c.readByte(addr + 1))
 	return lo | (hi << 8)
 }
 
 // Write16 writes a little-endian uint16 to addr and addr+1.
-// MMIO registers occupy 0xFF00-0xFF1F; addresses above that (0xFF20+) are normal RAM
-// and are used for the stack (which starts at 0xFFFE and grows down).
+// MMIO registers occupy 0xFF00-0xFF4F; addresses above that (0xFF50+) are
+// normal RAM.
 func (c *CPU) Write16(addr uint16, val uint16) {
+	c.write16(addr, val)
+	c.watch16(addr, val, true)
+}
+
+func (c *CPU) write16(addr uint16, val uint16) {
 	if addr >= 0xFE00 && addr <= 0xFEFF {
+		if c.MMIOLogger != nil {
+			c.MMIOLogger(addr, val, true)
+		}
 		slot := uint8((addr - 0xFE00) / 16)
 		offset := (addr - 0xFE00) % 16
 		if c.Peripherals[slot] != nil {
@@ -365,16 +820,25 @@ func (c *CPU) Write16(addr uint16, val uint16) {
 		return
 	}
 
-	if addr >= 0xFF00 && addr <= 0xFF2F {
+	if addr >= 0xFF00 && addr <= 0xFF4F {
 		c.handleMMIOWrite16(addr, val)
 		return
 	}
-	c.WriteByte(addr, byte(val&0xFF))
-	c.WriteByte(addr+1, byte(val>>8))
+	c.writeByte(addr, byte(val&0xFF))
+	c.writeByte(addr+1, byte(val>>8))
 }
 
 // ReadByte reads a single byte from addr, with MMIO and VRAM interception.
 func (c *CPU) ReadByte(addr uint16) byte {
+	val := c.readByte(addr)
+	if c.MMIOLogger != nil && isMMIO(addr) && !(addr >= 0xFE00 && addr <= 0xFEFF) {
+		c.MMIOLogger(addr, uint16(val), false)
+	}
+	c.watchByte(addr, val, false)
+	return val
+}
+
+func (c *CPU) readByte(addr uint16) byte {
 	// Expansion Bus: 0xFE00-0xFEFF
 	if addr >= 0xFE00 && addr <= 0xFEFF {
 		val := c.Read16(addr & 0xFFFE)
@@ -395,6 +859,7 @@ func (c *CPU) ReadByte(addr uint16) byte {
 	}
 	// Graphics banks: 0xB600-0xF5FF
 	if addr >= 0xB600 && addr <= 0xF5FF {
+		c.ensureBanks()
 		return c.GraphicsBanks[c.CurrentBank][addr-0xB600]
 	}
 	// MMIO reads
@@ -430,6 +895,11 @@ func (c *CPU) ReadByte(addr uint16) byte {
 
 // WriteByte writes a single byte to addr, with MMIO and VRAM interception.
 func (c *CPU) WriteByte(addr uint16, val byte) {
+	c.writeByte(addr, val)
+	c.watchByte(addr, val, true)
+}
+
+func (c *CPU) writeByte(addr uint16, val byte) {
 	// Expansion Bus: 0xFE00-0xFEFF
 	if addr >= 0xFE00 && addr <= 0xFEFF {
 		wordAddr := addr & 0xFFFE
@@ -457,15 +927,20 @@ func (c *CPU) WriteByte(addr uint16, val byte) {
 	}
 	// Graphics banks: 0xB600-0xF5FF
 	if addr >= 0xB600 && addr <= 0xF5FF {
+		c.ensureBanks()
 		c.GraphicsBanks[c.CurrentBank][addr-0xB600] = val
 		return
 	}
 	// MMIO byte writes (for completeness; 16-bit MMIO handled via handleMMIOWrite16)
-	// Only 0xFF00-0xFF1F are MMIO; above that is normal RAM (used by the stack).
-	if addr >= 0xFF00 && addr <= 0xFF2F {
+	// Only 0xFF00-0xFF4F are MMIO; above that is normal RAM.
+	if addr >= 0xFF00 && addr <= 0xFF4F {
 		c.handleMMIOWrite16(addr, uint16(val))
 		return
 	}
+	if c.isWriteProtected(addr) {
+		c.writeProtectFault(addr)
+		return
+	}
 	c.Memory[addr] = val
 }
 
@@ -476,14 +951,21 @@ func (c *CPU) WriteByte(addr uint16, val byte) {
 // }
 
 func (c *CPU) handleMMIOWrite16(addr uint16, val uint16) {
+	if c.MMIOLogger != nil {
+		c.MMIOLogger(addr, val, true)
+	}
 	switch addr {
 	case 0xFF00:
-		fmt.Fprintf(c.outputSink(), "%c", val)
+		if c.OutputTranslateCRLF && val == '\n' {
+			fmt.Fprint(c.outputSink(), "\r\n")
+		} else {
+			fmt.Fprintf(c.outputSink(), "%c", val)
+		}
 	case 0xFF01:
 		// TODO: not sure we need a special case for 0xFF01 vs 0xFF00
 		fmt.Fprintf(c.outputSink(), "%d", val)
 	case 0xFF02:
-		c.CurrentBank = val & 0x03
+		c.CurrentBank = val & c.bankMask()
 	case 0xFF03:
 		c.TextResolutionMode = val & 0x01
 	case 0xFF05:
@@ -492,7 +974,7 @@ func (c *CPU) handleMMIOWrite16(addr uint16, val uint16) {
 		c.BufferedMode = (val & 0x04) != 0
 		c.ColorMode8bpp = (val & 0x08) != 0
 	case 0xFF06:
-		bankToFlip := val & 0x03
+		bankToFlip := val & c.bankMask()
 		c.DisplayBank = bankToFlip
 		copy(c.GraphicsBanksFront[bankToFlip][:], c.GraphicsBanks[bankToFlip][:])
 		copy(c.TextVRAM_Front[:], c.TextVRAM[:])
@@ -500,8 +982,16 @@ func (c *CPU) handleMMIOWrite16(addr uint16, val uint16) {
 		c.PaletteIndex = val & 0xFF
 	case 0xFF08:
 		c.Palette[c.PaletteIndex] = val
+	case GfxWidthReg:
+		_, h := c.GraphicsSize()
+		c.SetGraphicsSize(int(val), h)
+	case GfxHeightReg:
+		w, _ := c.GraphicsSize()
+		c.SetGraphicsSize(w, int(val))
 	case 0xFF09:
 		c.PeripheralIntMask &= ^val
+	case InterruptCauseReg:
+		c.acknowledgeInterrupts(val)
 	case 0xFF10:
 		c.handleVFSCommand(val)
 	case 0xFF11:
@@ -514,10 +1004,32 @@ func (c *CPU) handleMMIOWrite16(addr uint16, val uint16) {
 		c.vfsStatus = val
 	case 0xFF15:
 		c.vfsFreeHigh = val
+	case VFSOffsetLoReg:
+		c.vfsOffset = c.vfsOffset&0xFFFF0000 | uint32(val)
+	case VFSOffsetHiReg:
+		c.vfsOffset = c.vfsOffset&0xFFFF | uint32(val)<<16
 	case 0xFF20:
 		c.mathA = val
 	case 0xFF23:
 		c.mathOp = val
+	case 0xFF25:
+		c.WatchdogTimeout = val
+		c.watchdogCounter = val
+	case 0xFF26:
+		if val == WatchdogPetValue {
+			c.watchdogCounter = c.WatchdogTimeout
+		}
+	case MailboxStatusReg:
+		c.mailboxReady = false
+	case MailboxAddrReg:
+		c.mailboxAddr = val
+	case MailboxSizeReg:
+		c.mailboxSize = val
+	case TimerReloadReg:
+		c.timerReload = val
+		c.timerCounter = val
+	case TimerControlReg:
+		c.setTimerControl(val)
 	case 0xFF21:
 		// Trigger Calculation
 		if c.mathOp == 0 { // Multiplication Q8.8
@@ -567,6 +1079,9 @@ func (c *CPU) ReadStringFromRAM(ptr uint16) (string, error) {
 }
 
 func (c *CPU) writeStringToRAM(ptr uint16, s string) error {
+	if c.rangeWriteProtected(ptr, len(s)+1) {
+		return ErrWriteProtected
+	}
 	for i := 0; i < len(s); i++ {
 		if int(ptr)+i >= len(c.Memory) {
 			return errors.New("memory access out of bounds")
@@ -584,10 +1099,22 @@ func (c *CPU) copyToRAM(dst uint16, src []byte) error {
 	if int(dst)+len(src) > len(c.Memory) {
 		return errors.New("memory access out of bounds")
 	}
+	if c.rangeWriteProtected(dst, len(src)) {
+		return ErrWriteProtected
+	}
 	copy(c.Memory[dst:], src)
 	return nil
 }
 
+// ramWriteStatus maps an error from copyToRAM or writeStringToRAM to a VFS
+// status: 7 (Protected) for a write-protected buffer, else 4 (Out of Bounds).
+func ramWriteStatus(err error) uint16 {
+	if errors.Is(err, ErrWriteProtected) {
+		return 7
+	}
+	return 4
+}
+
 func (c *CPU) copyFromRAM(src uint16, length uint16) ([]byte, error) {
 	if int(src)+int(length) > len(c.Memory) {
 		return nil, errors.New("memory access out of bounds")
@@ -620,7 +1147,7 @@ func (c *CPU) handleVFSCommand(val uint16) {
 		}
 		err = c.copyToRAM(bufferPtr, data)
 		if err != nil {
-			c.vfsStatus = 4 // Out of Bounds
+			c.vfsStatus = ramWriteStatus(err)
 			return
 		}
 		c.vfsStatus = 0 // Success
@@ -697,7 +1224,7 @@ func (c *CPU) handleVFSCommand(val uint16) {
 		filename := c.VfsDirKeys[c.VfsDirIndex]
 		err := c.writeStringToRAM(bufferPtr, filename)
 		if err != nil {
-			c.vfsStatus = 4 // Out of bounds
+			c.vfsStatus = ramWriteStatus(err)
 			return
 		}
 
@@ -738,7 +1265,7 @@ func (c *CPU) handleVFSCommand(val uint16) {
 		}
 		err = c.copyToRAM(bufferPtr, metaBytes)
 		if err != nil {
-			c.vfsStatus = 4 // Out of Bounds
+			c.vfsStatus = ramWriteStatus(err)
 			return
 		}
 		c.vfsStatus = 0 // Success
@@ -756,6 +1283,10 @@ func (c *CPU) handleVFSCommand(val uint16) {
 			c.vfsStatus = 1 // Not Found
 			return
 		}
+		if len(binData) > len(c.Memory) {
+			c.vfsStatus = 4 // Out of Bounds
+			return
+		}
 
 		// 2. Generate swap filename
 		swapName := fmt.Sprintf(".swap_%d.sys", c.CallDepth)
@@ -769,7 +1300,8 @@ func (c *CPU) handleVFSCommand(val uint16) {
 			return
 		}
 
-		// 4. Write swap file
+		// 4. Write swap file. Every check that can fail happens before this
+		// point, so a failed ExecWait leaves the running program untouched.
 		if err := c.Disk.Write(swapName, buf.Bytes()); err != nil {
 			if errors.Is(err, vfs.ErrQuotaExceeded) {
 				c.vfsStatus = 2
@@ -782,14 +1314,8 @@ func (c *CPU) handleVFSCommand(val uint16) {
 		// 5. Context Switch
 		c.CallDepth++
 
-		// Clear Memory
+		// Clear Memory and load binary
 		c.Memory = [65536]byte{}
-
-		// Load binary
-		if len(binData) > len(c.Memory) {
-			c.vfsStatus = 4 // Out of Bounds
-			return
-		}
 		copy(c.Memory[:], binData)
 
 		// Reset Registers
@@ -798,6 +1324,7 @@ func (c *CPU) handleVFSCommand(val uint16) {
 		c.Z = false
 		c.N = false
 		c.C = false
+		c.V = false
 		c.IE = false
 		c.Waiting = false
 		c.InterruptPending = false
@@ -809,10 +1336,99 @@ func (c *CPU) handleVFSCommand(val uint16) {
 		c.BufferedMode = false
 
 		c.vfsStatus = 0 // Success
+
+	case 9: // Rename
+		oldName, err := c.ReadStringFromRAM(filenamePtr)
+		if err != nil {
+			c.vfsStatus = 3 // Invalid Name
+			return
+		}
+		newName, err := c.ReadStringFromRAM(bufferPtr)
+		if err != nil {
+			c.vfsStatus = 3 // Invalid Name
+			return
+		}
+		err = c.Disk.Rename(oldName, newName)
+		switch {
+		case err == nil:
+			c.vfsStatus = 0 // Success
+		case errors.Is(err, vfs.ErrFileNotFound):
+			c.vfsStatus = 1
+		case errors.Is(err, vfs.ErrFileExists):
+			c.vfsStatus = 6 // Exists
+		default:
+			c.vfsStatus = 3
+		}
+
+	case 10: // ReadAt
+		filename, err := c.ReadStringFromRAM(filenamePtr)
+		if err != nil {
+			c.vfsStatus = 3 // Invalid Name
+			return
+		}
+		if int(bufferPtr)+int(c.vfsLength) > len(c.Memory) {
+			c.vfsStatus = 4 // Out of Bounds
+			return
+		}
+		data, err := c.Disk.ReadAt(filename, int(c.vfsOffset), int(c.vfsLength))
+		if err != nil {
+			if errors.Is(err, vfs.ErrFileNotFound) {
+				c.vfsStatus = 1
+			} else {
+				c.vfsStatus = 3
+			}
+			return
+		}
+		if err := c.copyToRAM(bufferPtr, data); err != nil {
+			c.vfsStatus = ramWriteStatus(err)
+			return
+		}
+		c.vfsLength = uint16(len(data))
+		c.vfsStatus = 0 // Success
+
+	case 11: // Append
+		filename, err := c.ReadStringFromRAM(filenamePtr)
+		if err != nil {
+			c.vfsStatus = 3 // Invalid Name
+			return
+		}
+		data, err := c.copyFromRAM(bufferPtr, c.vfsLength)
+		if err != nil {
+			c.vfsStatus = 4 // Out of Bounds
+			return
+		}
+		err = c.Disk.Append(filename, data)
+		if err != nil {
+			if errors.Is(err, vfs.ErrQuotaExceeded) {
+				c.vfsStatus = 2
+			} else {
+				c.vfsStatus = 3
+			}
+			return
+		}
+		c.vfsStatus = 0 // Success
 	}
 }
 
+// Step advances the machine by one instruction. Each call runs in a fixed
+// order: the mounted peripherals step in slot order 0-15, then the watchdog
+// and timer tick, then the highest-priority pending interrupt is dispatched,
+// then one instruction is fetched, passed to StepHook and executed.
+// Interrupts raised anywhere before the dispatch point are therefore taken
+// on the same Step.
 func (c *CPU) Step() {
+	c.step(nil)
+}
+
+// StepWithPeripheralHook is Step with hook called right after the
+// peripherals step, before the timer ticks and interrupts are dispatched.
+// Tests use it to raise events (PushKey, PostMessage, ...) at a precise
+// point in the sequence.
+func (c *CPU) StepWithPeripheralHook(hook func()) {
+	c.step(hook)
+}
+
+func (c *CPU) step(hook func()) {
 	if c.Halted {
 		return
 	}
@@ -822,21 +1438,33 @@ func (c *CPU) Step() {
 			p.Step()
 		}
 	}
+	if hook != nil {
+		hook()
+	}
 
-	if c.InterruptPending && c.IE {
-		c.InterruptPending = false
-		c.IE = false
-		c.Waiting = false
-		c.SP -= 2
-		c.Write16(c.SP, c.PC)
-		c.PC = 0x0010
+	if c.tickWatchdog() {
+		return
 	}
+	c.tickTimer()
 
-	if c.Waiting {
+	c.dispatchInterrupt()
+
+	if c.Waiting || c.Halted {
 		return
 	}
 
-	instr := c.Read16(c.PC)
+	if c.PCHistogram != nil {
+		c.PCHistogram[c.PC]++
+	}
+	c.Cycles++
+
+	// Instruction and immediate fetches use read16, so watchpoints and the
+	// MMIO logger only see the program's data accesses.
+	pc := c.PC
+	instr := c.read16(c.PC)
+	if c.StepHook != nil {
+		c.StepHook(pc, instr)
+	}
 	c.PC += 2
 
 	opcode := (instr >> 10) & 0x3F
@@ -880,14 +1508,14 @@ func (c *CPU) Step() {
 		// No operation.
 
 	case OpJNC:
-		target := c.Read16(c.PC)
+		target := c.read16(c.PC)
 		c.PC += 2
 		if !c.C { // Jump if Carry flag is false (No Carry)
 			c.PC = target
 		}
 
 	case OpLDI:
-		imm := c.Read16(c.PC)
+		imm := c.read16(c.PC)
 		c.PC += 2
 		*c.reg(regA) = imm
 
@@ -896,6 +1524,9 @@ func (c *CPU) Step() {
 
 	case OpLD:
 		addr := *c.reg(regB)
+		if !c.checkAlign(addr) {
+			return
+		}
 		*c.reg(regA) = c.Read16(addr)
 
 	case OpADD:
@@ -904,6 +1535,7 @@ func (c *CPU) Step() {
 		res32 := valA + valB
 		result := uint16(res32)
 		c.C = res32 > 0xFFFF
+		c.V = addOverflow(uint16(valA), uint16(valB), result)
 		*c.reg(regA) = result
 		c.updateFlags(result)
 
@@ -912,9 +1544,42 @@ func (c *CPU) Step() {
 		valB := *c.reg(regB)
 		result := valA - valB
 		c.C = valA < valB
+		c.V = subOverflow(valA, valB, result)
 		*c.reg(regA) = result
 		c.updateFlags(result)
 
+	case OpADC:
+		valA := *c.reg(regA)
+		valB := *c.reg(regB)
+		res32 := uint32(valA) + uint32(valB)
+		if c.C {
+			res32++
+		}
+		result := uint16(res32)
+		c.C = res32 > 0xFFFF
+		c.V = addOverflow(valA, valB, result)
+		*c.reg(regA) = result
+		c.updateFlags(result)
+
+	case OpSBC:
+		valA := uint32(*c.reg(regA))
+		valB := uint32(*c.reg(regB))
+		if c.C {
+			valB++
+		}
+		result := uint16(valA - valB)
+		c.V = subOverflow(*c.reg(regA), *c.reg(regB), result)
+		c.C = valA < valB
+		*c.reg(regA) = result
+		c.updateFlags(result)
+
+	case OpCMP:
+		valA := *c.reg(regA)
+		valB := *c.reg(regB)
+		c.C = valA < valB
+		c.V = subOverflow(valA, valB, valA-valB)
+		c.updateFlags(valA - valB)
+
 	case OpAND:
 		result := *c.reg(regA) & *c.reg(regB)
 		*c.reg(regA) = result
@@ -945,40 +1610,70 @@ func (c *CPU) Step() {
 		*c.reg(regA) = result
 		c.updateFlags(result)
 
+	case OpROL:
+		result := bits.RotateLeft16(*c.reg(regA), int(*c.reg(regB)&0x0F))
+		*c.reg(regA) = result
+		c.updateFlags(result)
+
+	case OpROR:
+		result := bits.RotateLeft16(*c.reg(regA), -int(*c.reg(regB)&0x0F))
+		*c.reg(regA) = result
+		c.updateFlags(result)
+
 	case OpJMP:
-		target := c.Read16(c.PC)
+		target := c.read16(c.PC)
 		c.PC += 2
 		c.PC = target
 
 	case OpJZ:
-		target := c.Read16(c.PC)
+		target := c.read16(c.PC)
 		c.PC += 2
 		if c.Z {
 			c.PC = target
 		}
 
 	case OpJNZ:
-		target := c.Read16(c.PC)
+		target := c.read16(c.PC)
 		c.PC += 2
 		if !c.Z {
 			c.PC = target
 		}
 
+	case OpJMPR:
+		c.PC = *c.reg(regA)
+
 	case OpJN:
-		target := c.Read16(c.PC)
+		target := c.read16(c.PC)
 		c.PC += 2
 		if c.N {
 			c.PC = target
 		}
 
 	case OpJC:
-		target := c.Read16(c.PC)
+		target := c.read16(c.PC)
 		c.PC += 2
 		if c.C {
 			c.PC = target
 		}
 
+	case OpJV:
+		target := c.read16(c.PC)
+		c.PC += 2
+		if c.V {
+			c.PC = target
+		}
+
+	case OpJNV:
+		target := c.read16(c.PC)
+		c.PC += 2
+		if !c.V {
+			c.PC = target
+		}
+
 	case OpPUSH:
+		if c.stackOverflow(pc) {
+			return
+		}
 		c.SP -= 2
 		c.Write16(c.SP, *c.reg(regA))
 
@@ -987,15 +1682,25 @@ func (c *CPU) Step() {
 		c.SP += 2
 
 	case OpCALL:
-		target := c.Read16(c.PC)
+		if c.stackOverflow(pc) {
+			return
+		}
+		target := c.read16(c.PC)
 		c.PC += 2
 		c.SP -= 2
 		c.Write16(c.SP, c.PC)
 		c.PC = target
+		c.subroutineDepth++
+		if c.subroutineDepth > c.maxSubroutineDepth {
+			c.maxSubroutineDepth = c.subroutineDepth
+		}
 
 	case OpRET:
 		c.PC = c.Read16(c.SP)
 		c.SP += 2
+		if c.subroutineDepth > 0 {
+			c.subroutineDepth--
+		}
 
 	case OpEI:
 		c.IE = true
@@ -1007,6 +1712,9 @@ func (c *CPU) Step() {
 		c.PC = c.Read16(c.SP)
 		c.SP += 2
 		c.IE = true
+		if n := len(c.interruptLevels); n > 0 {
+			c.interruptLevels = c.interruptLevels[:n-1]
+		}
 
 	case OpWFI:
 		c.Waiting = true
@@ -1044,16 +1752,126 @@ func (c *CPU) Step() {
 			c.updateFlags(uint16(result))
 		}
 
+	case OpADDS, OpSUBS:
+		valA := int32(int16(*c.reg(regA)))
+		valB := int32(int16(*c.reg(regB)))
+		var res32 int32
+		if opcode == OpADDS {
+			res32 = valA + valB
+		} else {
+			res32 = valA - valB
+		}
+		if res32 > 0x7FFF {
+			res32 = 0x7FFF
+		} else if res32 < -0x8000 {
+			res32 = -0x8000
+		}
+		result := uint16(res32)
+		*c.reg(regA) = result
+		c.updateFlags(result)
+
+	case OpADDUS:
+		res32 := uint32(*c.reg(regA)) + uint32(*c.reg(regB))
+		if res32 > 0xFFFF {
+			res32 = 0xFFFF
+		}
+		result := uint16(res32)
+		*c.reg(regA) = result
+		c.updateFlags(result)
+
+	case OpSUBUS:
+		valA := *c.reg(regA)
+		valB := *c.reg(regB)
+		result := uint16(0)
+		if valA > valB {
+			result = valA - valB
+		}
+		*c.reg(regA) = result
+		c.updateFlags(result)
+
+	case OpADDB:
+		res := (*c.reg(regA) & 0xFF) + (*c.reg(regB) & 0xFF)
+		c.C = res > 0xFF
+		c.setByteResult(regA, res)
+
+	case OpSUBB:
+		valA := *c.reg(regA) & 0xFF
+		valB := *c.reg(regB) & 0xFF
+		c.C = valA < valB
+		c.setByteResult(regA, valA-valB)
+
+	case OpANDB:
+		c.setByteResult(regA, *c.reg(regA)&*c.reg(regB))
+
+	case OpLDF:
+		*c.reg(regA) = c.Flags()
+
+	case OpSTF:
+		c.SetFlags(*c.reg(regA))
+
+	case OpSWAP:
+		*c.reg(regA), *c.reg(regB) = *c.reg(regB), *c.reg(regA)
+
+	case OpMIN, OpMAX, OpMINU, OpMAXU:
+		valA := *c.reg(regA)
+		valB := *c.reg(regB)
+		var aLess, bLess bool
+		if opcode == OpMIN || opcode == OpMAX {
+			aLess, bLess = int16(valA) < int16(valB), int16(valB) < int16(valA)
+		} else {
+			aLess, bLess = valA < valB, valB < valA
+		}
+		result := valA
+		if (opcode == OpMIN || opcode == OpMINU) && bLess {
+			result = valB
+		} else if (opcode == OpMAX || opcode == OpMAXU) && aLess {
+			result = valB
+		}
+		*c.reg(regA) = result
+		c.updateFlags(result)
+
 	case OpST:
 		addr := *c.reg(regA)
+		if !c.checkAlign(addr) {
+			return
+		}
 		val := *c.reg(regB)
 		c.Write16(addr, val)
 
+	case OpLDX:
+		addr := *c.reg(regB) + c.read16(c.PC)
+		if !c.checkAlign(addr) {
+			return
+		}
+		c.PC += 2
+		*c.reg(regA) = c.Read16(addr)
+
+	case OpSTX:
+		addr := *c.reg(regA) + c.read16(c.PC)
+		if !c.checkAlign(addr) {
+			return
+		}
+		c.PC += 2
+		c.Write16(addr, *c.reg(regB))
+
+	case OpMOVM:
+		dst := c.read16(c.PC)
+		src := c.read16(c.PC + 2)
+		if !c.checkAlign(src) || !c.checkAlign(dst) {
+			return
+		}
+		c.PC += 4
+		c.Write16(dst, c.Read16(src))
+
 	case OpFILL:
 		regC := (instr >> 1) & 0x07
 		startAddr := *c.reg(regA)
 		count := *c.reg(regB)
 		val := *c.reg(regC)
+		count, ok := c.clipBlock(count, startAddr)
+		if !ok {
+			return
+		}
 		for i := uint16(0); i < count; i++ {
 			c.Write16(startAddr+i*2, val)
 		}
@@ -1062,9 +1880,12 @@ func (c *CPU) Step() {
 		regC := (instr >> 1) & 0x07
 		srcAddr := *c.reg(regA)
 		dstAddr := *c.reg(regB)
-		count := *c.reg(regC)
+		count, ok := c.clipBlock(*c.reg(regC), srcAddr, dstAddr)
+		if !ok {
+			return
+		}
 
-		if srcAddr < dstAddr && srcAddr+count*2 > dstAddr {
+		if srcAddr < dstAddr && uint32(srcAddr)+uint32(count)*2 > uint32(dstAddr) {
 			// Overlap, copy backwards
 			for i := count; i > 0; i-- {
 				val := c.Read16(srcAddr + (i-1)*2)
@@ -1086,6 +1907,74 @@ func (c *CPU) Step() {
 		addr := *c.reg(regA)
 		c.WriteByte(addr, byte(*c.reg(regB)&0xFF))
 	}
+
+	if errors.Is(c.Fault, ErrWriteProtected) {
+		c.PC = pc
+		c.Fault = fmt.Errorf("%w at PC 0x%04X", c.Fault, pc)
+	}
+}
+
+// checkAlign reports whether a word access to addr may proceed. With
+// StrictAlign set, an odd address faults the CPU; PC is rewound so it points
+// at the offending instruction.
+func (c *CPU) checkAlign(addr uint16) bool {
+	if !c.StrictAlign || addr%2 == 0 {
+		return true
+	}
+	c.PC -= 2
+	c.Fault = fmt.Errorf("%w: address 0x%04X at PC 0x%04X", ErrUnalignedAccess, addr, c.PC)
+	c.Halted = true
+	return false
+}
+
+// stackOverflow reports whether pushing a word would take SP below
+// StackLimit. If so it faults the CPU with PC set to pc, the instruction (or
+// interrupted instruction) responsible.
+func (c *CPU) stackOverflow(pc uint16) bool {
+	if c.StackLimit == 0 || int(c.SP)-2 >= int(c.StackLimit) {
+		return false
+	}
+	c.PC = pc
+	c.StackOverflow = true
+	c.Fault = fmt.Errorf("%w: SP 0x%04X below limit 0x%04X at PC 0x%04X", ErrStackOverflow, c.SP-2, c.StackLimit, pc)
+	c.Halted = true
+	return true
+}
+
+// clipBlock limits a FILL/COPY word count so that no access starting at any
+// of addrs runs past 0xFFFF and wraps into low memory. With StrictBounds set,
+// an overrunning block faults the CPU instead and ok is false.
+func (c *CPU) clipBlock(count uint16, addrs ...uint16) (clipped uint16, ok bool) {
+	clipped = count
+	for _, addr := range addrs {
+		if room := uint16((0x10000 - uint32(addr)) / 2); clipped > room {
+			clipped = room
+		}
+	}
+	if clipped == count || !c.StrictBounds {
+		return clipped, true
+	}
+	c.PC -= 2
+	c.Fault = fmt.Errorf("%w: %d words at PC 0x%04X", ErrBlockOutOfRange, count, c.PC)
+	c.Halted = true
+	return 0, false
+}
+
+// LoadFrom reads a program image from r and copies it into memory starting
+// at address 0. Memory is left untouched if reading fails or the image does
+// not fit.
+func (c *CPU) LoadFrom(r io.Reader) error {
+	// Read at most one byte more than fits, so an endless or oversized
+	// stream is rejected without buffering all of it.
+	data, err := io.ReadAll(io.LimitReader(r, int64(len(c.Memory))+1))
+	if err != nil {
+		return fmt.Errorf("reading program: %w", err)
+	}
+	if len(data) > len(c.Memory) {
+		return fmt.Errorf("program too large for memory: more than %d bytes", len(c.Memory))
+	}
+	copy(c.Memory[:], data)
+	return nil
 }
 
 func (c *CPU) Run() {
@@ -1098,6 +1987,42 @@ func EncodeInstruction(opcode, regA, regB, regC uint16) uint16 {
 	return (opcode << 10) | ((regA & 0x07) << 7) | ((regB & 0x07) << 4) | ((regC & 0x07) << 1)
 }
 
+// StepOver executes the next instruction like Step, except that a CALL runs
+// the whole subroutine: execution continues until it returns to the
+// instruction after the CALL, or until the CPU halts or waits for an
+// interrupt. It returns the number of instructions executed.
+func (c *CPU) StepOver() int {
+	start := c.Cycles
+	instr := c.read16(c.PC)
+	if c.Halted || c.Waiting || (instr>>10)&0x3F != OpCALL {
+		c.Step()
+		return int(c.Cycles - start)
+	}
+
+	ret, sp := c.PC+4, c.SP
+	c.Step()
+	for !c.Halted && !c.Waiting && !(c.PC == ret && c.SP >= sp) {
+		c.Step()
+	}
+	return int(c.Cycles - start)
+}
+
+// RunUntilBreak steps until the CPU halts or PC reaches an address in
+// Breakpoints, and reports whether it stopped at a breakpoint. It always
+// executes at least one step, so calling it again while stopped at a
+// breakpoint continues past it. While the CPU waits in WFI, PC is not
+// checked: a breakpoint on the instruction after WFI stops once the wait
+// ends.
+func (c *CPU) RunUntilBreak() bool {
+	for !c.Halted {
+		c.Step()
+		if c.Breakpoints[c.PC] && !c.Halted && !c.Waiting {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *CPU) RunUntilDone() {
 	for {
 		if c.Halted || c.Waiting {
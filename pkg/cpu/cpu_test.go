@@ -2,6 +2,9 @@ package cpu
 
 import (
 	"bytes"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -258,6 +261,121 @@ func TestSubroutine(t *testing.T) {
 	}
 }
 
+func TestStepOver(t *testing.T) {
+	cpu := NewCPU()
+	loadProgram(cpu,
+		EncodeInstruction(OpCALL, 0, 0, 0), 0x0020, // 0x0000: CALL 0x0020
+		EncodeInstruction(OpNOP, 0, 0, 0), // 0x0004: NOP
+		EncodeInstruction(OpHLT, 0, 0, 0), // 0x0006: HLT
+	)
+	w16(cpu, 0x0020, EncodeInstruction(OpLDI, 0, 0, 0)) // 0x0020: LDI R0, 7
+	w16(cpu, 0x0022, 7)
+	w16(cpu, 0x0024, EncodeInstruction(OpADD, 0, 0, 0)) // 0x0024: ADD R0, R0
+	w16(cpu, 0x0026, EncodeInstruction(OpRET, 0, 0, 0)) // 0x0026: RET
+	sp := cpu.SP
+
+	if n := cpu.StepOver(); n != 4 {
+		t.Errorf("StepOver CALL executed %d instructions, want 4", n)
+	}
+	if cpu.PC != 0x0004 || cpu.SP != sp {
+		t.Errorf("after StepOver: PC=0x%04X SP=0x%04X, want PC=0x0004 SP=0x%04X", cpu.PC, cpu.SP, sp)
+	}
+	if cpu.Regs[0] != 14 {
+		t.Errorf("subroutine did not run: R0=%d, want 14", cpu.Regs[0])
+	}
+
+	// Anything other than CALL is a single step.
+	if n := cpu.StepOver(); n != 1 || cpu.PC != 0x0006 {
+		t.Errorf("StepOver NOP: %d instructions, PC=0x%04X; want 1, 0x0006", n, cpu.PC)
+	}
+}
+
+func TestStepHook(t *testing.T) {
+	cpu := NewCPU()
+	loadProgram(cpu,
+		EncodeInstruction(OpNOP, 0, 0, 0),    // 0x0000: NOP
+		EncodeInstruction(OpLDI, 0, 0, 0), 5, // 0x0002: LDI R0, 5
+		EncodeInstruction(OpWFI, 0, 0, 0), // 0x0006: WFI
+		EncodeInstruction(OpHLT, 0, 0, 0), // 0x0008: HLT
+	)
+	w16(cpu, 0x0010, EncodeInstruction(OpRETI, 0, 0, 0)) // 0x0010: RETI
+	cpu.IE = true
+
+	var pcs []uint16
+	cpu.StepHook = func(pc, instr uint16) {
+		if instr != cpu.Read16(pc) {
+			t.Errorf("hook at 0x%04X: instr 0x%04X, want 0x%04X", pc, instr, cpu.Read16(pc))
+		}
+		if cpu.Regs[0] != 0 && pc == 0x0002 {
+			t.Errorf("hook for LDI ran after it executed")
+		}
+		pcs = append(pcs, pc)
+	}
+
+	for i := 0; i < 5; i++ {
+		cpu.Step() // NOP, LDI, WFI, then two steps spent waiting
+	}
+	cpu.PushKey('k')
+	cpu.Run()  // RETI in the handler, then HLT
+	cpu.Step() // halted: no hook
+
+	want := []uint16{0x0000, 0x0002, 0x0006, 0x0010, 0x0008}
+	if !reflect.DeepEqual(pcs, want) {
+		t.Errorf("hook PCs = %04X, want %04X", pcs, want)
+	}
+}
+
+func TestRunUntilBreak(t *testing.T) {
+	cpu := NewCPU()
+	loadProgram(cpu,
+		EncodeInstruction(OpLDI, 0, 0, 0), 1, // 0x0000: LDI R0, 1
+		EncodeInstruction(OpADD, 0, 0, 0), // 0x0004: ADD R0, R0
+		EncodeInstruction(OpADD, 0, 0, 0), // 0x0006: ADD R0, R0
+		EncodeInstruction(OpHLT, 0, 0, 0), // 0x0008: HLT
+	)
+	cpu.Breakpoints = map[uint16]bool{0x0006: true}
+
+	if !cpu.RunUntilBreak() {
+		t.Fatal("RunUntilBreak did not stop at the breakpoint")
+	}
+	if cpu.PC != 0x0006 || cpu.Regs[0] != 2 {
+		t.Errorf("stopped at PC=0x%04X R0=%d, want PC=0x0006 R0=2", cpu.PC, cpu.Regs[0])
+	}
+
+	// Resuming from a breakpoint runs past it to the halt.
+	if cpu.RunUntilBreak() {
+		t.Errorf("RunUntilBreak reported a breakpoint at PC=0x%04X", cpu.PC)
+	}
+	if !cpu.Halted || cpu.Regs[0] != 4 {
+		t.Errorf("after resume: Halted=%v R0=%d, want halted with R0=4", cpu.Halted, cpu.Regs[0])
+	}
+}
+
+func TestRunUntilBreak_Waiting(t *testing.T) {
+	cpu := NewCPU()
+	loadProgram(cpu,
+		EncodeInstruction(OpEI, 0, 0, 0),  // 0x0000: EI
+		EncodeInstruction(OpWFI, 0, 0, 0), // 0x0002: WFI
+		EncodeInstruction(OpHLT, 0, 0, 0), // 0x0004: HLT
+	)
+	// Handler at 0x0010: LDI R1, 7; RETI.
+	w16(cpu, 0x0010, EncodeInstruction(OpLDI, 1, 0, 0))
+	w16(cpu, 0x0012, 7)
+	w16(cpu, 0x0014, EncodeInstruction(OpRETI, 0, 0, 0))
+	cpu.Write16(TimerReloadReg, 5)
+	cpu.Write16(TimerControlReg, TimerEnable)
+	cpu.Breakpoints = map[uint16]bool{0x0004: true}
+
+	// The breakpoint after WFI must not fire while the CPU is still waiting.
+	if !cpu.RunUntilBreak() {
+		t.Fatal("RunUntilBreak did not stop at the breakpoint")
+	}
+	if cpu.Waiting || cpu.PC != 0x0004 || cpu.Regs[1] != 7 {
+		t.Errorf("stopped with Waiting=%v PC=0x%04X R1=%d, want the handler run and PC=0x0004",
+			cpu.Waiting, cpu.PC, cpu.Regs[1])
+	}
+}
+
 func TestInterrupts(t *testing.T) {
 	// Verify PushKey triggers an interrupt
 	pushKeyCPU := NewCPU()
@@ -373,6 +491,27 @@ func TestWriteMem_MMIO(t *testing.T) {
 	}
 }
 
+func TestOutputTranslateCRLF(t *testing.T) {
+	for _, tt := range []struct {
+		crlf bool
+		want string
+	}{
+		{false, "a\nb"},
+		{true, "a\r\nb"},
+	} {
+		cpu := NewCPU()
+		var buf bytes.Buffer
+		cpu.Output = &buf
+		cpu.OutputTranslateCRLF = tt.crlf
+		for _, ch := range "a\nb" {
+			cpu.WriteMem(0xFF00, uint16(ch))
+		}
+		if buf.String() != tt.want {
+			t.Errorf("OutputTranslateCRLF=%v: output %q, want %q", tt.crlf, buf.String(), tt.want)
+		}
+	}
+}
+
 func TestTextVRAM_Write(t *testing.T) {
 	cpu := NewCPU()
 	// Write 16-bit value 0x0041 to address 0xF600 + 5*2 = 0xF60A (word index 5)
@@ -401,13 +540,13 @@ func TestTextVRAM_Bounds(t *testing.T) {
 	// Below VRAM range (Base RAM)
 	cpu.WriteMem(0xB5FE, 0x1111)
 	// Above VRAM range (Memory above MMIO)
-	cpu.WriteMem(0xFF30, 0x2222)
+	cpu.WriteMem(0xFF50, 0x2222)
 
 	if cpu.Read16(0xB5FE) != 0x1111 {
 		t.Errorf("TextVRAM_Bounds: expected 0x1111 at 0xB5FE, got 0x%04X", cpu.Read16(0xB5FE))
 	}
-	if cpu.Read16(0xFF30) != 0x2222 {
-		t.Errorf("TextVRAM_Bounds: expected 0x2222 at 0xFF30, got 0x%04X", cpu.Read16(0xFF30))
+	if cpu.Read16(0xFF50) != 0x2222 {
+		t.Errorf("TextVRAM_Bounds: expected 0x2222 at 0xFF50, got 0x%04X", cpu.Read16(0xFF50))
 	}
 	// TextVRAM should be untouched
 	for i := 0; i < 1024; i++ {
@@ -425,7 +564,7 @@ func TestBankSwitching_Write(t *testing.T) {
 	cpu.WriteMem(0xFF02, 1)
 	cpu.WriteMem(0xB600, 0xBBBB)
 
-	// GraphicsBanks are now [4][16384]byte; low byte of 0xAAAA is 0xAA
+	// GraphicsBanks hold bytes; low byte of 0xAAAA is 0xAA
 	if cpu.GraphicsBanks[0][0] != 0xAA {
 		t.Errorf("BankSwitching_Write: expected GraphicsBanks[0][0]=0xAA, got 0x%02X", cpu.GraphicsBanks[0][0])
 	}
@@ -461,6 +600,64 @@ func TestBankRegister_Masking(t *testing.T) {
 	}
 }
 
+func TestGraphicsBanks_Configurable(t *testing.T) {
+	c, err := NewCPUWithOptions(Options{GraphicsBanks: 8})
+	if err != nil {
+		t.Fatalf("NewCPUWithOptions: %v", err)
+	}
+	if len(c.GraphicsBanks) != 8 || len(c.GraphicsBanksFront) != 8 {
+		t.Fatalf("expected 8 banks, got %d/%d", len(c.GraphicsBanks), len(c.GraphicsBanksFront))
+	}
+
+	c.WriteMem(0xFF02, 5)
+	if c.CurrentBank != 5 {
+		t.Fatalf("expected CurrentBank=5, got %d", c.CurrentBank)
+	}
+	c.WriteMem(0xB600, 0x5555)
+	if c.GraphicsBanks[5][0] != 0x55 || c.GraphicsBanks[1][0] != 0 {
+		t.Errorf("write to bank 5 landed in the wrong bank")
+	}
+	if got := c.ReadMem(0xB600); got != 0x5555 {
+		t.Errorf("expected 0x5555 read back from bank 5, got 0x%04X", got)
+	}
+
+	c.WriteMem(0xFF02, 0xFFFF)
+	if c.CurrentBank != 7 {
+		t.Errorf("expected CurrentBank masked to 7, got %d", c.CurrentBank)
+	}
+
+	c.WriteMem(0xFF06, 13) // 13 & 7 = 5
+	if c.DisplayBank != 5 || c.GraphicsBanksFront[5][0] != 0x55 {
+		t.Errorf("expected bank 5 flipped to the front buffer, DisplayBank=%d", c.DisplayBank)
+	}
+}
+
+func TestGraphicsBanks_InvalidCount(t *testing.T) {
+	for _, banks := range []int{-1, 6, 512} {
+		if c, err := NewCPUWithOptions(Options{GraphicsBanks: banks}); err == nil || c != nil {
+			t.Errorf("GraphicsBanks=%d: expected an error, got cpu=%v err=%v", banks, c != nil, err)
+		}
+	}
+	if _, err := RunProgram([]byte{0, 0}, Options{GraphicsBanks: 6}); err == nil {
+		t.Error("RunProgram: expected an error for GraphicsBanks=6")
+	}
+}
+
+func TestGraphicsBanks_ZeroValueCPU(t *testing.T) {
+	c := &CPU{}
+	c.WriteMem(0xFF02, 0xFFFF)
+	if c.CurrentBank != DefaultGraphicsBanks-1 {
+		t.Errorf("expected CurrentBank masked to %d, got %d", DefaultGraphicsBanks-1, c.CurrentBank)
+	}
+	c.WriteMem(0xB600, 0x1234)
+	if got := c.ReadMem(0xB600); got != 0x1234 {
+		t.Errorf("expected 0x1234 read back, got 0x%04X", got)
+	}
+	if len(c.GraphicsBanks) != DefaultGraphicsBanks || len(c.GraphicsBanksFront) != DefaultGraphicsBanks {
+		t.Errorf("expected %d banks, got %d/%d", DefaultGraphicsBanks, len(c.GraphicsBanks), len(c.GraphicsBanksFront))
+	}
+}
+
 func TestVRAMConfigRegister_Write(t *testing.T) {
 	cpu := NewCPU()
 
@@ -550,6 +747,102 @@ func TestOpDIV(t *testing.T) {
 	}
 }
 
+func TestOpJMPR(t *testing.T) {
+	c := NewCPU()
+	c.Regs[RegA] = 0x0010
+	loadProgram(c,
+		EncodeInstruction(OpJMPR, RegA, 0, 0), // 0x0000: JMPR R0
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+	c.Step()
+	if c.PC != 0x0010 {
+		t.Errorf("JMPR R0: expected PC=0x0010, got 0x%04X", c.PC)
+	}
+}
+
+func TestSaturatingOps(t *testing.T) {
+	tests := []struct {
+		name  string
+		op    uint16
+		a, b  uint16
+		want  uint16
+		wantZ bool
+		wantN bool
+	}{
+		{"ADDS overflow", OpADDS, 0x7FFF, 1, 0x7FFF, false, false},
+		{"ADDS underflow", OpADDS, 0x8000, 0xFFFF, 0x8000, false, true},
+		{"ADDS in range", OpADDS, 0xFFFE, 3, 1, false, false},
+		{"SUBS underflow", OpSUBS, 0x8000, 1, 0x8000, false, true},
+		{"SUBS overflow", OpSUBS, 0x7FFF, 0xFFFF, 0x7FFF, false, false},
+		{"SUBS in range", OpSUBS, 5, 5, 0, true, false},
+		{"ADDUS overflow", OpADDUS, 0xFFF0, 0x0100, 0xFFFF, false, true},
+		{"ADDUS in range", OpADDUS, 0x7FFF, 1, 0x8000, false, true},
+		{"SUBUS underflow", OpSUBUS, 0x0000, 1, 0x0000, true, false},
+		{"SUBUS in range", OpSUBUS, 10, 3, 7, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCPU()
+			c.Regs[RegA] = tt.a
+			c.Regs[RegB] = tt.b
+			loadProgram(c,
+				EncodeInstruction(tt.op, RegA, RegB, 0),
+				EncodeInstruction(OpHLT, 0, 0, 0),
+			)
+			c.Run()
+			if c.Regs[RegA] != tt.want {
+				t.Errorf("expected 0x%04X, got 0x%04X", tt.want, c.Regs[RegA])
+			}
+			if c.Z != tt.wantZ || c.N != tt.wantN {
+				t.Errorf("flags Z=%v N=%v, want Z=%v N=%v", c.Z, c.N, tt.wantZ, tt.wantN)
+			}
+		})
+	}
+}
+
+func TestByteOps(t *testing.T) {
+	tests := []struct {
+		name  string
+		op    uint16
+		a, b  uint16
+		want  uint16
+		wantZ bool
+		wantN bool
+		wantC bool
+	}{
+		{"ADDB wraps", OpADDB, 0x00FF, 0x0002, 0x0001, false, false, true},
+		{"ADDB ignores high bytes", OpADDB, 0x12FF, 0x3402, 0x0001, false, false, true},
+		{"ADDB negative", OpADDB, 0x0070, 0x0010, 0x0080, false, true, false},
+		{"ADDB zero", OpADDB, 0x0080, 0x0080, 0x0000, true, false, true},
+		{"SUBB borrow", OpSUBB, 0x0001, 0x0002, 0x00FF, false, true, true},
+		{"SUBB equal", OpSUBB, 0xAB42, 0x0042, 0x0000, true, false, false},
+		{"ANDB", OpANDB, 0xFFF0, 0xFF3C, 0x0030, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCPU()
+			c.Regs[RegA] = tt.a
+			c.Regs[RegB] = tt.b
+			loadProgram(c,
+				EncodeInstruction(tt.op, RegA, RegB, 0),
+				EncodeInstruction(OpHLT, 0, 0, 0),
+			)
+			c.Run()
+			if c.Regs[RegA] != tt.want {
+				t.Errorf("expected 0x%04X, got 0x%04X", tt.want, c.Regs[RegA])
+			}
+			if c.Z != tt.wantZ || c.N != tt.wantN {
+				t.Errorf("flags Z=%v N=%v, want Z=%v N=%v", c.Z, c.N, tt.wantZ, tt.wantN)
+			}
+			if tt.op != OpANDB && c.C != tt.wantC {
+				t.Errorf("C=%v, want %v", c.C, tt.wantC)
+			}
+		})
+	}
+}
+
 func TestBitmapEnable_DefaultOff(t *testing.T) {
 	c := NewCPU()
 	if c.GraphicsEnabled {
@@ -744,6 +1037,84 @@ func TestOpCOPY(t *testing.T) {
 	}
 }
 
+func TestBlockOps_ClipAtEndOfMemory(t *testing.T) {
+	fill := EncodeInstruction(OpFILL, RegA, RegB, RegC)
+	cpy := EncodeInstruction(OpCOPY, RegA, RegB, RegC)
+
+	tests := []struct {
+		name   string
+		instr  uint16
+		a, b   uint16
+		c      uint16
+		strict bool
+	}{
+		{"FILL", fill, 0xFFF0, 100, 0xAA55, false},
+		{"FILL_OddStart", fill, 0xFFF1, 100, 0xAA55, false},
+		{"COPY_DstNearTop", cpy, 0x2000, 0xFFF0, 100, false},
+		{"COPY_SrcNearTop", cpy, 0xFFF0, 0x2000, 100, false},
+		{"FILL_Strict", fill, 0xFFF0, 100, 0xAA55, true},
+		{"COPY_Strict", cpy, 0x2000, 0xFFF0, 100, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCPU()
+			c.StrictBounds = tt.strict
+			c.Regs[RegA], c.Regs[RegB], c.Regs[RegC] = tt.a, tt.b, tt.c
+			for i := uint16(0); i < 100; i++ {
+				c.Write16(0x2000+i*2, 0x1111)
+			}
+			c.Write16(0x0100, 0xBEEF)
+			loadProgram(c, tt.instr, EncodeInstruction(OpHLT, 0, 0, 0))
+			c.Run()
+
+			// Nothing may wrap around into low memory: the program and the
+			// marker word must be untouched.
+			if got := c.Read16(0x0000); got != tt.instr {
+				t.Errorf("Memory[0x0000] = 0x%04X, want 0x%04X", got, tt.instr)
+			}
+			if got := c.Read16(0x0100); got != 0xBEEF {
+				t.Errorf("Memory[0x0100] = 0x%04X, want 0xBEEF", got)
+			}
+
+			if tt.strict {
+				if !errors.Is(c.Fault, ErrBlockOutOfRange) {
+					t.Fatalf("Fault = %v, want ErrBlockOutOfRange", c.Fault)
+				}
+				if c.PC != 0x0000 {
+					t.Errorf("PC = 0x%04X, want 0x0000 (the faulting instruction)", c.PC)
+				}
+				if got := c.Read16(0xFFF0); got != 0 {
+					t.Errorf("Memory[0xFFF0] = 0x%04X, faulting op must not write", got)
+				}
+				return
+			}
+			if c.Fault != nil {
+				t.Fatalf("unexpected fault: %v", c.Fault)
+			}
+			// The words that do fit are still transferred.
+			if tt.instr == fill && tt.a == 0xFFF0 {
+				for addr := uint32(0xFFF0); addr <= 0xFFFE; addr += 2 {
+					if got := c.Read16(uint16(addr)); got != 0xAA55 {
+						t.Errorf("Memory[0x%04X] = 0x%04X, want 0xAA55", addr, got)
+					}
+				}
+			}
+			if tt.instr == cpy && tt.b == 0xFFF0 {
+				if got := c.Read16(0xFFFE); got != 0x1111 {
+					t.Errorf("Memory[0xFFFE] = 0x%04X, want 0x1111", got)
+				}
+			}
+			if tt.instr == cpy && tt.a == 0xFFF0 {
+				// Only the 8 source words below 0x10000 are copied.
+				if got := c.Read16(0x2000 + 8*2); got != 0x1111 {
+					t.Errorf("Memory[0x2010] = 0x%04X, want 0x1111 (copy must stop at end of source)", got)
+				}
+			}
+		})
+	}
+}
+
 func TestALU_EdgeCases(t *testing.T) {
 	// ADD Overflow
 	cpu := NewCPU()
@@ -1097,6 +1468,482 @@ func TestLDB_STB(t *testing.T) {
 	}
 }
 
+func TestLDF_STF(t *testing.T) {
+	// LDF reflects the flags left by the last ALU op.
+	c := NewCPU()
+	c.Regs[RegA] = 0x8000
+	c.Regs[RegB] = 0x8000
+	loadProgram(c,
+		EncodeInstruction(OpEI, 0, 0, 0),
+		EncodeInstruction(OpSUB, RegA, RegB, 0), // Z=1, N=0, C=0
+		EncodeInstruction(OpLDF, RegC, 0, 0),
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+	c.Run()
+	if want := FlagZ | FlagIE; c.Regs[RegC] != want {
+		t.Errorf("LDF: expected R2=0x%04X, got 0x%04X", want, c.Regs[RegC])
+	}
+
+	// Without FlagSetIE, STF restores Z/N/C/V and leaves IE alone, in
+	// either direction.
+	for _, ie := range []bool{false, true} {
+		c = NewCPU()
+		c.Regs[RegA] = FlagN | FlagC
+		if !ie {
+			c.Regs[RegA] |= FlagIE
+		}
+		loadProgram(c,
+			EncodeInstruction(OpSTF, RegA, 0, 0),
+			EncodeInstruction(OpHLT, 0, 0, 0),
+		)
+		c.Z, c.IE = true, ie
+		c.Run()
+		if c.Z || !c.N || !c.C || c.V || c.IE != ie {
+			t.Errorf("STF: expected Z=0 N=1 C=1 V=0 IE=%v, got Z=%v N=%v C=%v V=%v IE=%v", ie, c.Z, c.N, c.C, c.V, c.IE)
+		}
+	}
+
+	// With FlagSetIE, STF also loads IE from bit 4, both set and clear.
+	for _, ie := range []bool{false, true} {
+		c = NewCPU()
+		c.Regs[RegA] = FlagSetIE
+		if ie {
+			c.Regs[RegA] |= FlagIE
+		}
+		loadProgram(c,
+			EncodeInstruction(OpSTF, RegA, 0, 0),
+			EncodeInstruction(OpHLT, 0, 0, 0),
+		)
+		c.IE = !ie
+		c.Run()
+		if c.IE != ie {
+			t.Errorf("STF with FlagSetIE: expected IE=%v, got %v", ie, c.IE)
+		}
+	}
+
+	// Round trip: save, clobber, restore.
+	c = NewCPU()
+	c.N, c.C = true, true
+	c.Regs[RegA] = 1
+	c.Regs[RegB] = 1
+	loadProgram(c,
+		EncodeInstruction(OpLDF, RegC, 0, 0),
+		EncodeInstruction(OpSUB, RegA, RegB, 0), // Z=1, N=0
+		EncodeInstruction(OpSTF, RegC, 0, 0),
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+	c.Run()
+	if c.Z || !c.N || !c.C {
+		t.Errorf("round trip: expected Z=0 N=1 C=1, got Z=%v N=%v C=%v", c.Z, c.N, c.C)
+	}
+}
+
+func TestLDF_STFBits(t *testing.T) {
+	tests := []struct {
+		name string
+		bit  uint16
+		set  func(c *CPU)
+		get  func(c *CPU) bool
+	}{
+		{"Z", 1 << 0, func(c *CPU) { c.Z = true }, func(c *CPU) bool { return c.Z }},
+		{"N", 1 << 1, func(c *CPU) { c.N = true }, func(c *CPU) bool { return c.N }},
+		{"C", 1 << 2, func(c *CPU) { c.C = true }, func(c *CPU) bool { return c.C }},
+		{"V", 1 << 3, func(c *CPU) { c.V = true }, func(c *CPU) bool { return c.V }},
+		{"IE", 1 << 4, func(c *CPU) { c.IE = true }, func(c *CPU) bool { return c.IE }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LDF sets exactly this flag's bit.
+			c := NewCPU()
+			tt.set(c)
+			loadProgram(c,
+				EncodeInstruction(OpLDF, RegA, 0, 0),
+				EncodeInstruction(OpHLT, 0, 0, 0),
+			)
+			c.Run()
+			if c.Regs[RegA] != tt.bit {
+				t.Errorf("LDF = 0x%04X, want 0x%04X", c.Regs[RegA], tt.bit)
+			}
+
+			// STF of that bit alone sets only this flag.
+			c = NewCPU()
+			c.Regs[RegA] = tt.bit | FlagSetIE
+			loadProgram(c,
+				EncodeInstruction(OpSTF, RegA, 0, 0),
+				EncodeInstruction(OpHLT, 0, 0, 0),
+			)
+			c.Run()
+			if !tt.get(c) {
+				t.Errorf("STF 0x%04X did not set %s", tt.bit, tt.name)
+			}
+			if got := c.Flags(); got != tt.bit {
+				t.Errorf("flags after STF = 0x%04X, want 0x%04X", got, tt.bit)
+			}
+		})
+	}
+}
+
+func TestSWAP(t *testing.T) {
+	c := NewCPU()
+	c.Regs[RegA] = 1
+	c.Regs[RegB] = 2
+	c.Z, c.N, c.C = true, false, true
+	loadProgram(c,
+		EncodeInstruction(OpSWAP, RegA, RegB, 0),
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+	c.Run()
+	if c.Regs[RegA] != 2 || c.Regs[RegB] != 1 {
+		t.Errorf("SWAP: expected R0=2 R1=1, got R0=%d R1=%d", c.Regs[RegA], c.Regs[RegB])
+	}
+	if !c.Z || c.N || !c.C {
+		t.Errorf("SWAP: flags changed: Z=%v N=%v C=%v", c.Z, c.N, c.C)
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	tests := []struct {
+		op   uint16
+		a, b uint16
+		want uint16
+	}{
+		{OpMIN, 3, 7, 3},
+		{OpMIN, 0xFFFF, 1, 0xFFFF}, // -1 < 1
+		{OpMAX, 0xFFFF, 1, 1},
+		{OpMAX, 5, 5, 5},
+		{OpMINU, 0xFFFF, 1, 1},
+		{OpMAXU, 0xFFFF, 1, 0xFFFF},
+		{OpMAXU, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		c := NewCPU()
+		c.Regs[RegA] = tt.a
+		c.Regs[RegB] = tt.b
+		loadProgram(c,
+			EncodeInstruction(tt.op, RegA, RegB, 0),
+			EncodeInstruction(OpHLT, 0, 0, 0),
+		)
+		c.Run()
+		if c.Regs[RegA] != tt.want {
+			t.Errorf("op 0x%02X (0x%04X, 0x%04X): expected 0x%04X, got 0x%04X", tt.op, tt.a, tt.b, tt.want, c.Regs[RegA])
+		}
+		if c.Z != (tt.want == 0) || c.N != (tt.want&0x8000 != 0) {
+			t.Errorf("op 0x%02X: flags Z=%v N=%v do not match result 0x%04X", tt.op, c.Z, c.N, tt.want)
+		}
+	}
+}
+
+func TestCMP(t *testing.T) {
+	tests := []struct {
+		a, b    uint16
+		z, n, c bool
+	}{
+		{5, 5, true, false, false},
+		{7, 3, false, false, false},
+		{3, 7, false, true, true},
+		{0xFFFF, 1, false, true, false}, // -1 vs 1: negative, no borrow
+		{1, 0xFFFF, false, false, true},
+	}
+	for _, tt := range tests {
+		c := NewCPU()
+		c.Regs[RegA] = tt.a
+		c.Regs[RegB] = tt.b
+		loadProgram(c,
+			EncodeInstruction(OpCMP, RegA, RegB, 0),
+			EncodeInstruction(OpHLT, 0, 0, 0),
+		)
+		c.Run()
+		if c.Regs[RegA] != tt.a || c.Regs[RegB] != tt.b {
+			t.Errorf("CMP 0x%04X, 0x%04X changed operands to 0x%04X, 0x%04X", tt.a, tt.b, c.Regs[RegA], c.Regs[RegB])
+		}
+		if c.Z != tt.z || c.N != tt.n || c.C != tt.c {
+			t.Errorf("CMP 0x%04X, 0x%04X: Z=%v N=%v C=%v, want Z=%v N=%v C=%v", tt.a, tt.b, c.Z, c.N, c.C, tt.z, tt.n, tt.c)
+		}
+	}
+}
+
+func TestLDX_STX(t *testing.T) {
+	c := NewCPU()
+	c.Regs[RegB] = 0x2000 // base
+	c.Regs[RegC] = 0xBEEF
+	w16(c, 0x2006, 0x1234)
+	loadProgram(c,
+		EncodeInstruction(OpLDX, RegA, RegB, 0), 6, // LDX R0, [R1 + 6]
+		EncodeInstruction(OpSTX, RegB, RegC, 0), 0x000A, // STX [R1 + 10], R2
+		EncodeInstruction(OpSTX, RegB, RegA, 0), 0xFFFE, // STX [R1 - 2], R0
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+	c.Run()
+
+	if c.Regs[RegA] != 0x1234 {
+		t.Errorf("LDX: R0 = 0x%04X, want 0x1234", c.Regs[RegA])
+	}
+	if got := c.Read16(0x200A); got != 0xBEEF {
+		t.Errorf("STX: [0x200A] = 0x%04X, want 0xBEEF", got)
+	}
+	if got := c.Read16(0x1FFE); got != 0x1234 {
+		t.Errorf("STX negative displacement: [0x1FFE] = 0x%04X, want 0x1234", got)
+	}
+	if c.Regs[RegB] != 0x2000 {
+		t.Errorf("base register changed to 0x%04X", c.Regs[RegB])
+	}
+	if c.PC != 14 {
+		t.Errorf("PC = %d, want 14", c.PC)
+	}
+}
+
+func TestMOVM(t *testing.T) {
+	c := NewCPU()
+	w16(c, 0x2000, 0xCAFE)
+	c.Z = true
+	loadProgram(c,
+		EncodeInstruction(OpMOVM, 0, 0, 0), 0x3000, 0x2000, // MOVM 0x3000, 0x2000
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+	c.Run()
+	if got := c.Read16(0x3000); got != 0xCAFE {
+		t.Errorf("[0x3000] = 0x%04X, want 0xCAFE", got)
+	}
+	if got := c.Read16(0x2000); got != 0xCAFE {
+		t.Errorf("source changed to 0x%04X", got)
+	}
+	if !c.Z {
+		t.Error("MOVM changed the flags")
+	}
+	if c.PC != 8 {
+		t.Errorf("PC = %d, want 8", c.PC)
+	}
+}
+
+func TestLDX_StrictAlign(t *testing.T) {
+	c := NewCPU()
+	c.StrictAlign = true
+	c.Regs[RegB] = 0x2000
+	loadProgram(c,
+		EncodeInstruction(OpNOP, 0, 0, 0),
+		EncodeInstruction(OpLDX, RegA, RegB, 0), 3,
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+	c.Run()
+	if !errors.Is(c.Fault, ErrUnalignedAccess) {
+		t.Fatalf("Fault = %v, want ErrUnalignedAccess", c.Fault)
+	}
+	if c.PC != 2 {
+		t.Errorf("PC = 0x%04X, want 0x0002 (the LDX)", c.PC)
+	}
+}
+
+func TestStackLimit(t *testing.T) {
+	c := NewCPU()
+	c.StackLimit = 0x1000
+	c.Regs[RegA] = 0xAAAA
+	loadProgram(c,
+		EncodeInstruction(OpPUSH, RegA, 0, 0),      // 0x0000: PUSH R0
+		EncodeInstruction(OpCALL, 0, 0, 0), 0x0000, // 0x0002: CALL 0x0000
+	)
+	c.RunUntilDone()
+
+	if !c.StackOverflow || !errors.Is(c.Fault, ErrStackOverflow) {
+		t.Fatalf("StackOverflow = %v, Fault = %v; want ErrStackOverflow", c.StackOverflow, c.Fault)
+	}
+	if !c.Halted {
+		t.Error("CPU not halted")
+	}
+	if c.SP != 0x1000 {
+		t.Errorf("SP = 0x%04X, want 0x1000", c.SP)
+	}
+	if c.PC != 0x0002 {
+		t.Errorf("PC = 0x%04X, want 0x0002 (the CALL)", c.PC)
+	}
+	if got := c.Read16(0x0FFE); got != 0 {
+		t.Errorf("[0x0FFE] = 0x%04X, written below the limit", got)
+	}
+	for i := range c.GraphicsBanks {
+		if c.GraphicsBanks[i] != [16384]byte{} {
+			t.Fatalf("graphics bank %d written", i)
+		}
+	}
+
+	c.Reset()
+	if c.StackOverflow {
+		t.Error("Reset left StackOverflow set")
+	}
+}
+
+func TestStackLimit_Wrap(t *testing.T) {
+	// A push from SP 0x0000 would wrap to the top of memory.
+	c := NewCPU()
+	c.StackLimit = 0x0100
+	c.SP = 0x0000
+	loadProgram(c, EncodeInstruction(OpPUSH, RegA, 0, 0))
+	c.Step()
+	if !errors.Is(c.Fault, ErrStackOverflow) || c.SP != 0x0000 {
+		t.Errorf("Fault = %v, SP = 0x%04X; want ErrStackOverflow with SP unchanged", c.Fault, c.SP)
+	}
+}
+
+func TestStackLimit_Interrupt(t *testing.T) {
+	c := NewCPU()
+	c.StackLimit = 0x1000
+	c.SP = 0x1000
+	c.IE = true
+	loadProgram(c,
+		EncodeInstruction(OpNOP, 0, 0, 0),
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+	c.Step()
+	c.PushKey('k')
+	c.Step()
+	if !errors.Is(c.Fault, ErrStackOverflow) {
+		t.Fatalf("Fault = %v, want ErrStackOverflow", c.Fault)
+	}
+	if c.PC != 0x0002 || c.SP != 0x1000 {
+		t.Errorf("PC = 0x%04X, SP = 0x%04X; want the interrupted PC 0x0002 and SP 0x1000", c.PC, c.SP)
+	}
+}
+
+func TestOverflowFlag(t *testing.T) {
+	tests := []struct {
+		name  string
+		op    uint16
+		a, b  uint16
+		wantV bool
+	}{
+		{"0x7FFF + 1", OpADD, 0x7FFF, 1, true},
+		{"0x8000 + 0x8000", OpADD, 0x8000, 0x8000, true},
+		{"1 + 2", OpADD, 1, 2, false},
+		{"-1 + 1", OpADD, 0xFFFF, 1, false},
+		{"0x8000 - 1", OpSUB, 0x8000, 1, true},
+		{"0x7FFF - -1", OpSUB, 0x7FFF, 0xFFFF, true},
+		{"5 - 7", OpSUB, 5, 7, false},
+		{"cmp 0x8000, 1", OpCMP, 0x8000, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCPU()
+			c.Regs[RegA] = tt.a
+			c.Regs[RegB] = tt.b
+			c.V = !tt.wantV
+			loadProgram(c,
+				EncodeInstruction(tt.op, RegA, RegB, 0),
+				EncodeInstruction(OpHLT, 0, 0, 0),
+			)
+			c.Run()
+			if c.V != tt.wantV {
+				t.Errorf("V = %v, want %v", c.V, tt.wantV)
+			}
+			if got := c.Flags()&FlagV != 0; got != tt.wantV {
+				t.Errorf("Flags() V bit = %v, want %v", got, tt.wantV)
+			}
+		})
+	}
+}
+
+func TestJV_JNV(t *testing.T) {
+	for _, overflow := range []bool{true, false} {
+		c := NewCPU()
+		c.Regs[RegA] = 0x7FFF
+		if !overflow {
+			c.Regs[RegA] = 1
+		}
+		c.Regs[RegB] = 1
+		loadProgram(c,
+			EncodeInstruction(OpADD, RegA, RegB, 0), // 0000
+			EncodeInstruction(OpJV, 0, 0, 0), 0x000E, // 0002
+			EncodeInstruction(OpJNV, 0, 0, 0), 0x0014, // 0006
+			EncodeInstruction(OpHLT, 0, 0, 0), // 000A
+			EncodeInstruction(OpHLT, 0, 0, 0), // 000C
+			EncodeInstruction(OpLDI, RegC, 0, 0), 1, // 000E: overflow
+			EncodeInstruction(OpHLT, 0, 0, 0), // 0012
+			EncodeInstruction(OpLDI, RegC, 0, 0), 2, // 0014: no overflow
+			EncodeInstruction(OpHLT, 0, 0, 0), // 0018
+		)
+		c.Run()
+		want := uint16(2)
+		if overflow {
+			want = 1
+		}
+		if c.Regs[RegC] != want {
+			t.Errorf("overflow=%v: took branch %d, want %d", overflow, c.Regs[RegC], want)
+		}
+	}
+}
+
+func TestADC_SBC(t *testing.T) {
+	// 32-bit values as hi:lo register pairs, Rd:Ra += Rc:Rb, then back again.
+	tests := []struct {
+		name       string
+		op         uint16
+		x, y, want uint32
+		wantC      bool
+	}{
+		{"add with carry into high word", OpADC, 0x0001FFFF, 0x00000001, 0x00020000, false},
+		{"add no carry", OpADC, 0x12340001, 0x00010002, 0x12350003, false},
+		{"add carry out of high word", OpADC, 0xFFFF8000, 0x00008000, 0x00000000, true},
+		{"sub with borrow from high word", OpSBC, 0x00020000, 0x00000001, 0x0001FFFF, false},
+		{"sub borrow out of high word", OpSBC, 0x00000000, 0x00000001, 0xFFFFFFFF, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCPU()
+			c.Regs[RegA] = uint16(tt.x)
+			c.Regs[RegD] = uint16(tt.x >> 16)
+			c.Regs[RegB] = uint16(tt.y)
+			c.Regs[RegC] = uint16(tt.y >> 16)
+			c.C = false
+			loadProgram(c,
+				EncodeInstruction(tt.op, RegA, RegB, 0),
+				EncodeInstruction(tt.op, RegD, RegC, 0),
+				EncodeInstruction(OpHLT, 0, 0, 0),
+			)
+			c.Run()
+			got := uint32(c.Regs[RegD])<<16 | uint32(c.Regs[RegA])
+			if got != tt.want {
+				t.Errorf("result = 0x%08X, want 0x%08X", got, tt.want)
+			}
+			if c.C != tt.wantC {
+				t.Errorf("C = %v, want %v", c.C, tt.wantC)
+			}
+			if c.Z != (c.Regs[RegD] == 0) || c.N != (c.Regs[RegD]&0x8000 != 0) {
+				t.Errorf("Z/N = %v/%v for high word 0x%04X", c.Z, c.N, c.Regs[RegD])
+			}
+		})
+	}
+}
+
+func TestRotate(t *testing.T) {
+	tests := []struct {
+		op       uint16
+		val, cnt uint16
+		want     uint16
+	}{
+		{OpROL, 0x8001, 1, 0x0003},
+		{OpROR, 0x8001, 1, 0xC000},
+		{OpROL, 0x1234, 4, 0x2341},
+		{OpROR, 0x1234, 4, 0x4123},
+		{OpROL, 0x1234, 16, 0x1234}, // count is taken mod 16
+		{OpROR, 0x1234, 0, 0x1234},
+		{OpROL, 0, 3, 0},
+	}
+	for _, tt := range tests {
+		c := NewCPU()
+		c.Regs[RegA] = tt.val
+		c.Regs[RegB] = tt.cnt
+		c.C = true
+		loadProgram(c,
+			EncodeInstruction(tt.op, RegA, RegB, 0),
+			EncodeInstruction(OpHLT, 0, 0, 0),
+		)
+		c.Run()
+		if c.Regs[RegA] != tt.want {
+			t.Errorf("op 0x%02X 0x%04X by %d: got 0x%04X, want 0x%04X", tt.op, tt.val, tt.cnt, c.Regs[RegA], tt.want)
+		}
+		if c.Z != (tt.want == 0) || c.N != (tt.want&0x8000 != 0) || !c.C {
+			t.Errorf("op 0x%02X 0x%04X by %d: flags Z=%v N=%v C=%v", tt.op, tt.val, tt.cnt, c.Z, c.N, c.C)
+		}
+	}
+}
+
 func TestNewRegisters(t *testing.T) {
 	cpu := NewCPU()
 	// Store 100 in R4
@@ -1263,3 +2110,271 @@ func TestPeripheralRouting(t *testing.T) {
 		t.Errorf("Peripheral Reading: expected 0x1337, got 0x%04X", val)
 	}
 }
+
+func TestStrictAlign(t *testing.T) {
+	tests := []struct {
+		name      string
+		strict    bool
+		addr      uint16
+		wantFault bool
+	}{
+		{"Strict_Even", true, 0x2000, false},
+		{"Strict_Odd", true, 0x2001, true},
+		{"Default_Odd", false, 0x2001, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCPU()
+			c.StrictAlign = tt.strict
+			c.Write16(tt.addr, 0xBEEF)
+			loadProgram(c,
+				EncodeInstruction(OpLDI, 1, 0, 0), tt.addr, // LDI R1, addr
+				EncodeInstruction(OpLD, 0, 1, 0), // LD R0, [R1]
+				EncodeInstruction(OpHLT, 0, 0, 0),
+			)
+			c.Run()
+
+			if !tt.wantFault {
+				if c.Fault != nil {
+					t.Fatalf("unexpected fault: %v", c.Fault)
+				}
+				if c.Regs[0] != 0xBEEF {
+					t.Errorf("R0 = 0x%04X, want 0xBEEF", c.Regs[0])
+				}
+				return
+			}
+			if !errors.Is(c.Fault, ErrUnalignedAccess) {
+				t.Fatalf("Fault = %v, want ErrUnalignedAccess", c.Fault)
+			}
+			if !c.Halted {
+				t.Error("CPU should be halted after a fault")
+			}
+			if c.PC != 0x0004 {
+				t.Errorf("PC = 0x%04X, want 0x0004 (the faulting LD)", c.PC)
+			}
+			if c.Regs[0] != 0 {
+				t.Errorf("R0 = 0x%04X, faulting LD must not load a value", c.Regs[0])
+			}
+		})
+	}
+
+	t.Run("Strict_OddStore", func(t *testing.T) {
+		c := NewCPU()
+		c.StrictAlign = true
+		loadProgram(c,
+			EncodeInstruction(OpLDI, 1, 0, 0), 0x2001, // LDI R1, 0x2001
+			EncodeInstruction(OpLDI, 2, 0, 0), 0x1234, // LDI R2, 0x1234
+			EncodeInstruction(OpST, 1, 2, 0), // ST [R1], R2
+			EncodeInstruction(OpHLT, 0, 0, 0),
+		)
+		c.Run()
+		if !errors.Is(c.Fault, ErrUnalignedAccess) {
+			t.Fatalf("Fault = %v, want ErrUnalignedAccess", c.Fault)
+		}
+		if c.Read16(0x2001) != 0 {
+			t.Error("faulting ST must not write memory")
+		}
+	})
+}
+
+func TestNewCPUWithOptions_MemFill(t *testing.T) {
+	c, err := NewCPUWithOptions(Options{MemFill: 0xCD})
+	if err != nil {
+		t.Fatalf("NewCPUWithOptions: %v", err)
+	}
+	program := []uint16{
+		EncodeInstruction(OpLDI, 1, 0, 0), 0x4000, // LDI R1, 0x4000
+		EncodeInstruction(OpLDB, 0, 1, 0), // LDB R0, [R1]
+		EncodeInstruction(OpLD, 2, 1, 0),  // LD R2, [R1]
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	}
+	loadProgram(c, program...)
+	c.Run()
+
+	if c.Regs[0] != 0xCD {
+		t.Errorf("LDB from unwritten memory: expected 0xCD, got 0x%02X", c.Regs[0])
+	}
+	if c.Regs[2] != 0xCDCD {
+		t.Errorf("LD from unwritten memory: expected 0xCDCD, got 0x%04X", c.Regs[2])
+	}
+	for i, w := range program {
+		if got := c.Read16(uint16(2 * i)); got != w {
+			t.Errorf("program word %d: expected 0x%04X, got 0x%04X", i, w, got)
+		}
+	}
+	if c.Memory[2*len(program)] != 0xCD {
+		t.Errorf("byte after program: expected 0xCD, got 0x%02X", c.Memory[2*len(program)])
+	}
+
+	if plain := NewCPU(); plain.Memory[0x4000] != 0 {
+		t.Errorf("NewCPU should leave memory zeroed, got 0x%02X", plain.Memory[0x4000])
+	}
+}
+
+type failingReader struct {
+	data []byte
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// endlessReader yields zero bytes forever and counts how many were read.
+type endlessReader struct {
+	n int64
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	clear(p)
+	r.n += int64(len(p))
+	return len(p), nil
+}
+
+func TestLoadFrom(t *testing.T) {
+	image := []byte{0x00, 0x08, 0x2A, 0x00, 0x00, 0x00} // LDI R0, 42; HLT
+	c := NewCPU()
+	if err := c.LoadFrom(bytes.NewReader(image)); err != nil {
+		t.Fatalf("LoadFrom failed: %v", err)
+	}
+	if !bytes.Equal(c.Memory[:len(image)], image) {
+		t.Errorf("memory = % X, want % X", c.Memory[:len(image)], image)
+	}
+	c.Run()
+	if c.Regs[0] != 42 {
+		t.Errorf("expected R0 = 42, got %d", c.Regs[0])
+	}
+
+	t.Run("ReaderError", func(t *testing.T) {
+		c := NewCPU()
+		c.Memory[0] = 0x77
+		errBoom := errors.New("connection reset")
+		err := c.LoadFrom(&failingReader{data: []byte{1, 2, 3, 4}, err: errBoom})
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("expected wrapped reader error, got %v", err)
+		}
+		if c.Memory[0] != 0x77 || c.Memory[1] != 0 {
+			t.Errorf("memory modified by failed load: % X", c.Memory[:4])
+		}
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		c := NewCPU()
+		err := c.LoadFrom(bytes.NewReader(make([]byte, len(c.Memory)+1)))
+		if err == nil || !strings.Contains(err.Error(), "too large") {
+			t.Errorf("expected size error, got %v", err)
+		}
+	})
+
+	t.Run("EndlessStream", func(t *testing.T) {
+		c := NewCPU()
+		r := &endlessReader{}
+		err := c.LoadFrom(r)
+		if err == nil || !strings.Contains(err.Error(), "too large") {
+			t.Errorf("expected size error, got %v", err)
+		}
+		if r.n > int64(len(c.Memory))+1 {
+			t.Errorf("read %d bytes, expected at most %d", r.n, len(c.Memory)+1)
+		}
+	})
+}
+
+func TestMMIOLogger(t *testing.T) {
+	type access struct {
+		addr  uint16
+		val   uint16
+		write bool
+	}
+	var log []access
+	c := NewCPU()
+	c.MMIOLogger = func(addr uint16, val uint16, write bool) {
+		log = append(log, access{addr, val, write})
+	}
+
+	loadProgram(c,
+		EncodeInstruction(OpLDI, 1, 0, 0), 0xFF07, // LDI R1, 0xFF07 (palette index)
+		EncodeInstruction(OpLDI, 0, 0, 0), 3, // LDI R0, 3
+		EncodeInstruction(OpST, 1, 0, 0), // ST [R1], R0
+		EncodeInstruction(OpLDI, 1, 0, 0), 0xFF08, // LDI R1, 0xFF08 (palette data)
+		EncodeInstruction(OpLDI, 0, 0, 0), 0x1234, // LDI R0, 0x1234
+		EncodeInstruction(OpST, 1, 0, 0), // ST [R1], R0
+		EncodeInstruction(OpLD, 2, 1, 0), // LD R2, [R1]
+		EncodeInstruction(OpLDI, 1, 0, 0), 0xFF05, // LDI R1, 0xFF05 (video mode)
+		EncodeInstruction(OpLDB, 3, 1, 0), // LDB R3, [R1]
+		EncodeInstruction(OpLDI, 1, 0, 0), 0x4000, // LDI R1, 0x4000 (plain RAM)
+		EncodeInstruction(OpST, 1, 0, 0), // ST [R1], R0
+		EncodeInstruction(OpLD, 2, 1, 0), // LD R2, [R1]
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+	c.Run()
+
+	want := []access{
+		{0xFF07, 3, true},
+		{0xFF08, 0x1234, true},
+		{0xFF08, 0x1234, false},
+		{0xFF05, 0x01, false}, // text overlay on by default
+	}
+	if len(log) != len(want) {
+		t.Fatalf("logged %d accesses, want %d: %+v", len(log), len(want), log)
+	}
+	for i := range want {
+		if log[i] != want[i] {
+			t.Errorf("access %d = %+v, want %+v", i, log[i], want[i])
+		}
+	}
+}
+
+func TestCycles(t *testing.T) {
+	c := NewCPU()
+	loadProgram(c,
+		EncodeInstruction(OpLDI, 0, 0, 0), 3, // LDI R0, 3
+		EncodeInstruction(OpLDI, 1, 0, 0), 1, // LDI R1, 1
+		EncodeInstruction(OpSUB, 0, 1, 0), // loop: SUB R0, R1
+		EncodeInstruction(OpJNZ, 0, 0, 0), 0x0008, // JNZ loop
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+	c.Run()
+	// 2 LDIs, 3 passes of SUB+JNZ, then HLT.
+	if c.Cycles != 9 {
+		t.Errorf("Cycles = %d, want 9", c.Cycles)
+	}
+
+	c.Step() // halted: nothing executes
+	if c.Cycles != 9 {
+		t.Errorf("Cycles after halted Step = %d, want 9", c.Cycles)
+	}
+}
+
+func TestCycles_MMIO(t *testing.T) {
+	c := NewCPU()
+	c.Cycles = 0x1_2345_6789
+	if got := c.Read16(CycleCountLoReg); got != 0x6789 {
+		t.Errorf("low word = 0x%04X, want 0x6789", got)
+	}
+	if got := c.Read16(CycleCountHiReg); got != 0x2345 {
+		t.Errorf("high word = 0x%04X, want 0x2345", got)
+	}
+
+	c.Write16(CycleCountLoReg, 0)
+	if c.Cycles != 0x1_2345_6789 {
+		t.Errorf("write changed Cycles to 0x%X", c.Cycles)
+	}
+
+	data, err := c.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+	c2 := NewCPU()
+	if err := c2.RestoreFromBytes(data); err != nil {
+		t.Fatalf("RestoreFromBytes: %v", err)
+	}
+	if c2.Cycles != 0x1_2345_6789 {
+		t.Errorf("restored Cycles = 0x%X, want 0x123456789", c2.Cycles)
+	}
+}
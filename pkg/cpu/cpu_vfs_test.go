@@ -2,6 +2,8 @@ package cpu
 
 import (
 	"testing"
+
+	"gocpu/pkg/vfs"
 )
 
 func TestVFS_NewCommands(t *testing.T) {
@@ -117,3 +119,181 @@ func TestVFS_NewCommands(t *testing.T) {
 		t.Errorf("Verify Delete: Expected Status=1 (NotFound), got %d", c.Read16(0xFF14))
 	}
 }
+
+func TestVFS_SpaceRegisters(t *testing.T) {
+	c := NewCPU()
+	read32 := func(lo, hi uint16) int {
+		return int(uint32(c.Read16(hi))<<16 | uint32(c.Read16(lo)))
+	}
+
+	if used := read32(0xFF4B, 0xFF4C); used != 0 {
+		t.Errorf("empty disk: used = %d, expected 0", used)
+	}
+	if free := read32(0xFF4D, 0xFF4E); free != vfs.MaxDiskBytes {
+		t.Errorf("empty disk: free = %d, expected %d", free, vfs.MaxDiskBytes)
+	}
+
+	c.Disk.Write("a.bin", make([]byte, 100))
+	c.Disk.Write("b.bin", make([]byte, 70000)) // needs the high word
+	used := read32(0xFF4B, 0xFF4C)
+	if used != c.Disk.UsedBytes || used != 70100 {
+		t.Errorf("used = %d, expected %d", used, c.Disk.UsedBytes)
+	}
+	if free := read32(0xFF4D, 0xFF4E); free != vfs.MaxDiskBytes-70100 {
+		t.Errorf("free = %d, expected %d", free, vfs.MaxDiskBytes-70100)
+	}
+
+	c.Disk.Delete("b.bin")
+	if used := read32(0xFF4B, 0xFF4C); used != 100 {
+		t.Errorf("after delete: used = %d, expected 100", used)
+	}
+
+	// Writes are ignored.
+	c.Write16(0xFF4B, 0x1234)
+	if used := read32(0xFF4B, 0xFF4C); used != 100 {
+		t.Errorf("after write: used = %d, expected 100", used)
+	}
+}
+
+func TestVFS_ExecWaitFailsCleanly(t *testing.T) {
+	setup := func() *CPU {
+		c := NewCPU()
+		copy(c.Memory[0x1000:], "child.bin\x00")
+		c.Memory[0x4000] = 0x5A
+		c.Regs[3] = 0x1234
+		c.PC = 0x0200
+		c.SP = 0xB000
+		c.Z = true
+		c.Write16(0xFF11, 0x1000)
+		return c
+	}
+	check := func(t *testing.T, c *CPU, wantStatus uint16) {
+		t.Helper()
+		if got := c.Read16(0xFF14); got != wantStatus {
+			t.Errorf("status = %d, expected %d", got, wantStatus)
+		}
+		if c.Memory[0x4000] != 0x5A || c.Regs[3] != 0x1234 || c.PC != 0x0200 || c.SP != 0xB000 || !c.Z {
+			t.Errorf("program state changed: mem=0x%02X R3=0x%04X PC=0x%04X SP=0x%04X Z=%v",
+				c.Memory[0x4000], c.Regs[3], c.PC, c.SP, c.Z)
+		}
+		if c.CallDepth != 0 {
+			t.Errorf("CallDepth = %d, expected 0", c.CallDepth)
+		}
+		for _, name := range c.Disk.List() {
+			if name == ".swap_0.sys" {
+				t.Errorf("swap file left on disk")
+			}
+		}
+	}
+
+	t.Run("DiskFull", func(t *testing.T) {
+		c := setup()
+		c.Disk.Write("child.bin", []byte{0x00, 0x00})
+		c.Disk.Write("filler.bin", make([]byte, vfs.MaxDiskBytes-c.Disk.UsedBytes-100))
+		c.WriteMem(0xFF10, 8)
+		check(t, c, 2)
+	})
+
+	t.Run("ImageTooLarge", func(t *testing.T) {
+		c := setup()
+		c.Disk.Write("child.bin", make([]byte, 0x10001))
+		c.WriteMem(0xFF10, 8)
+		check(t, c, 4)
+	})
+}
+
+func TestVFS_Rename(t *testing.T) {
+	c := NewCPU()
+	c.Disk.Write("a.txt", []byte{0xAA})
+	c.Disk.Write("b.txt", []byte{0xBB})
+	copy(c.Memory[0x1000:], "a.txt\x00")
+	copy(c.Memory[0x1100:], "c.txt\x00")
+	copy(c.Memory[0x1200:], "b.txt\x00")
+
+	rename := func(oldPtr, newPtr uint16) uint16 {
+		c.Write16(0xFF11, oldPtr)
+		c.Write16(0xFF12, newPtr)
+		c.WriteMem(0xFF10, 9)
+		return c.Read16(0xFF14)
+	}
+
+	if st := rename(0x1000, 0x1100); st != 0 {
+		t.Fatalf("rename a.txt -> c.txt: status %d", st)
+	}
+	if data, err := c.Disk.Read("c.txt"); err != nil || data[0] != 0xAA {
+		t.Errorf("c.txt = %v, %v", data, err)
+	}
+	if st := rename(0x1000, 0x1100); st != 1 {
+		t.Errorf("missing source: status %d, expected 1", st)
+	}
+	if st := rename(0x1100, 0x1200); st != 6 {
+		t.Errorf("existing target: status %d, expected 6", st)
+	}
+}
+
+func TestVFS_ReadAt(t *testing.T) {
+	c := NewCPU()
+	big := make([]byte, 70000)
+	for i := range big {
+		big[i] = byte(i / 1000)
+	}
+	c.Disk.Write("big.bin", big)
+	copy(c.Memory[0x1000:], "big.bin\x00")
+	c.Write16(0xFF11, 0x1000)
+	c.Write16(0xFF12, 0x2000)
+
+	readAt := func(offset uint32, length uint16) uint16 {
+		c.Write16(VFSOffsetLoReg, uint16(offset))
+		c.Write16(VFSOffsetHiReg, uint16(offset>>16))
+		c.Write16(0xFF13, length)
+		c.WriteMem(0xFF10, 10)
+		return c.Read16(0xFF14)
+	}
+
+	// Mid-file, beyond the first 64KB.
+	if st := readAt(68999, 3); st != 0 {
+		t.Fatalf("mid-file read: status %d", st)
+	}
+	if got := c.Read16(0xFF13); got != 3 {
+		t.Errorf("mid-file read: length = %d, expected 3", got)
+	}
+	if got := c.Memory[0x2000:0x2003]; got[0] != 68 || got[1] != 69 || got[2] != 69 {
+		t.Errorf("mid-file read: bytes = %v, expected [68 69 69]", got)
+	}
+
+	// Short read at the end of the file.
+	if st := readAt(69998, 16); st != 0 || c.Read16(0xFF13) != 2 {
+		t.Errorf("tail read: status %d, length %d, expected 0, 2", st, c.Read16(0xFF13))
+	}
+
+	// Past EOF: success, nothing copied.
+	c.Memory[0x2000] = 0xEE
+	if st := readAt(80000, 16); st != 0 || c.Read16(0xFF13) != 0 || c.Memory[0x2000] != 0xEE {
+		t.Errorf("past EOF: status %d, length %d, buf[0] 0x%02X", st, c.Read16(0xFF13), c.Memory[0x2000])
+	}
+
+	// Buffer running off the end of RAM.
+	c.Write16(0xFF12, 0xFFF0)
+	if st := readAt(0, 32); st != 4 {
+		t.Errorf("out of bounds: status %d, expected 4", st)
+	}
+}
+
+func TestVFS_Append(t *testing.T) {
+	c := NewCPU()
+	copy(c.Memory[0x1000:], "log.txt\x00")
+	c.Write16(0xFF11, 0x1000)
+	c.Write16(0xFF12, 0x2000)
+
+	for _, chunk := range []string{"boot ", "ok"} {
+		copy(c.Memory[0x2000:], chunk)
+		c.Write16(0xFF13, uint16(len(chunk)))
+		c.WriteMem(0xFF10, 11)
+		if st := c.Read16(0xFF14); st != 0 {
+			t.Fatalf("append %q: status %d", chunk, st)
+		}
+	}
+	if data, _ := c.Disk.Read("log.txt"); string(data) != "boot ok" {
+		t.Errorf("log.txt = %q, expected %q", data, "boot ok")
+	}
+}
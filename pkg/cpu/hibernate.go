@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,6 +14,24 @@ import (
 	"gocpu/pkg/vfs"
 )
 
+// HibernateMagic identifies a hibernation archive in its version.json entry.
+const HibernateMagic = "SICPU-HIBERNATE"
+
+// HibernateVersion is the archive format version written by HibernateToBytes.
+// Archives without a version.json entry predate it and are treated as
+// version 0.
+const HibernateVersion = 1
+
+// ErrHibernateVersion is returned (wrapped) by RestoreFromBytes for an
+// archive whose format version is newer than HibernateVersion.
+var ErrHibernateVersion = errors.New("unsupported hibernation format version")
+
+// hibernateHeader is the contents of an archive's version.json entry.
+type hibernateHeader struct {
+	Magic   string `json:"magic"`
+	Version int    `json:"version"`
+}
+
 // humanReadableState is the JSON-serializable snapshot of CPU control state.
 type humanReadableState struct {
 	Regs               [8]uint16      `json:"regs"`
@@ -21,22 +40,49 @@ type humanReadableState struct {
 	Z                  bool           `json:"z"`
 	N                  bool           `json:"n"`
 	C                  bool           `json:"c"`
+	V                  bool           `json:"v"`
 	IE                 bool           `json:"ie"`
 	Waiting            bool           `json:"waiting"`
 	Halted             bool           `json:"halted"`
 	InterruptPending   bool           `json:"interrupt_pending"`
+	InterruptCause     uint16         `json:"interrupt_cause"`
+	PendingLevels      uint16         `json:"pending_levels"`
+	InterruptLevels    []int          `json:"interrupt_levels"`
 	CallDepth          int            `json:"call_depth"`
 	PeripheralIntMask  uint16         `json:"peripheral_int_mask"`
 	GraphicsEnabled    bool           `json:"graphics_enabled"`
 	TextOverlay        bool           `json:"text_overlay"`
 	BufferedMode       bool           `json:"buffered_mode"`
 	ColorMode8bpp      bool           `json:"color_mode_8bpp"`
+	GfxWidth           uint16         `json:"gfx_width,omitempty"`
+	GfxHeight          uint16         `json:"gfx_height,omitempty"`
 	TextResolutionMode uint16         `json:"text_resolution_mode"`
+	GraphicsBanks      int            `json:"graphics_banks"`
 	CurrentBank        uint16         `json:"current_bank"`
 	DisplayBank        uint16         `json:"display_bank"`
 	Palette            [256]uint16    `json:"palette"`
 	PaletteIndex       uint16         `json:"palette_index"`
 	MountedPeripherals map[int]string `json:"mounted_peripherals"`
+	WatchdogTimeout    uint16         `json:"watchdog_timeout"`
+	WatchdogCounter    uint16         `json:"watchdog_counter"`
+	WatchdogResets     uint16         `json:"watchdog_resets"`
+	MailboxAddr        uint16         `json:"mailbox_addr"`
+	MailboxSize        uint16         `json:"mailbox_size"`
+	MailboxReady       bool           `json:"mailbox_ready"`
+	TimerReload        uint16         `json:"timer_reload"`
+	TimerCounter       uint16         `json:"timer_counter"`
+	TimerEnabled       bool           `json:"timer_enabled"`
+	TimerExpired       bool           `json:"timer_expired"`
+	Cycles             uint64         `json:"cycles"`
+	VFSNamePtr         uint16         `json:"vfs_name_ptr"`
+	VFSBufPtr          uint16         `json:"vfs_buf_ptr"`
+	VFSLength          uint16         `json:"vfs_length"`
+	VFSStatus          uint16         `json:"vfs_status"`
+	VFSFreeHigh        uint16         `json:"vfs_free_high"`
+	VFSOffset          uint32         `json:"vfs_offset"`
+	VFSDirKeys         []string       `json:"vfs_dir_keys"`
+	VFSDirIndex        int            `json:"vfs_dir_index"`
+	KeyBuffer          []uint16       `json:"key_buffer"`
 }
 
 // vfsFileDescriptor holds per-file metadata for the VFS snapshot.
@@ -55,9 +101,19 @@ type vfsMetadata struct {
 // HibernateToBytes serialises the complete VM state into an in-memory ZIP archive
 // and returns the raw bytes.
 func (c *CPU) HibernateToBytes() ([]byte, error) {
+	c.ensureBanks()
 	buf := new(bytes.Buffer)
 	zw := zip.NewWriter(buf)
 
+	//  0. version.json
+	header, err := json.Marshal(hibernateHeader{Magic: HibernateMagic, Version: HibernateVersion})
+	if err != nil {
+		return nil, fmt.Errorf("marshal version: %w", err)
+	}
+	if err := writeZipEntry(zw, "version.json", header); err != nil {
+		return nil, err
+	}
+
 	//  1. cpu_state.json
 	state := humanReadableState{
 		Regs:               c.Regs,
@@ -66,22 +122,49 @@ func (c *CPU) HibernateToBytes() ([]byte, error) {
 		Z:                  c.Z,
 		N:                  c.N,
 		C:                  c.C,
+		V:                  c.V,
 		IE:                 c.IE,
 		Waiting:            c.Waiting,
 		Halted:             c.Halted,
 		InterruptPending:   c.InterruptPending,
+		InterruptCause:     c.interruptCause,
+		PendingLevels:      c.pendingLevels,
+		InterruptLevels:    c.interruptLevels,
 		CallDepth:          c.CallDepth,
 		PeripheralIntMask:  c.PeripheralIntMask,
 		GraphicsEnabled:    c.GraphicsEnabled,
 		TextOverlay:        c.TextOverlay,
 		BufferedMode:       c.BufferedMode,
 		ColorMode8bpp:      c.ColorMode8bpp,
+		GfxWidth:           c.gfxWidth,
+		GfxHeight:          c.gfxHeight,
 		TextResolutionMode: c.TextResolutionMode,
+		GraphicsBanks:      len(c.GraphicsBanks),
 		CurrentBank:        c.CurrentBank,
 		DisplayBank:        c.DisplayBank,
 		Palette:            c.Palette,
 		PaletteIndex:       c.PaletteIndex,
 		MountedPeripherals: make(map[int]string),
+		WatchdogTimeout:    c.WatchdogTimeout,
+		WatchdogCounter:    c.watchdogCounter,
+		WatchdogResets:     c.WatchdogResets,
+		MailboxAddr:        c.mailboxAddr,
+		MailboxSize:        c.mailboxSize,
+		MailboxReady:       c.mailboxReady,
+		TimerReload:        c.timerReload,
+		TimerCounter:       c.timerCounter,
+		TimerEnabled:       c.timerEnabled,
+		TimerExpired:       c.timerExpired,
+		Cycles:             c.Cycles,
+		VFSNamePtr:         c.vfsNamePtr,
+		VFSBufPtr:          c.vfsBufPtr,
+		VFSLength:          c.vfsLength,
+		VFSStatus:          c.vfsStatus,
+		VFSFreeHigh:        c.vfsFreeHigh,
+		VFSOffset:          c.vfsOffset,
+		VFSDirKeys:         c.VfsDirKeys,
+		VFSDirIndex:        c.VfsDirIndex,
+		KeyBuffer:          append([]uint16{}, c.KeyBuffer...), // [] rather than null when empty
 	}
 
 	for i, p := range c.Peripherals {
@@ -104,7 +187,7 @@ func (c *CPU) HibernateToBytes() ([]byte, error) {
 	}
 
 	//  3. Graphics banks
-	for i := 0; i < 4; i++ {
+	for i := range c.GraphicsBanks {
 		if err := writeZipEntry(zw, fmt.Sprintf("graphics_bank_%d.bin", i), c.GraphicsBanks[i][:]); err != nil {
 			return nil, err
 		}
@@ -189,6 +272,11 @@ func (c *CPU) RestoreFromBytes(data []byte) error {
 		fileMap[f.Name] = f
 	}
 
+	//  0. version.json
+	if err := checkHibernateHeader(fileMap); err != nil {
+		return err
+	}
+
 	//  1. cpu_state.json
 	jsonData, err := readZipEntry(fileMap, "cpu_state.json")
 	if err != nil {
@@ -198,6 +286,19 @@ func (c *CPU) RestoreFromBytes(data []byte) error {
 	if err := json.Unmarshal(jsonData, &state); err != nil {
 		return fmt.Errorf("unmarshal cpu_state: %w", err)
 	}
+	banks := state.GraphicsBanks
+	if banks == 0 {
+		banks = DefaultGraphicsBanks // snapshots that predate a configurable bank count
+	}
+	if !validBankCount(banks) {
+		return fmt.Errorf("invalid graphics bank count %d", banks)
+	}
+	if int(state.CurrentBank) >= banks {
+		return fmt.Errorf("current graphics bank %d out of range (%d banks)", state.CurrentBank, banks)
+	}
+	if int(state.DisplayBank) >= banks {
+		return fmt.Errorf("display graphics bank %d out of range (%d banks)", state.DisplayBank, banks)
+	}
 
 	c.Regs = state.Regs
 	c.PC = state.PC
@@ -205,21 +306,57 @@ func (c *CPU) RestoreFromBytes(data []byte) error {
 	c.Z = state.Z
 	c.N = state.N
 	c.C = state.C
+	c.V = state.V
 	c.IE = state.IE
 	c.Waiting = state.Waiting
 	c.Halted = state.Halted
 	c.InterruptPending = state.InterruptPending
+	c.interruptCause = state.InterruptCause
+	c.pendingLevels = state.PendingLevels
+	c.interruptLevels = state.InterruptLevels
 	c.CallDepth = state.CallDepth
 	c.PeripheralIntMask = state.PeripheralIntMask
 	c.GraphicsEnabled = state.GraphicsEnabled
 	c.TextOverlay = state.TextOverlay
 	c.BufferedMode = state.BufferedMode
 	c.ColorMode8bpp = state.ColorMode8bpp
+	c.gfxWidth = state.GfxWidth
+	c.gfxHeight = state.GfxHeight
 	c.TextResolutionMode = state.TextResolutionMode
 	c.CurrentBank = state.CurrentBank
 	c.DisplayBank = state.DisplayBank
 	c.Palette = state.Palette
 	c.PaletteIndex = state.PaletteIndex
+	c.WatchdogTimeout = state.WatchdogTimeout
+	c.watchdogCounter = state.WatchdogCounter
+	c.WatchdogResets = state.WatchdogResets
+	c.mailboxAddr = state.MailboxAddr
+	c.mailboxSize = state.MailboxSize
+	c.mailboxReady = state.MailboxReady
+	c.timerReload = state.TimerReload
+	c.timerCounter = state.TimerCounter
+	c.timerEnabled = state.TimerEnabled
+	c.timerExpired = state.TimerExpired
+	c.Cycles = state.Cycles
+	// An empty queue restores as nil, the same as on a fresh CPU.
+	c.KeyBuffer = nil
+	if len(state.KeyBuffer) > 0 {
+		c.KeyBuffer = state.KeyBuffer
+	}
+	c.vfsNamePtr = state.VFSNamePtr
+	c.vfsBufPtr = state.VFSBufPtr
+	c.vfsLength = state.VFSLength
+	c.vfsStatus = state.VFSStatus
+	c.vfsFreeHigh = state.VFSFreeHigh
+	c.vfsOffset = state.VFSOffset
+	// An index outside the saved listing (or one without a listing) would
+	// make the next List command misbehave, so start the listing over.
+	c.VfsDirKeys = state.VFSDirKeys
+	c.VfsDirIndex = state.VFSDirIndex
+	if c.VfsDirKeys == nil || c.VfsDirIndex < 0 || c.VfsDirIndex > len(c.VfsDirKeys) {
+		c.VfsDirKeys = nil
+		c.VfsDirIndex = 0
+	}
 
 	//  2. memory.bin
 	if memData, err := readZipEntry(fileMap, "memory.bin"); err == nil {
@@ -227,7 +364,9 @@ func (c *CPU) RestoreFromBytes(data []byte) error {
 	}
 
 	//  3. Graphics banks
-	for i := 0; i < 4; i++ {
+	c.GraphicsBanks = make([][16384]byte, banks)
+	c.GraphicsBanksFront = make([][16384]byte, banks)
+	for i := range c.GraphicsBanks {
 		if d, err := readZipEntry(fileMap, fmt.Sprintf("graphics_bank_%d.bin", i)); err == nil {
 			copy(c.GraphicsBanks[i][:], d)
 		}
@@ -325,8 +464,34 @@ func (c *CPU) RestoreFromFile(path string) error {
 
 //  helpers
 
+// checkHibernateHeader validates the archive's version.json entry. A missing
+// entry is a version 0 archive, which is still accepted.
+func checkHibernateHeader(fileMap map[string]*zip.File) error {
+	if _, ok := fileMap["version.json"]; !ok {
+		return nil
+	}
+	data, err := readZipEntry(fileMap, "version.json")
+	if err != nil {
+		return err
+	}
+	var header hibernateHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return fmt.Errorf("unmarshal version: %w", err)
+	}
+	if header.Magic != HibernateMagic {
+		return fmt.Errorf("not a hibernation archive: magic %q, expected %q", header.Magic, HibernateMagic)
+	}
+	if header.Version > HibernateVersion {
+		return fmt.Errorf("%w: archive is version %d, this build supports up to %d", ErrHibernateVersion, header.Version, HibernateVersion)
+	}
+	return nil
+}
+
+// writeZipEntry writes data as a Deflate-compressed entry. Memory and the
+// graphics banks are mostly zeros, so this keeps snapshots small; the zip
+// reader decompresses transparently.
 func writeZipEntry(zw *zip.Writer, name string, data []byte) error {
-	w, err := zw.Create(name)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
 	if err != nil {
 		return fmt.Errorf("create zip entry %q: %w", name, err)
 	}
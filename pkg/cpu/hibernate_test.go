@@ -1,7 +1,14 @@
 package cpu
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -162,6 +169,65 @@ func TestCPU_HibernateMemory(t *testing.T) {
 	}
 }
 
+func TestCPU_HibernateGraphicsBanks(t *testing.T) {
+	c1, err := NewCPUWithOptions(Options{GraphicsBanks: 8})
+	if err != nil {
+		t.Fatalf("NewCPUWithOptions: %v", err)
+	}
+	c1.GraphicsBanks[5][0x20] = 0x77
+	c1.GraphicsBanksFront[7][0x21] = 0x88
+	c1.CurrentBank = 5
+
+	data, err := c1.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+
+	c2 := NewCPU()
+	if err := c2.RestoreFromBytes(data); err != nil {
+		t.Fatalf("RestoreFromBytes: %v", err)
+	}
+	if len(c2.GraphicsBanks) != 8 || len(c2.GraphicsBanksFront) != 8 {
+		t.Fatalf("expected 8 banks after restore, got %d/%d", len(c2.GraphicsBanks), len(c2.GraphicsBanksFront))
+	}
+	if c2.GraphicsBanks[5][0x20] != 0x77 || c2.GraphicsBanksFront[7][0x21] != 0x88 {
+		t.Errorf("bank contents mismatch: %02X %02X", c2.GraphicsBanks[5][0x20], c2.GraphicsBanksFront[7][0x21])
+	}
+	if c2.CurrentBank != 5 {
+		t.Errorf("CurrentBank: got %d, want 5", c2.CurrentBank)
+	}
+}
+
+func TestCPU_HibernateBankOutOfRange(t *testing.T) {
+	data, err := NewCPU().HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+	for _, field := range []string{"current_bank", "display_bank"} {
+		bad := rewriteArchive(t, data, func(name string, body []byte) []byte {
+			if name != "cpu_state.json" {
+				return body
+			}
+			var state map[string]any
+			if err := json.Unmarshal(body, &state); err != nil {
+				t.Fatalf("unmarshal cpu_state: %v", err)
+			}
+			state[field] = state["graphics_banks"]
+			out, _ := json.Marshal(state)
+			return out
+		})
+
+		c := NewCPU()
+		c.Regs[0] = 0x5555
+		if err := c.RestoreFromBytes(bad); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("%s: RestoreFromBytes error = %v, want out of range", field, err)
+		}
+		if c.Regs[0] != 0x5555 {
+			t.Errorf("%s: CPU state changed by rejected archive", field)
+		}
+	}
+}
+
 func TestCPU_HibernateVFS(t *testing.T) {
 	c1 := NewCPU()
 
@@ -316,3 +382,272 @@ func TestCPU_HibernateAndResume(t *testing.T) {
 		t.Errorf("PC mismatch: c1=0x%04X c2=0x%04X", c1.PC, c2.PC)
 	}
 }
+
+func TestCPU_HibernateVFSRegisters(t *testing.T) {
+	c1 := NewCPU()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		c1.Disk.Write(name, []byte{1})
+	}
+	c1.Write16(0xFF12, 0x2000)
+	c1.WriteMem(0xFF10, 5) // List: returns a.txt, leaves the listing half read
+	if c1.Read16(0xFF14) != 0 || c1.VfsDirIndex != 1 {
+		t.Fatalf("List: status %d, index %d", c1.Read16(0xFF14), c1.VfsDirIndex)
+	}
+	c1.Write16(0xFF11, 0x1000)
+	c1.Write16(0xFF13, 0x0123)
+	c1.Write16(0xFF15, 0x0004)
+	c1.Write16(VFSOffsetLoReg, 0x5678)
+	c1.Write16(VFSOffsetHiReg, 0x0001)
+
+	data, err := c1.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+	c2 := NewCPU()
+	if err := c2.RestoreFromBytes(data); err != nil {
+		t.Fatalf("RestoreFromBytes: %v", err)
+	}
+
+	for _, reg := range []uint16{0xFF11, 0xFF12, 0xFF13, 0xFF14, 0xFF15, VFSOffsetLoReg, VFSOffsetHiReg} {
+		if got, want := c2.Read16(reg), c1.Read16(reg); got != want {
+			t.Errorf("0x%04X: got 0x%04X, want 0x%04X", reg, got, want)
+		}
+	}
+
+	// The listing carries on where it stopped.
+	var names []string
+	for {
+		c2.WriteMem(0xFF10, 5)
+		if c2.Read16(0xFF14) != 0 {
+			break
+		}
+		name, _ := c2.ReadStringFromRAM(0x2000)
+		names = append(names, name)
+	}
+	if len(names) != 2 || names[0] != "b.txt" || names[1] != "c.txt" {
+		t.Errorf("resumed listing = %v, want [b.txt c.txt]", names)
+	}
+}
+
+func TestCPU_HibernateVFSDirIndexOutOfRange(t *testing.T) {
+	c1 := NewCPU()
+	c1.Disk.Write("a.txt", []byte{1})
+	c1.VfsDirKeys = []string{"a.txt"}
+	c1.VfsDirIndex = 5
+
+	data, err := c1.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+	c2 := NewCPU()
+	if err := c2.RestoreFromBytes(data); err != nil {
+		t.Fatalf("RestoreFromBytes: %v", err)
+	}
+	if c2.VfsDirKeys != nil || c2.VfsDirIndex != 0 {
+		t.Errorf("listing not reset: keys %v, index %d", c2.VfsDirKeys, c2.VfsDirIndex)
+	}
+}
+
+func TestCPU_HibernateKeyBuffer(t *testing.T) {
+	c1 := NewCPU()
+	for _, k := range []uint16{'a', 'b', 0x0100} {
+		c1.PushKey(k)
+	}
+	data, err := c1.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+	c2 := NewCPU()
+	if err := c2.RestoreFromBytes(data); err != nil {
+		t.Fatalf("RestoreFromBytes: %v", err)
+	}
+	for _, want := range []uint16{'a', 'b', 0x0100, 0} {
+		if got := c2.Read16(0xFF04); got != want {
+			t.Errorf("key: got 0x%04X, want 0x%04X", got, want)
+		}
+	}
+
+	// An empty buffer restores as empty, like a fresh CPU's.
+	empty := NewCPU()
+	data, err = empty.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+	c3 := NewCPU()
+	c3.PushKey('x')
+	if err := c3.RestoreFromBytes(data); err != nil {
+		t.Fatalf("RestoreFromBytes: %v", err)
+	}
+	if !reflect.DeepEqual(c3.KeyBuffer, empty.KeyBuffer) {
+		t.Errorf("KeyBuffer = %#v, want %#v", c3.KeyBuffer, empty.KeyBuffer)
+	}
+}
+
+// rewriteArchive copies a hibernation archive, passing each entry through
+// edit. Entries for which edit returns nil are dropped.
+func rewriteArchive(t *testing.T, data []byte, edit func(name string, body []byte) []byte) []byte {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		body, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		if body = edit(f.Name, body); body == nil {
+			continue
+		}
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatalf("create %s: %v", f.Name, err)
+		}
+		w.Write(body)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCPU_HibernateVersion(t *testing.T) {
+	c1 := NewCPU()
+	c1.Regs[0] = 0x1234
+	data, err := c1.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+
+	var header []byte
+	rewriteArchive(t, data, func(name string, body []byte) []byte {
+		if name == "version.json" {
+			header = body
+		}
+		return body
+	})
+	want := `{"magic":"SICPU-HIBERNATE","version":1}`
+	if string(header) != want {
+		t.Errorf("version.json = %s, want %s", header, want)
+	}
+
+	c2 := NewCPU()
+	if err := c2.RestoreFromBytes(data); err != nil {
+		t.Fatalf("RestoreFromBytes: %v", err)
+	}
+	if c2.Regs[0] != 0x1234 {
+		t.Errorf("R0 = 0x%04X, want 0x1234", c2.Regs[0])
+	}
+}
+
+func TestCPU_HibernateVersionTooNew(t *testing.T) {
+	c1 := NewCPU()
+	data, err := c1.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+	newer := rewriteArchive(t, data, func(name string, body []byte) []byte {
+		if name == "version.json" {
+			return []byte(`{"magic":"SICPU-HIBERNATE","version":99}`)
+		}
+		return body
+	})
+
+	c2 := NewCPU()
+	c2.Regs[0] = 0x5555
+	err = c2.RestoreFromBytes(newer)
+	if !errors.Is(err, ErrHibernateVersion) {
+		t.Fatalf("RestoreFromBytes error = %v, want ErrHibernateVersion", err)
+	}
+	if !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("error %q does not name the archive version", err)
+	}
+	if c2.Regs[0] != 0x5555 {
+		t.Errorf("CPU state changed by rejected archive")
+	}
+}
+
+func TestCPU_HibernateVersionBadMagic(t *testing.T) {
+	c1 := NewCPU()
+	data, err := c1.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+	bad := rewriteArchive(t, data, func(name string, body []byte) []byte {
+		if name == "version.json" {
+			return []byte(`{"magic":"SOMETHING-ELSE","version":1}`)
+		}
+		return body
+	})
+	if err := NewCPU().RestoreFromBytes(bad); err == nil || !strings.Contains(err.Error(), "magic") {
+		t.Errorf("RestoreFromBytes error = %v, want a magic mismatch", err)
+	}
+}
+
+func TestCPU_HibernateLegacyArchive(t *testing.T) {
+	c1 := NewCPU()
+	c1.Regs[3] = 0xBEEF
+	c1.Memory[0x2000] = 0x42
+	data, err := c1.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+	legacy := rewriteArchive(t, data, func(name string, body []byte) []byte {
+		if name == "version.json" {
+			return nil
+		}
+		return body
+	})
+
+	c2 := NewCPU()
+	if err := c2.RestoreFromBytes(legacy); err != nil {
+		t.Fatalf("RestoreFromBytes(legacy): %v", err)
+	}
+	if c2.Regs[3] != 0xBEEF || c2.Memory[0x2000] != 0x42 {
+		t.Errorf("legacy restore: R3 = 0x%04X, mem = 0x%02X", c2.Regs[3], c2.Memory[0x2000])
+	}
+}
+
+func TestCPU_HibernateCompressed(t *testing.T) {
+	c1 := NewCPU()
+	copy(c1.Memory[0x1000:], []byte("mostly zeros"))
+	c1.GraphicsBanks[1][0x10] = 0x5A
+	data, err := c1.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+
+	// Uncompressed, memory alone would be 64 KB and the banks 128 KB more.
+	if len(data) > 16*1024 {
+		t.Errorf("archive is %d bytes, expected it to be compressed", len(data))
+	}
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	for _, f := range r.File {
+		if f.Name == "memory.bin" || strings.HasPrefix(f.Name, "graphics_bank_") {
+			if f.Method != zip.Deflate {
+				t.Errorf("%s stored with method %d, want Deflate", f.Name, f.Method)
+			}
+		}
+	}
+
+	c2 := NewCPU()
+	if err := c2.RestoreFromBytes(data); err != nil {
+		t.Fatalf("RestoreFromBytes: %v", err)
+	}
+	if c2.Memory != c1.Memory {
+		t.Error("memory differs after restore")
+	}
+	if c2.GraphicsBanks[1] != c1.GraphicsBanks[1] {
+		t.Error("graphics bank 1 differs after restore")
+	}
+}
@@ -0,0 +1,125 @@
+package cpu
+
+// InterruptCauseReg reads which sources have raised an interrupt, one bit
+// per source. Writing a value clears (acknowledges) the sources whose bits
+// are set in it.
+const InterruptCauseReg uint16 = 0xFF0A
+
+// InterruptCauseReg bits.
+const (
+	// IntCauseKeyboard is set by PushKey.
+	IntCauseKeyboard uint16 = 1 << 0
+	// IntCauseTimer mirrors TimerExpired in TimerControlReg.
+	IntCauseTimer uint16 = 1 << 1
+	// IntCauseMailbox is set while a mailbox message is waiting;
+	// acknowledging it is the same as writing MailboxStatusReg.
+	IntCauseMailbox uint16 = 1 << 2
+	// IntCausePeripheral is set while any bit of PeripheralIntMask (0xFF09)
+	// is set; that register says which slots raised it. Acknowledging it
+	// clears the whole mask.
+	IntCausePeripheral uint16 = 1 << 3
+	// IntCauseSoftware is set by TriggerInterrupt.
+	IntCauseSoftware uint16 = 1 << 4
+)
+
+// interruptPriority returns the priority level of an interrupt source, from
+// 1 (lowest) to 4. A handler only gets preempted, after it re-enables
+// interrupts with EI, by a source of a higher level.
+func interruptPriority(cause uint16) int {
+	switch cause {
+	case IntCauseTimer:
+		return 4
+	case IntCausePeripheral:
+		return 3
+	case IntCauseMailbox:
+		return 2
+	default: // keyboard, software
+		return 1
+	}
+}
+
+// TriggerInterrupt raises a software interrupt from the host.
+func (c *CPU) TriggerInterrupt() {
+	c.interruptCause |= IntCauseSoftware
+	c.requestInterrupt(IntCauseSoftware)
+}
+
+// requestInterrupt marks an interrupt from cause pending at that source's
+// priority. The caller records its own cause bit first.
+func (c *CPU) requestInterrupt(cause uint16) {
+	c.pendingLevels |= 1 << interruptPriority(cause)
+	c.InterruptPending = true
+}
+
+// interruptLevel returns the priority of the running handler, or 0 outside
+// any handler.
+func (c *CPU) interruptLevel() int {
+	if n := len(c.interruptLevels); n > 0 {
+		return c.interruptLevels[n-1]
+	}
+	return 0
+}
+
+// pendingLevel returns the highest pending priority. An InterruptPending set
+// directly, without a recorded source, counts as the lowest level.
+func (c *CPU) pendingLevel() int {
+	for level := 4; level > 0; level-- {
+		if c.pendingLevels&(1<<level) != 0 {
+			return level
+		}
+	}
+	return 1
+}
+
+// dispatchInterrupt enters the handler at 0x0010 for the highest pending
+// interrupt, if interrupts are enabled and it outranks the running handler.
+// Lower pending levels stay pending until the handlers above them return.
+func (c *CPU) dispatchInterrupt() {
+	if !c.InterruptPending || !c.IE {
+		return
+	}
+	level := c.pendingLevel()
+	if level <= c.interruptLevel() {
+		return
+	}
+	if c.stackOverflow(c.PC) {
+		return
+	}
+	c.pendingLevels &^= 1 << level
+	c.InterruptPending = c.pendingLevels != 0
+	c.interruptLevels = append(c.interruptLevels, level)
+	c.IE = false
+	c.Waiting = false
+	c.SP -= 2
+	c.Write16(c.SP, c.PC)
+	c.PC = 0x0010
+}
+
+// interruptCauses returns the value of InterruptCauseReg. The keyboard and
+// software bits are latched; the others reflect their device's state.
+func (c *CPU) interruptCauses() uint16 {
+	v := c.interruptCause
+	if c.timerExpired {
+		v |= IntCauseTimer
+	}
+	if c.mailboxReady {
+		v |= IntCauseMailbox
+	}
+	if c.PeripheralIntMask != 0 {
+		v |= IntCausePeripheral
+	}
+	return v
+}
+
+func (c *CPU) acknowledgeInterrupts(val uint16) {
+	c.interruptCause &^= val
+	if val&IntCauseTimer != 0 {
+		c.timerExpired = false
+	}
+	if val&IntCauseMailbox != 0 {
+		c.mailboxReady = false
+	}
+	if val&IntCausePeripheral != 0 {
+		c.PeripheralIntMask = 0
+	}
+}
@@ -0,0 +1,233 @@
+package cpu
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestInterruptCause_Keyboard(t *testing.T) {
+	c := NewCPU()
+	c.PushKey('a')
+	if !c.InterruptPending {
+		t.Fatal("PushKey did not raise an interrupt")
+	}
+	if got := c.Read16(InterruptCauseReg); got != IntCauseKeyboard {
+		t.Errorf("cause = 0x%04X, want only the keyboard bit (0x%04X)", got, IntCauseKeyboard)
+	}
+
+	c.Write16(InterruptCauseReg, IntCauseKeyboard)
+	if got := c.Read16(InterruptCauseReg); got != 0 {
+		t.Errorf("cause after acknowledge = 0x%04X, want 0", got)
+	}
+	if len(c.KeyBuffer) != 1 {
+		t.Errorf("acknowledging dropped the key: buffer %v", c.KeyBuffer)
+	}
+}
+
+func TestInterruptCause_Sources(t *testing.T) {
+	c := NewCPU()
+	c.TriggerInterrupt()
+	c.TriggerPeripheralInterrupt(3)
+	c.mailboxSize = 16
+	if err := c.PostMessage([]byte("hi")); err != nil {
+		t.Fatalf("PostMessage: %v", err)
+	}
+	c.Write16(TimerReloadReg, 1)
+	c.Write16(TimerControlReg, TimerEnable)
+	c.tickTimer()
+
+	want := IntCauseSoftware | IntCausePeripheral | IntCauseMailbox | IntCauseTimer
+	if got := c.Read16(InterruptCauseReg); got != want {
+		t.Fatalf("cause = 0x%04X, want 0x%04X", got, want)
+	}
+
+	// Acknowledging a source clears it at the device too.
+	c.Write16(InterruptCauseReg, IntCauseTimer|IntCausePeripheral|IntCauseMailbox)
+	if got := c.Read16(InterruptCauseReg); got != IntCauseSoftware {
+		t.Errorf("cause = 0x%04X, want 0x%04X", got, IntCauseSoftware)
+	}
+	if c.timerExpired || c.PeripheralIntMask != 0 || c.MailboxReady() {
+		t.Errorf("device state not acknowledged: timer %v, mask 0x%04X, mailbox %v",
+			c.timerExpired, c.PeripheralIntMask, c.MailboxReady())
+	}
+}
+
+// nestedProgram loads a main loop at 0x0100 and an ISR at 0x0010 that
+// re-enables interrupts straight away: EI; NOP; RETI.
+func nestedProgram(c *CPU) {
+	loadProgram(c,
+		EncodeInstruction(OpJMP, 0, 0, 0), 0x0100,
+	)
+	c.Write16(0x0010, EncodeInstruction(OpEI, 0, 0, 0))
+	c.Write16(0x0012, EncodeInstruction(OpNOP, 0, 0, 0))
+	c.Write16(0x0014, EncodeInstruction(OpRETI, 0, 0, 0))
+	c.Write16(0x0100, EncodeInstruction(OpJMP, 0, 0, 0)) // loop: JMP loop
+	c.Write16(0x0102, 0x0100)
+	c.PC = 0x0100
+	c.IE = true
+}
+
+func TestInterrupt_HigherPriorityPreempts(t *testing.T) {
+	c := NewCPU()
+	nestedProgram(c)
+	sp := c.SP
+
+	c.PushKey('a') // priority 1
+	c.Step()       // dispatch, EI
+	if c.PC != 0x0012 || c.interruptLevel() != 1 {
+		t.Fatalf("keyboard handler: PC = 0x%04X, level %d", c.PC, c.interruptLevel())
+	}
+
+	c.TriggerPeripheralInterrupt(0) // priority 3 preempts
+	c.Step()                        // dispatch, EI
+	if c.PC != 0x0012 || c.interruptLevel() != 3 {
+		t.Fatalf("peripheral handler: PC = 0x%04X, level %d", c.PC, c.interruptLevel())
+	}
+	if got := c.Read16(c.SP); got != 0x0012 {
+		t.Errorf("preempted handler's return address = 0x%04X, want 0x0012", got)
+	}
+
+	c.PushKey('b') // priority 1 must wait
+	c.Step()       // NOP
+	if c.PC != 0x0014 || c.interruptLevel() != 3 {
+		t.Fatalf("lower priority preempted: PC = 0x%04X, level %d", c.PC, c.interruptLevel())
+	}
+
+	c.Step() // RETI back into the keyboard handler
+	if c.PC != 0x0012 || c.interruptLevel() != 1 || !c.IE {
+		t.Fatalf("after inner RETI: PC = 0x%04X, level %d, IE %v", c.PC, c.interruptLevel(), c.IE)
+	}
+	c.Step() // NOP; the pending key is still at the running level
+	c.Step() // RETI to main
+	if c.PC != 0x0100 || c.interruptLevel() != 0 || c.SP != sp {
+		t.Fatalf("after outer RETI: PC = 0x%04X, level %d, SP 0x%04X (want 0x%04X)", c.PC, c.interruptLevel(), c.SP, sp)
+	}
+
+	c.Step() // the deferred key is dispatched now
+	if c.PC != 0x0012 || c.interruptLevel() != 1 || c.InterruptPending {
+		t.Errorf("deferred interrupt: PC = 0x%04X, level %d, pending %v", c.PC, c.interruptLevel(), c.InterruptPending)
+	}
+}
+
+func TestInterrupt_NoPreemptionWithoutEI(t *testing.T) {
+	c := NewCPU()
+	nestedProgram(c)
+	c.Write16(0x0010, EncodeInstruction(OpNOP, 0, 0, 0)) // handler leaves IE off
+
+	c.PushKey('a')
+	c.Step()
+	c.Write16(TimerReloadReg, 1)
+	c.Write16(TimerControlReg, TimerEnable)
+	c.Step() // timer fires but IE is clear
+	if c.interruptLevel() != 1 || !c.InterruptPending {
+		t.Errorf("level %d, pending %v; want the timer held pending", c.interruptLevel(), c.InterruptPending)
+	}
+}
+
+func TestPushKey_Coalesce(t *testing.T) {
+	for _, tt := range []struct {
+		coalesce bool
+		want     uint16
+	}{
+		{false, 4},
+		{true, 1},
+	} {
+		c := NewCPU()
+		c.CoalesceKeyInterrupts = tt.coalesce
+		c.Regs[RegB] = 1
+		loadProgram(c,
+			EncodeInstruction(OpEI, 0, 0, 0),          // 0000
+			EncodeInstruction(OpJMP, 0, 0, 0), 0x0002, // 0002: spin
+		)
+		// Handler at 0x0010 counts interrupts in R3 but leaves the keys
+		// buffered.
+		w16(c, 0x0010, EncodeInstruction(OpADD, RegD, RegB, 0))
+		w16(c, 0x0012, EncodeInstruction(OpRETI, 0, 0, 0))
+
+		c.PushKey('a')
+		for i := 0; i < 20; i++ {
+			c.Step()
+		}
+		for _, k := range "bcd" {
+			c.PushKey(uint16(k))
+			for i := 0; i < 20; i++ {
+				c.Step()
+			}
+		}
+
+		if c.Regs[RegD] != tt.want {
+			t.Errorf("coalesce=%v: handler ran %d times, want %d", tt.coalesce, c.Regs[RegD], tt.want)
+		}
+		if len(c.KeyBuffer) != 4 {
+			t.Errorf("coalesce=%v: buffer holds %d keys, want 4", tt.coalesce, len(c.KeyBuffer))
+		}
+	}
+}
+
+// orderPeripheral appends its name to a shared log each time it steps.
+type orderPeripheral struct {
+	name string
+	log  *[]string
+}
+
+func (p *orderPeripheral) Read16(offset uint16) uint16       { return 0 }
+func (p *orderPeripheral) Write16(offset uint16, val uint16) {}
+func (p *orderPeripheral) Step()                             { *p.log = append(*p.log, p.name) }
+func (p *orderPeripheral) Type() string                      { return "Order" }
+
+func TestStepWithPeripheralHook_Order(t *testing.T) {
+	run := func() ([]string, []string) {
+		c := NewCPU()
+		c.Write16(0x0010, EncodeInstruction(OpNOP, 0, 0, 0))
+		c.Write16(0x0012, EncodeInstruction(OpRETI, 0, 0, 0))
+		c.Write16(0x0100, EncodeInstruction(OpJMP, 0, 0, 0))
+		c.Write16(0x0102, 0x0100)
+		c.PC = 0x0100
+		c.IE = true
+
+		var order []string
+		c.MountPeripheral(5, &orderPeripheral{"slot5", &order})
+		c.MountPeripheral(2, &orderPeripheral{"slot2", &order})
+
+		var trace []string
+		for i := 0; i < 5; i++ {
+			c.StepWithPeripheralHook(func() {
+				order = append(order, "hook")
+				switch i {
+				case 0: // keyboard and timer both become pending
+					c.PushKey('k')
+					c.Write16(TimerReloadReg, 1)
+					c.Write16(TimerControlReg, TimerEnable)
+				case 1:
+					c.Write16(TimerControlReg, 0)
+				}
+			})
+			trace = append(trace, fmt.Sprintf("PC=%04X level=%d", c.PC, c.interruptLevel()))
+		}
+		return order, trace
+	}
+
+	order, trace := run()
+	wantOrder := []string{"slot2", "slot5", "hook"}
+	for i := 0; i < len(order); i += 3 {
+		if !reflect.DeepEqual(order[i:i+3], wantOrder) {
+			t.Fatalf("step order = %v, expected %v per step", order, wantOrder)
+		}
+	}
+
+	wantTrace := []string{
+		"PC=0012 level=4", // timer dispatched first, handler NOP
+		"PC=0100 level=0", // RETI; keyboard waited behind the timer handler
+		"PC=0012 level=1", // keyboard dispatched, handler NOP
+		"PC=0100 level=0", // RETI
+		"PC=0100 level=0", // JMP loop
+	}
+	if !reflect.DeepEqual(trace, wantTrace) {
+		t.Errorf("trace = %q, expected %q", trace, wantTrace)
+	}
+
+	if _, again := run(); !reflect.DeepEqual(again, trace) {
+		t.Errorf("second run = %q, expected %q", again, trace)
+	}
+}
@@ -0,0 +1,62 @@
+package cpu
+
+import "errors"
+
+// Mailbox MMIO registers. The program points MailboxAddrReg at a RAM buffer
+// and writes its size in bytes to MailboxSizeReg. The host then delivers
+// messages into that buffer with PostMessage: a little-endian length word
+// followed by the message bytes. Bit 0 of MailboxStatusReg reads 1 while a
+// message is waiting; writing any value to it acknowledges the message.
+const (
+	MailboxStatusReg uint16 = 0xFF27
+	MailboxAddrReg   uint16 = 0xFF28
+	MailboxSizeReg   uint16 = 0xFF29
+)
+
+var (
+	// ErrMailboxNotConfigured is returned by PostMessage before the program
+	// has given the mailbox a buffer.
+	ErrMailboxNotConfigured = errors.New("mailbox buffer not configured")
+	// ErrMailboxBusy is returned by PostMessage while the previous message
+	// has not been acknowledged.
+	ErrMailboxBusy = errors.New("mailbox busy: previous message not acknowledged")
+	// ErrMessageTooLarge is returned by PostMessage when the message and its
+	// length prefix do not fit in the mailbox buffer.
+	ErrMessageTooLarge = errors.New("message too large for mailbox buffer")
+)
+
+// PostMessage delivers data to the program's mailbox buffer, sets the ready
+// bit and raises an interrupt. Nothing is written if an error is returned.
+func (c *CPU) PostMessage(data []byte) error {
+	if c.mailboxSize == 0 {
+		return ErrMailboxNotConfigured
+	}
+	if c.mailboxReady {
+		return ErrMailboxBusy
+	}
+	if len(data)+2 > int(c.mailboxSize) {
+		return ErrMessageTooLarge
+	}
+
+	addr := c.mailboxAddr
+	c.Write16(addr, uint16(len(data)))
+	for i, b := range data {
+		c.WriteByte(addr+2+uint16(i), b)
+	}
+	c.mailboxReady = true
+	c.requestInterrupt(IntCauseMailbox)
+	return nil
+}
+
+// MailboxReady reports whether a posted message is waiting to be
+// acknowledged by the program.
+func (c *CPU) MailboxReady() bool {
+	return c.mailboxReady
+}
+
+func (c *CPU) mailboxStatus() uint16 {
+	if c.mailboxReady {
+		return 1
+	}
+	return 0
+}
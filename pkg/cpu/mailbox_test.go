@@ -0,0 +1,85 @@
+package cpu
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMailbox_PostAndAcknowledge(t *testing.T) {
+	c := NewCPU()
+	loadProgram(c,
+		// Point the mailbox at a 16-byte buffer at 0x4000.
+		EncodeInstruction(OpLDI, 1, 0, 0), MailboxAddrReg,
+		EncodeInstruction(OpLDI, 0, 0, 0), 0x4000,
+		EncodeInstruction(OpST, 1, 0, 0),
+		EncodeInstruction(OpLDI, 1, 0, 0), MailboxSizeReg,
+		EncodeInstruction(OpLDI, 0, 0, 0), 16,
+		EncodeInstruction(OpST, 1, 0, 0),
+		EncodeInstruction(OpHLT, 0, 0, 0), // 0x0018: wait for the host
+		// 0x001A: read the status, the length and the first two bytes, then ack.
+		EncodeInstruction(OpLDI, 1, 0, 0), MailboxStatusReg,
+		EncodeInstruction(OpLD, 4, 1, 0),
+		EncodeInstruction(OpLDI, 1, 0, 0), 0x4000,
+		EncodeInstruction(OpLD, 2, 1, 0),
+		EncodeInstruction(OpLDI, 1, 0, 0), 0x4002,
+		EncodeInstruction(OpLDB, 3, 1, 0),
+		EncodeInstruction(OpLDI, 1, 0, 0), 0x4003,
+		EncodeInstruction(OpLDB, 5, 1, 0),
+		EncodeInstruction(OpLDI, 1, 0, 0), MailboxStatusReg,
+		EncodeInstruction(OpST, 1, 0, 0),
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+	c.Run()
+
+	if err := c.PostMessage([]byte("hi!")); err != nil {
+		t.Fatalf("PostMessage failed: %v", err)
+	}
+	if !c.MailboxReady() || c.Read16(MailboxStatusReg) != 1 {
+		t.Fatalf("ready bit not set after PostMessage")
+	}
+	if !c.InterruptPending {
+		t.Error("PostMessage should raise an interrupt")
+	}
+	if err := c.PostMessage([]byte("again")); !errors.Is(err, ErrMailboxBusy) {
+		t.Errorf("second PostMessage before ack: expected ErrMailboxBusy, got %v", err)
+	}
+
+	c.Halted = false
+	c.Run()
+
+	if c.Regs[4] != 1 {
+		t.Errorf("VM read status %d, want 1", c.Regs[4])
+	}
+	if c.Regs[2] != 3 {
+		t.Errorf("VM read length %d, want 3", c.Regs[2])
+	}
+	if c.Regs[3] != 'h' || c.Regs[5] != 'i' {
+		t.Errorf("VM read bytes %q %q, want 'h' 'i'", rune(c.Regs[3]), rune(c.Regs[5]))
+	}
+	if c.MailboxReady() || c.Read16(MailboxStatusReg) != 0 {
+		t.Error("ready bit should clear after the VM acknowledges")
+	}
+	if err := c.PostMessage([]byte("again")); err != nil {
+		t.Errorf("PostMessage after ack failed: %v", err)
+	}
+}
+
+func TestMailbox_Errors(t *testing.T) {
+	c := NewCPU()
+	if err := c.PostMessage([]byte("x")); !errors.Is(err, ErrMailboxNotConfigured) {
+		t.Errorf("expected ErrMailboxNotConfigured, got %v", err)
+	}
+
+	c.Write16(MailboxAddrReg, 0x4000)
+	c.Write16(MailboxSizeReg, 4)
+	c.Memory[0x4000] = 0xAA
+	if err := c.PostMessage([]byte("abc")); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("expected ErrMessageTooLarge, got %v", err)
+	}
+	if c.Memory[0x4000] != 0xAA || c.MailboxReady() {
+		t.Error("a rejected message must not touch the buffer or the ready bit")
+	}
+	if err := c.PostMessage([]byte("ab")); err != nil {
+		t.Errorf("message filling the buffer exactly should fit: %v", err)
+	}
+}
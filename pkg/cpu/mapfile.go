@@ -0,0 +1,84 @@
+package cpu
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrWriteProtected is the fault raised when a store targets a
+// write-protected address, such as a read-only MapFile region.
+var ErrWriteProtected = errors.New("write to protected memory")
+
+// baseRAMEnd is the first address past base RAM; graphics VRAM starts here.
+const baseRAMEnd = 0xB600
+
+// memRange is a half-open address range [start, end).
+type memRange struct {
+	start, end uint32
+}
+
+// WriteProtect makes the size bytes starting at addr read-only. Any later
+// write to them is dropped and faults the CPU with ErrWriteProtected; PC is
+// rewound so it points at the offending instruction. A VFS command whose
+// buffer overlaps the range writes nothing and sets status 7 (Protected).
+// Protection covers plain RAM only and is not saved by hibernation.
+func (c *CPU) WriteProtect(addr uint16, size int) {
+	if size <= 0 {
+		return
+	}
+	end := uint32(addr) + uint32(size)
+	if end > 0x10000 {
+		end = 0x10000
+	}
+	c.protected = append(c.protected, memRange{uint32(addr), end})
+}
+
+func (c *CPU) isWriteProtected(addr uint16) bool {
+	for _, r := range c.protected {
+		if uint32(addr) >= r.start && uint32(addr) < r.end {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeWriteProtected reports whether any of the n bytes starting at addr is
+// write-protected.
+func (c *CPU) rangeWriteProtected(addr uint16, n int) bool {
+	start, end := uint32(addr), uint32(addr)+uint32(n)
+	for _, r := range c.protected {
+		if start < r.end && r.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// writeProtectFault halts the CPU for a write to a protected address. Only
+// the first offending write of an instruction is reported.
+func (c *CPU) writeProtectFault(addr uint16) {
+	if c.Fault == nil {
+		c.Fault = fmt.Errorf("%w: address 0x%04X", ErrWriteProtected, addr)
+	}
+	c.Halted = true
+}
+
+// MapFile copies the contents of the host file at path into memory at addr,
+// so large read-only data does not have to go through the VFS. The file must
+// fit in base RAM (below 0xB600). With readOnly set, the mapped bytes are
+// write-protected (see WriteProtect).
+func (c *CPU) MapFile(addr uint16, path string, readOnly bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("mapping file: %w", err)
+	}
+	if int(addr)+len(data) > baseRAMEnd {
+		return fmt.Errorf("mapping %s: %d bytes at 0x%04X do not fit in base RAM", path, len(data), addr)
+	}
+	copy(c.Memory[addr:], data)
+	if readOnly {
+		c.WriteProtect(addr, len(data))
+	}
+	return nil
+}
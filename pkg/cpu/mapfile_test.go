@@ -0,0 +1,94 @@
+package cpu
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMapFile_ReadOnly(t *testing.T) {
+	c := NewCPU()
+	if err := c.MapFile(0x2000, "testdata/table.bin", true); err != nil {
+		t.Fatalf("MapFile: %v", err)
+	}
+	if got := c.ReadByte(0x2000); got != 'S' {
+		t.Errorf("ReadByte(0x2000) = 0x%02X, want 'S'", got)
+	}
+	if got := c.ReadByte(0x2007); got != 0x02 {
+		t.Errorf("ReadByte(0x2007) = 0x%02X, want 0x02", got)
+	}
+
+	loadProgram(c,
+		EncodeInstruction(OpLDI, 0, 0, 0), 0x2000, // LDI R0, 0x2000
+		EncodeInstruction(OpLDI, 1, 0, 0), 0x1234, // LDI R1, 0x1234
+		EncodeInstruction(OpST, 0, 1, 0), // ST [R0], R1
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+	c.Run()
+	if !errors.Is(c.Fault, ErrWriteProtected) {
+		t.Fatalf("Fault = %v, want ErrWriteProtected", c.Fault)
+	}
+	if c.PC != 0x0008 {
+		t.Errorf("PC = 0x%04X, want 0x0008 (the faulting ST)", c.PC)
+	}
+	if c.Memory[0x2000] != 'S' || c.Memory[0x2001] != 'I' {
+		t.Errorf("protected bytes changed to %02X %02X", c.Memory[0x2000], c.Memory[0x2001])
+	}
+	// Bytes past the end of the file stay writable.
+	c.Fault, c.Halted = nil, false
+	c.WriteByte(0x2008, 0xAA)
+	if c.Fault != nil || c.Memory[0x2008] != 0xAA {
+		t.Errorf("write past the map: Fault = %v, byte = 0x%02X", c.Fault, c.Memory[0x2008])
+	}
+}
+
+func TestMapFile_Writable(t *testing.T) {
+	c := NewCPU()
+	if err := c.MapFile(0x2000, "testdata/table.bin", false); err != nil {
+		t.Fatalf("MapFile: %v", err)
+	}
+	c.WriteByte(0x2000, 'X')
+	if c.Fault != nil || c.Memory[0x2000] != 'X' {
+		t.Errorf("write to writable map: Fault = %v, byte = 0x%02X", c.Fault, c.Memory[0x2000])
+	}
+
+	if err := c.MapFile(0xB5FC, "testdata/table.bin", true); err == nil {
+		t.Error("MapFile past base RAM: expected error")
+	}
+	if err := c.MapFile(0x2000, "testdata/missing.bin", true); err == nil {
+		t.Error("MapFile of missing file: expected error")
+	}
+}
+
+func TestWriteProtect_VFS(t *testing.T) {
+	c := NewCPU()
+	c.Disk.Write("data.bin", []byte{1, 2, 3, 4})
+	copy(c.Memory[0x1000:], "data.bin\x00")
+	c.WriteProtect(0x2002, 2)
+
+	run := func(cmd, buf uint16) uint16 {
+		c.Write16(0xFF11, 0x1000)
+		c.Write16(0xFF12, buf)
+		c.Write16(0xFF13, 4)
+		c.Write16(VFSOffsetLoReg, 0)
+		c.Write16(VFSOffsetHiReg, 0)
+		c.WriteMem(0xFF10, cmd)
+		return c.Read16(0xFF14)
+	}
+
+	for _, cmd := range []uint16{1, 5, 7, 10} { // Read, List, GetMeta, ReadAt
+		if st := run(cmd, 0x2000); st != 7 {
+			t.Errorf("command %d into protected buffer: status %d, want 7", cmd, st)
+		}
+		if c.Memory[0x2000] != 0 || c.Memory[0x2002] != 0 {
+			t.Errorf("command %d wrote into a protected buffer", cmd)
+		}
+		if c.Fault != nil {
+			t.Errorf("command %d: Fault = %v, want nil", cmd, c.Fault)
+		}
+	}
+
+	// The buffer just below the protected range is still usable.
+	if st := run(1, 0x1FFE); st != 0 || c.Memory[0x2001] != 4 {
+		t.Errorf("read next to protected range: status %d, byte 0x%02X", st, c.Memory[0x2001])
+	}
+}
@@ -0,0 +1,169 @@
+package cpu
+
+import "fmt"
+
+// OperandForm is the operand layout of an instruction: which fields of the
+// instruction word it uses and how many words follow it.
+type OperandForm int
+
+const (
+	FormNone          OperandForm = iota // HLT
+	FormOneRegister                      // PUSH Rx
+	FormTwoRegister                      // ADD Rx, Ry
+	FormThreeRegister                    // FILL Rx, Ry, Rz
+	FormRegImmediate                     // LDI Rx, imm
+	FormImmediate                        // JMP imm
+	FormIndexed                          // LDX Rx, [Ry + imm] or STX [Rx + imm], Ry
+	FormTwoImmediate                     // MOVM imm, imm
+)
+
+// OpcodeInfo is the assembler mnemonic and operand layout of an opcode.
+type OpcodeInfo struct {
+	Mnemonic string
+	Form     OperandForm
+}
+
+// Opcodes maps every opcode to its mnemonic and operand layout. The
+// assembler's mnemonic tables, the disassembler and CoverageReport are all
+// derived from it.
+var Opcodes = map[uint16]OpcodeInfo{
+	OpHLT:   {"HLT", FormNone},
+	OpNOP:   {"NOP", FormNone},
+	OpLDI:   {"LDI", FormRegImmediate},
+	OpMOV:   {"MOV", FormTwoRegister},
+	OpLD:    {"LD", FormTwoRegister},
+	OpST:    {"ST", FormTwoRegister},
+	OpADD:   {"ADD", FormTwoRegister},
+	OpSUB:   {"SUB", FormTwoRegister},
+	OpAND:   {"AND", FormTwoRegister},
+	OpOR:    {"OR", FormTwoRegister},
+	OpXOR:   {"XOR", FormTwoRegister},
+	OpNOT:   {"NOT", FormOneRegister},
+	OpSHL:   {"SHL", FormTwoRegister},
+	OpSHR:   {"SHR", FormTwoRegister},
+	OpJMP:   {"JMP", FormImmediate},
+	OpJZ:    {"JZ", FormImmediate},
+	OpJNZ:   {"JNZ", FormImmediate},
+	OpJN:    {"JN", FormImmediate},
+	OpPUSH:  {"PUSH", FormOneRegister},
+	OpPOP:   {"POP", FormOneRegister},
+	OpCALL:  {"CALL", FormImmediate},
+	OpRET:   {"RET", FormNone},
+	OpEI:    {"EI", FormNone},
+	OpDI:    {"DI", FormNone},
+	OpRETI:  {"RETI", FormNone},
+	OpWFI:   {"WFI", FormNone},
+	OpLDSP:  {"LDSP", FormOneRegister},
+	OpSTSP:  {"STSP", FormOneRegister},
+	OpMUL:   {"MUL", FormTwoRegister},
+	OpDIV:   {"DIV", FormTwoRegister},
+	OpFILL:  {"FILL", FormThreeRegister},
+	OpCOPY:  {"COPY", FormThreeRegister},
+	OpLDB:   {"LDB", FormTwoRegister},
+	OpSTB:   {"STB", FormTwoRegister},
+	OpIDIV:  {"IDIV", FormTwoRegister},
+	OpJC:    {"JC", FormImmediate},
+	OpJNC:   {"JNC", FormImmediate},
+	OpADDS:  {"ADDS", FormTwoRegister},
+	OpSUBS:  {"SUBS", FormTwoRegister},
+	OpADDUS: {"ADDUS", FormTwoRegister},
+	OpSUBUS: {"SUBUS", FormTwoRegister},
+	OpJMPR:  {"JMPR", FormOneRegister},
+	OpADDB:  {"ADDB", FormTwoRegister},
+	OpSUBB:  {"SUBB", FormTwoRegister},
+	OpANDB:  {"ANDB", FormTwoRegister},
+	OpLDF:   {"LDF", FormOneRegister},
+	OpSTF:   {"STF", FormOneRegister},
+	OpSWAP:  {"SWAP", FormTwoRegister},
+	OpMIN:   {"MIN", FormTwoRegister},
+	OpMAX:   {"MAX", FormTwoRegister},
+	OpMINU:  {"MINU", FormTwoRegister},
+	OpMAXU:  {"MAXU", FormTwoRegister},
+	OpCMP:   {"CMP", FormTwoRegister},
+	OpROL:   {"ROL", FormTwoRegister},
+	OpROR:   {"ROR", FormTwoRegister},
+	OpADC:   {"ADC", FormTwoRegister},
+	OpSBC:   {"SBC", FormTwoRegister},
+	OpJV:    {"JV", FormImmediate},
+	OpJNV:   {"JNV", FormImmediate},
+	OpLDX:   {"LDX", FormIndexed},
+	OpSTX:   {"STX", FormIndexed},
+	OpMOVM:  {"MOVM", FormTwoImmediate},
+}
+
+// DisassembleAt decodes the instruction at code[pc:] into assembler syntax
+// and returns the text and its length in bytes. Words that are not valid
+// instructions (unknown opcodes, stray operand bits, data) come back as
+// `.WORD 0xXXXX`, so the text always reassembles to the same bytes, apart
+// from a trailing odd byte, which is padded to a word.
+func DisassembleAt(code []byte, pc int) (string, int) {
+	if pc+1 >= len(code) {
+		return fmt.Sprintf(".WORD 0x%04X", code[pc]), 1
+	}
+	instr := uint16(code[pc]) | uint16(code[pc+1])<<8
+	data := fmt.Sprintf(".WORD 0x%04X", instr)
+
+	info, ok := Opcodes[(instr>>10)&0x3F]
+	if !ok {
+		return data, 2
+	}
+	opcode := (instr >> 10) & 0x3F
+	regA := (instr >> 7) & 0x07
+	regB := (instr >> 4) & 0x07
+	regC := (instr >> 1) & 0x07
+
+	var canonical uint16
+	var text string
+	switch info.Form {
+	case FormNone, FormImmediate, FormTwoImmediate:
+		canonical = EncodeInstruction(opcode, 0, 0, 0)
+		text = info.Mnemonic
+	case FormOneRegister, FormRegImmediate:
+		canonical = EncodeInstruction(opcode, regA, 0, 0)
+		text = fmt.Sprintf("%s R%d", info.Mnemonic, regA)
+	case FormTwoRegister:
+		canonical = EncodeInstruction(opcode, regA, regB, 0)
+		switch info.Mnemonic {
+		case "LD", "LDB":
+			text = fmt.Sprintf("%s R%d, [R%d]", info.Mnemonic, regA, regB)
+		case "ST", "STB":
+			text = fmt.Sprintf("%s [R%d], R%d", info.Mnemonic, regA, regB)
+		default:
+			text = fmt.Sprintf("%s R%d, R%d", info.Mnemonic, regA, regB)
+		}
+	case FormThreeRegister:
+		canonical = EncodeInstruction(opcode, regA, regB, regC)
+		text = fmt.Sprintf("%s R%d, R%d, R%d", info.Mnemonic, regA, regB, regC)
+	case FormIndexed:
+		canonical = EncodeInstruction(opcode, regA, regB, 0)
+	}
+	if instr != canonical {
+		return data, 2 // operand bits the assembler would never set
+	}
+
+	if info.Form == FormTwoImmediate {
+		if pc+5 >= len(code) {
+			return data, 2 // operands cut off by the end of the image
+		}
+		dst := uint16(code[pc+2]) | uint16(code[pc+3])<<8
+		src := uint16(code[pc+4]) | uint16(code[pc+5])<<8
+		return fmt.Sprintf("%s 0x%04X, 0x%04X", text, dst, src), 6
+	}
+	if info.Form == FormRegImmediate || info.Form == FormImmediate || info.Form == FormIndexed {
+		if pc+3 >= len(code) {
+			return data, 2 // immediate cut off by the end of the image
+		}
+		imm := uint16(code[pc+2]) | uint16(code[pc+3])<<8
+		if info.Form == FormIndexed {
+			if info.Mnemonic == "STX" {
+				return fmt.Sprintf("STX [R%d + 0x%04X], R%d", regA, imm, regB), 4
+			}
+			return fmt.Sprintf("%s R%d, [R%d + 0x%04X]", info.Mnemonic, regA, regB, imm), 4
+		}
+		if info.Form == FormRegImmediate {
+			return fmt.Sprintf("%s, 0x%04X", text, imm), 4
+		}
+		return fmt.Sprintf("%s 0x%04X", text, imm), 4
+	}
+	return text, 2
+}
@@ -33,3 +33,106 @@ func TestEncodePeripheralName(t *testing.T) {
 		t.Errorf("Offset 0x0E: Expected 0x%04X, got 0x%04X", expected, actual)
 	}
 }
+
+// loadPeripheralISR loads a program whose ISR reads 0xFF09, services slots 1
+// and 3 (counting them in R4 and R5), writes each serviced bit back to clear
+// it, and returns with RETI. The main loop enables interrupts and sits in WFI.
+func loadPeripheralISR(c *CPU) {
+	const (
+		check3 = 0x0028
+		done   = 0x003A
+		main   = 0x003C
+		loop   = 0x003E
+	)
+	loadProgram(c, EncodeInstruction(OpJMP, 0, 0, 0), main)
+
+	isr := []uint16{
+		EncodeInstruction(OpLDI, 1, 0, 0), 0xFF09, // 0x10: LDI R1, 0xFF09
+		EncodeInstruction(OpLD, 0, 1, 0),          // 0x14: LD  R0, [R1]
+		EncodeInstruction(OpLDI, 2, 0, 0), 0x0002, // 0x16: LDI R2, slot 1 bit
+		EncodeInstruction(OpAND, 2, 0, 0),        // 0x1A: AND R2, R0
+		EncodeInstruction(OpJZ, 0, 0, 0), check3, // 0x1C: JZ  check3
+		EncodeInstruction(OpST, 1, 2, 0),     // 0x20: ST  [R1], R2 (clear)
+		EncodeInstruction(OpLDI, 3, 0, 0), 1, // 0x22: LDI R3, 1
+		EncodeInstruction(OpADD, 4, 3, 0),         // 0x26: ADD R4, R3
+		EncodeInstruction(OpLDI, 2, 0, 0), 0x0008, // 0x28: check3: LDI R2, slot 3 bit
+		EncodeInstruction(OpAND, 2, 0, 0),      // 0x2C: AND R2, R0
+		EncodeInstruction(OpJZ, 0, 0, 0), done, // 0x2E: JZ  done
+		EncodeInstruction(OpST, 1, 2, 0),     // 0x32: ST  [R1], R2 (clear)
+		EncodeInstruction(OpLDI, 3, 0, 0), 1, // 0x34: LDI R3, 1
+		EncodeInstruction(OpADD, 5, 3, 0),       // 0x38: ADD R5, R3
+		EncodeInstruction(OpRETI, 0, 0, 0),      // 0x3A: done: RETI
+		EncodeInstruction(OpEI, 0, 0, 0),        // 0x3C: main: EI
+		EncodeInstruction(OpWFI, 0, 0, 0),       // 0x3E: loop: WFI
+		EncodeInstruction(OpJMP, 0, 0, 0), loop, // 0x40: JMP loop
+	}
+	for i, w := range isr {
+		w16(c, 0x0010+uint16(i*2), w)
+	}
+}
+
+// runUntilWaiting steps until the CPU parks in WFI again.
+func runUntilWaiting(t *testing.T, c *CPU) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		c.Step()
+		if c.Waiting && c.IE && !c.InterruptPending {
+			return
+		}
+	}
+	t.Fatal("CPU never returned to WFI")
+}
+
+func TestPeripheralInterrupt_AckAndClear(t *testing.T) {
+	c := NewCPU()
+	loadPeripheralISR(c)
+	runUntilWaiting(t, c)
+
+	// Two slots raise interrupts before the CPU gets to run: one dispatch,
+	// and the ISR must service both bits.
+	c.TriggerPeripheralInterrupt(1)
+	c.TriggerPeripheralInterrupt(3)
+	if c.PeripheralIntMask != 0x000A {
+		t.Fatalf("PeripheralIntMask = 0x%04X, want 0x000A", c.PeripheralIntMask)
+	}
+	runUntilWaiting(t, c)
+
+	if c.PeripheralIntMask != 0 {
+		t.Errorf("PeripheralIntMask = 0x%04X after ISR, want 0", c.PeripheralIntMask)
+	}
+	if c.Regs[4] != 1 || c.Regs[5] != 1 {
+		t.Fatalf("slot 1 serviced %d times, slot 3 %d times; want 1 each", c.Regs[4], c.Regs[5])
+	}
+
+	// Once acknowledged, RETI must not re-enter the ISR.
+	for i := 0; i < 100; i++ {
+		c.Step()
+	}
+	if c.Regs[4] != 1 || c.Regs[5] != 1 {
+		t.Errorf("ISR re-triggered after clear: slot 1 = %d, slot 3 = %d", c.Regs[4], c.Regs[5])
+	}
+
+	// A later interrupt from a single slot is serviced on its own.
+	c.TriggerPeripheralInterrupt(3)
+	runUntilWaiting(t, c)
+	if c.Regs[4] != 1 || c.Regs[5] != 2 {
+		t.Errorf("after slot 3 only: slot 1 = %d, slot 3 = %d; want 1, 2", c.Regs[4], c.Regs[5])
+	}
+	if c.PeripheralIntMask != 0 {
+		t.Errorf("PeripheralIntMask = 0x%04X, want 0", c.PeripheralIntMask)
+	}
+}
+
+func TestPeripheralInterrupt_WriteToClearIsPerBit(t *testing.T) {
+	c := NewCPU()
+	c.TriggerPeripheralInterrupt(1)
+	c.TriggerPeripheralInterrupt(3)
+	c.Write16(0xFF09, 0x0002)
+	if got := c.Read16(0xFF09); got != 0x0008 {
+		t.Errorf("0xFF09 = 0x%04X after clearing slot 1, want 0x0008", got)
+	}
+	c.Write16(0xFF09, 0x0000)
+	if got := c.Read16(0xFF09); got != 0x0008 {
+		t.Errorf("writing 0 must not clear anything, got 0x%04X", got)
+	}
+}
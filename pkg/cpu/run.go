@@ -0,0 +1,104 @@
+package cpu
+
+import (
+	"bytes"
+	"io"
+)
+
+// HaltReason says why RunProgram stopped.
+type HaltReason int
+
+const (
+	// HaltInstruction means the program executed HLT.
+	HaltInstruction HaltReason = iota
+	// HaltFault means an instruction faulted; RunResult.Fault has the cause.
+	HaltFault
+	// HaltStepLimit means Options.MaxSteps steps ran without halting.
+	HaltStepLimit
+)
+
+func (r HaltReason) String() string {
+	switch r {
+	case HaltInstruction:
+		return "HLT"
+	case HaltFault:
+		return "fault"
+	case HaltStepLimit:
+		return "step limit"
+	}
+	return "unknown"
+}
+
+// RunResult is the machine state after RunProgram returns.
+type RunResult struct {
+	Regs       [8]uint16
+	PC, SP     uint16
+	Z, N, C, V bool
+
+	Reason HaltReason
+	Fault  error // set when Reason is HaltFault
+
+	// Output is everything the program wrote to the output registers.
+	Output string
+	// Steps is the number of instructions executed, which excludes steps
+	// spent waiting in WFI.
+	Steps uint64
+	// Coverage is the annotated disassembly from CoverageReport, when
+	// Options.Coverage is set.
+	Coverage string
+}
+
+// RunProgram creates a CPU configured by opts, loads code at address 0 and
+// runs it until it halts, faults or reaches opts.MaxSteps. Program output is
+// captured in the result and also copied to opts.Output if that is set. The
+// error is only for invalid options or a program that cannot be loaded; a
+// fault is reported through the result.
+func RunProgram(code []byte, opts Options) (RunResult, error) {
+	var out bytes.Buffer
+	if opts.Output != nil {
+		opts.Output = io.MultiWriter(&out, opts.Output)
+	} else {
+		opts.Output = &out
+	}
+
+	c, err := NewCPUWithOptions(opts)
+	if err != nil {
+		return RunResult{}, err
+	}
+	if err := c.LoadFrom(bytes.NewReader(code)); err != nil {
+		return RunResult{}, err
+	}
+	if opts.Coverage {
+		c.PCHistogram = make(map[uint16]uint64)
+	}
+
+	reason := HaltInstruction
+	for steps := uint64(0); !c.Halted; steps++ {
+		if opts.MaxSteps != 0 && steps >= opts.MaxSteps {
+			reason = HaltStepLimit
+			break
+		}
+		c.Step()
+	}
+	if c.Fault != nil {
+		reason = HaltFault
+	}
+
+	res := RunResult{
+		Regs:   c.Regs,
+		PC:     c.PC,
+		SP:     c.SP,
+		Z:      c.Z,
+		N:      c.N,
+		C:      c.C,
+		V:      c.V,
+		Reason: reason,
+		Fault:  c.Fault,
+		Output: out.String(),
+		Steps:  c.Cycles,
+	}
+	if opts.Coverage {
+		res.Coverage = c.CoverageReport(code)
+	}
+	return res, nil
+}
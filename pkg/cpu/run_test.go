@@ -0,0 +1,65 @@
+package cpu
+
+import (
+	"bytes"
+	"testing"
+)
+
+func words(ws ...uint16) []byte {
+	out := make([]byte, 0, len(ws)*2)
+	for _, w := range ws {
+		out = append(out, byte(w), byte(w>>8))
+	}
+	return out
+}
+
+func TestRunProgram(t *testing.T) {
+	code := words(
+		EncodeInstruction(OpLDI, 1, 0, 0), 0xFF00,
+		EncodeInstruction(OpLDI, 0, 0, 0), 'H',
+		EncodeInstruction(OpST, 1, 0, 0),
+		EncodeInstruction(OpLDI, 0, 0, 0), 'i',
+		EncodeInstruction(OpST, 1, 0, 0),
+		EncodeInstruction(OpCMP, 0, 0, 0),
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+
+	var echo bytes.Buffer
+	res, err := RunProgram(code, Options{Output: &echo})
+	if err != nil {
+		t.Fatalf("RunProgram failed: %v", err)
+	}
+	if res.Reason != HaltInstruction || res.Fault != nil {
+		t.Errorf("reason = %v, fault = %v; expected HLT", res.Reason, res.Fault)
+	}
+	if res.Output != "Hi" || echo.String() != "Hi" {
+		t.Errorf("output = %q, echoed %q; expected %q", res.Output, echo.String(), "Hi")
+	}
+	if res.Regs[0] != 'i' || res.Regs[1] != 0xFF00 {
+		t.Errorf("R0 = 0x%04X, R1 = 0x%04X", res.Regs[0], res.Regs[1])
+	}
+	if !res.Z || res.N {
+		t.Errorf("Z = %v, N = %v after CMP R0, R0", res.Z, res.N)
+	}
+	if res.Steps != 7 || res.PC != 20 || res.SP != 0xB5FE {
+		t.Errorf("steps = %d, PC = 0x%04X, SP = 0x%04X", res.Steps, res.PC, res.SP)
+	}
+}
+
+func TestRunProgram_StepLimit(t *testing.T) {
+	loop := words(EncodeInstruction(OpJMP, 0, 0, 0), 0x0000)
+	res, err := RunProgram(loop, Options{MaxSteps: 50, Coverage: true})
+	if err != nil {
+		t.Fatalf("RunProgram failed: %v", err)
+	}
+	if res.Reason != HaltStepLimit || res.Steps != 50 {
+		t.Errorf("reason = %v, steps = %d; expected step limit after 50", res.Reason, res.Steps)
+	}
+	if res.Coverage == "" {
+		t.Error("coverage report missing")
+	}
+
+	if _, err := RunProgram(make([]byte, 0x10001), Options{}); err == nil {
+		t.Error("oversized program loaded without error")
+	}
+}
@@ -0,0 +1,58 @@
+package cpu
+
+// Countdown timer MMIO registers. Writing TimerReloadReg sets the reload
+// value and restarts the count. While enabled (TimerEnable in
+// TimerControlReg) the count drops by one per Step; on reaching zero it
+// reloads, sets TimerExpired and raises an interrupt. TimerCountReg reads the
+// steps remaining.
+const (
+	TimerReloadReg  uint16 = 0xFF2C
+	TimerControlReg uint16 = 0xFF2D
+	TimerCountReg   uint16 = 0xFF2E
+)
+
+// TimerControlReg bits. TimerExpired is set by the timer, not the program:
+// writing a value with it set acknowledges (clears) it.
+const (
+	TimerEnable  uint16 = 0x01
+	TimerExpired uint16 = 0x02
+)
+
+// tickTimer counts the timer down by one step and fires it on reaching zero.
+// A reload value of 0 never fires.
+func (c *CPU) tickTimer() {
+	if !c.timerEnabled || c.timerReload == 0 {
+		return
+	}
+	if c.timerCounter > 0 {
+		c.timerCounter--
+	}
+	if c.timerCounter > 0 {
+		return
+	}
+	c.timerCounter = c.timerReload
+	c.timerExpired = true
+	c.requestInterrupt(IntCauseTimer)
+}
+
+func (c *CPU) timerControl() uint16 {
+	var v uint16
+	if c.timerEnabled {
+		v |= TimerEnable
+	}
+	if c.timerExpired {
+		v |= TimerExpired
+	}
+	return v
+}
+
+func (c *CPU) setTimerControl(val uint16) {
+	enable := val&TimerEnable != 0
+	if enable && !c.timerEnabled && c.timerCounter == 0 {
+		c.timerCounter = c.timerReload
+	}
+	c.timerEnabled = enable
+	if val&TimerExpired != 0 {
+		c.timerExpired = false
+	}
+}
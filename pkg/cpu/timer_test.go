@@ -0,0 +1,92 @@
+package cpu
+
+import "testing"
+
+// timerProgram enables interrupts and spins at 0x0002; the ISR at 0x0010
+// spins on itself, so PC shows whether the interrupt was taken.
+func timerProgram(c *CPU) {
+	loadProgram(c,
+		EncodeInstruction(OpEI, 0, 0, 0),          // 0x0000: EI
+		EncodeInstruction(OpJMP, 0, 0, 0), 0x0002, // 0x0002: JMP 0x0002
+	)
+	c.Write16(0x0010, EncodeInstruction(OpJMP, 0, 0, 0)) // 0x0010: JMP 0x0010
+	c.Write16(0x0012, 0x0010)
+}
+
+func TestTimer_FiresAndReloads(t *testing.T) {
+	const n = 5
+	c := NewCPU()
+	timerProgram(c)
+	c.Write16(TimerReloadReg, n)
+	c.Write16(TimerControlReg, TimerEnable)
+
+	for i := 0; i < n-1; i++ {
+		c.Step()
+	}
+	if c.PC == 0x0010 || c.timerExpired {
+		t.Fatalf("timer fired early: PC = 0x%04X", c.PC)
+	}
+
+	c.Step()
+	c.Step()
+	if c.PC != 0x0010 {
+		t.Errorf("PC = 0x%04X, want 0x0010", c.PC)
+	}
+	if got := c.Read16(TimerControlReg); got != TimerEnable|TimerExpired {
+		t.Errorf("control = 0x%04X, want enabled and expired", got)
+	}
+	if got := c.Read16(c.SP); got != 0x0002 {
+		t.Errorf("return address = 0x%04X, want 0x0002", got)
+	}
+	if got := c.Read16(TimerCountReg); got != n-1 {
+		t.Errorf("count = %d, want %d after reload", got, n-1)
+	}
+
+	c.Write16(TimerControlReg, TimerEnable|TimerExpired)
+	if got := c.Read16(TimerControlReg); got != TimerEnable {
+		t.Errorf("control after acknowledge = 0x%04X, want 0x%04X", got, TimerEnable)
+	}
+}
+
+func TestTimer_Disabled(t *testing.T) {
+	c := NewCPU()
+	timerProgram(c)
+	c.Write16(TimerReloadReg, 3)
+	for i := 0; i < 10; i++ {
+		c.Step()
+	}
+	if c.PC == 0x0010 || c.InterruptPending {
+		t.Errorf("disabled timer fired: PC = 0x%04X", c.PC)
+	}
+	if got := c.Read16(TimerCountReg); got != 3 {
+		t.Errorf("count = %d, want 3", got)
+	}
+}
+
+func TestTimer_Hibernate(t *testing.T) {
+	c1 := NewCPU()
+	timerProgram(c1)
+	c1.Write16(TimerReloadReg, 100)
+	c1.Write16(TimerControlReg, TimerEnable)
+	for i := 0; i < 10; i++ {
+		c1.Step()
+	}
+
+	data, err := c1.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+	c2 := NewCPU()
+	if err := c2.RestoreFromBytes(data); err != nil {
+		t.Fatalf("RestoreFromBytes: %v", err)
+	}
+	if got := c2.Read16(TimerReloadReg); got != 100 {
+		t.Errorf("reload = %d, want 100", got)
+	}
+	if got := c2.Read16(TimerCountReg); got != 90 {
+		t.Errorf("count = %d, want 90", got)
+	}
+	if got := c2.Read16(TimerControlReg); got != TimerEnable {
+		t.Errorf("control = 0x%04X, want 0x%04X", got, TimerEnable)
+	}
+}
@@ -18,10 +18,51 @@ func rgb565ToRGBA(val uint16) (r, g, b, a byte) {
 	return
 }
 
-// GetFramebufferRGBA decodes the current graphics bank into a 128×128 RGBA8888
-// byte slice (length 128*128*4 = 65536). It respects BufferedMode, DisplayBank,
-// CurrentBank, and ColorMode8bpp.
+// Graphics resolution MMIO registers. The bitmap is 128×128 by default; a
+// program may pick another size, such as 160×120, as long as every pixel fits
+// in one graphics bank in the current colour mode (two pixels per byte, or
+// one with ColorMode8bpp). Writes that would not fit, or a zero dimension,
+// are ignored, so when growing one side and shrinking the other, write the
+// shrinking one first. Pixels are stored row by row, width pixels per row.
+const (
+	GfxWidthReg  uint16 = 0xFF0B
+	GfxHeightReg uint16 = 0xFF0C
+
+	defaultGfxSize = 128
+	gfxBankBytes   = 16384
+)
+
+// GraphicsSize returns the bitmap resolution in pixels.
+func (c *CPU) GraphicsSize() (width, height int) {
+	width, height = int(c.gfxWidth), int(c.gfxHeight)
+	if width == 0 || height == 0 {
+		return defaultGfxSize, defaultGfxSize
+	}
+	return width, height
+}
+
+// SetGraphicsSize sets the bitmap resolution, as writing GfxWidthReg and
+// GfxHeightReg does. It reports false, leaving the resolution unchanged, if
+// a dimension is zero or the pixels would not fit in a graphics bank.
+func (c *CPU) SetGraphicsSize(width, height int) bool {
+	capacity := gfxBankBytes * 2
+	if c.ColorMode8bpp {
+		capacity = gfxBankBytes
+	}
+	if width <= 0 || height <= 0 || width > 0xFFFF || height > 0xFFFF || width*height > capacity {
+		return false
+	}
+	c.gfxWidth, c.gfxHeight = uint16(width), uint16(height)
+	return true
+}
+
+// GetFramebufferRGBA decodes the current graphics bank into an RGBA8888 byte
+// slice of GraphicsSize() pixels, row by row (length width*height*4). It
+// respects BufferedMode, DisplayBank, CurrentBank, and ColorMode8bpp. Pixels
+// past the end of the bank, possible after switching to 8bpp with a large
+// resolution, use palette entry 0.
 func (c *CPU) GetFramebufferRGBA() []byte {
+	c.ensureBanks()
 	var bankData *[16384]byte
 	if c.BufferedMode {
 		bankData = &c.GraphicsBanksFront[c.DisplayBank]
@@ -29,34 +70,25 @@ func (c *CPU) GetFramebufferRGBA() []byte {
 		bankData = &c.GraphicsBanks[c.CurrentBank]
 	}
 
-	pixels := make([]byte, 128*128*4)
+	width, height := c.GraphicsSize()
+	pixels := make([]byte, width*height*4)
 
-	if c.ColorMode8bpp {
-		// 8bpp: one byte per pixel, 16384 pixels total
-		for i := 0; i < 16384; i++ {
-			colorIdx := bankData[i]
-			r, g, b, a := rgb565ToRGBA(c.Palette[colorIdx])
-			pixels[i*4+0] = r
-			pixels[i*4+1] = g
-			pixels[i*4+2] = b
-			pixels[i*4+3] = a
-		}
-	} else {
-		// 4bpp: one word (2 bytes) holds 4 nibbles → 4 pixels
-		for wordIdx := 0; wordIdx < 4096; wordIdx++ {
-			lo := uint16(bankData[wordIdx*2])
-			hi := uint16(bankData[wordIdx*2+1])
-			word := lo | (hi << 8)
-			for sub := 0; sub < 4; sub++ {
-				colorIdx := (word >> (sub * 4)) & 0xF
-				r, g, b, a := rgb565ToRGBA(c.Palette[colorIdx])
-				pixelIdx := (wordIdx*4 + sub) * 4
-				pixels[pixelIdx+0] = r
-				pixels[pixelIdx+1] = g
-				pixels[pixelIdx+2] = b
-				pixels[pixelIdx+3] = a
+	for i := 0; i < width*height; i++ {
+		var colorIdx byte
+		if c.ColorMode8bpp {
+			// 8bpp: one byte per pixel
+			if i < len(bankData) {
+				colorIdx = bankData[i]
 			}
+		} else if i/2 < len(bankData) {
+			// 4bpp: two pixels per byte, low nibble first
+			colorIdx = (bankData[i/2] >> (uint(i%2) * 4)) & 0xF
 		}
+		r, g, b, a := rgb565ToRGBA(c.Palette[colorIdx])
+		pixels[i*4+0] = r
+		pixels[i*4+1] = g
+		pixels[i*4+2] = b
+		pixels[i*4+3] = a
 	}
 
 	return pixels
@@ -64,11 +96,12 @@ func (c *CPU) GetFramebufferRGBA() []byte {
 
 // GetFramebufferImage returns the current graphics bank as an *image.RGBA.
 func (c *CPU) GetFramebufferImage() *image.RGBA {
+	width, height := c.GraphicsSize()
 	pix := c.GetFramebufferRGBA()
 	return &image.RGBA{
 		Pix:    pix,
-		Stride: 128 * 4,
-		Rect:   image.Rect(0, 0, 128, 128),
+		Stride: width * 4,
+		Rect:   image.Rect(0, 0, width, height),
 	}
 }
 
@@ -77,6 +77,46 @@ func TestGetFramebufferRGBA_8bpp(t *testing.T) {
 	}
 }
 
+// Distinct palette entries, including indices a 4bpp nibble cannot reach,
+// map byte-for-byte onto pixels; the buffered front bank is used when
+// BufferedMode is set.
+func TestGetFramebufferRGBA_8bppPalette(t *testing.T) {
+	c := NewCPU()
+	c.ColorMode8bpp = true
+	c.Palette[0x00] = 0x0000 // black
+	c.Palette[0x10] = 0xF800 // red
+	c.Palette[0x80] = 0x07E0 // green
+	c.Palette[0xFF] = 0x001F // blue
+	c.Palette[0x42] = 0x8410 // mid grey: r5=16, g6=32, b5=16
+
+	bank := []byte{0x10, 0x80, 0xFF, 0x42, 0x00}
+	copy(c.GraphicsBanks[0][:], bank)
+	want := [][4]byte{
+		{0xFF, 0x00, 0x00, 0xFF},
+		{0x00, 0xFF, 0x00, 0xFF},
+		{0x00, 0x00, 0xFF, 0xFF},
+		{0x84, 0x82, 0x84, 0xFF},
+		{0x00, 0x00, 0x00, 0xFF},
+	}
+	check := func(label string, pixels []byte) {
+		t.Helper()
+		for i, w := range want {
+			var got [4]byte
+			copy(got[:], pixels[i*4:])
+			if got != w {
+				t.Errorf("%s pixel %d (index 0x%02X): got %v, want %v", label, i, bank[i], got, w)
+			}
+		}
+	}
+	check("direct", c.GetFramebufferRGBA())
+
+	// Buffered: the back bank is ignored until flipped to the front.
+	c.BufferedMode = true
+	c.GraphicsBanks[0][0] = 0xFF
+	copy(c.GraphicsBanksFront[0][:], bank)
+	check("buffered", c.GetFramebufferRGBA())
+}
+
 // TestGetFramebufferRGBA_4bpp verifies 4bpp framebuffer decoding (Ticket 3).
 func TestGetFramebufferRGBA_4bpp(t *testing.T) {
 	c := NewCPU()
@@ -137,3 +177,55 @@ func TestPaletteStateRoundtrip(t *testing.T) {
 		t.Error("ColorMode8bpp after restore: expected true")
 	}
 }
+
+func TestGraphicsResolution(t *testing.T) {
+	c := NewCPU()
+	if c.Read16(GfxWidthReg) != 128 || c.Read16(GfxHeightReg) != 128 {
+		t.Fatalf("default size = %dx%d, want 128x128", c.Read16(GfxWidthReg), c.Read16(GfxHeightReg))
+	}
+
+	// Shrink the height first so 160 wide still fits the bank.
+	c.Write16(GfxHeightReg, 120)
+	c.Write16(GfxWidthReg, 160)
+	if c.Read16(GfxWidthReg) != 160 || c.Read16(GfxHeightReg) != 120 {
+		t.Fatalf("size = %dx%d, want 160x120", c.Read16(GfxWidthReg), c.Read16(GfxHeightReg))
+	}
+
+	// Pixel (1, 1) is index 161: the high nibble of byte 80.
+	c.Palette[3] = 0xF800
+	c.GraphicsBanks[0][80] = 0x30
+	pixels := c.GetFramebufferRGBA()
+	if len(pixels) != 160*120*4 {
+		t.Fatalf("framebuffer length = %d, want %d", len(pixels), 160*120*4)
+	}
+	if p := pixels[161*4 : 161*4+4]; p[0] != 0xFF || p[1] != 0 || p[2] != 0 {
+		t.Errorf("pixel (1,1) = %v, want red", p)
+	}
+	img := c.GetFramebufferImage()
+	if img.Rect.Dx() != 160 || img.Rect.Dy() != 120 || img.Stride != 160*4 {
+		t.Errorf("image = %v stride %d, want 160x120 stride %d", img.Rect, img.Stride, 160*4)
+	}
+
+	// Invalid sizes are ignored.
+	c.Write16(GfxWidthReg, 0)
+	c.Write16(GfxWidthReg, 300) // 300*120 > 32768 pixels
+	c.ColorMode8bpp = true
+	if c.SetGraphicsSize(160, 120) {
+		t.Error("160x120 accepted in 8bpp mode, which needs 19200 bytes")
+	}
+	if w, h := c.GraphicsSize(); w != 160 || h != 120 {
+		t.Errorf("size after invalid writes = %dx%d, want 160x120", w, h)
+	}
+
+	data, err := c.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+	c2 := NewCPU()
+	if err := c2.RestoreFromBytes(data); err != nil {
+		t.Fatalf("RestoreFromBytes: %v", err)
+	}
+	if w, h := c2.GraphicsSize(); w != 160 || h != 120 {
+		t.Errorf("restored size = %dx%d, want 160x120", w, h)
+	}
+}
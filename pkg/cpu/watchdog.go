@@ -0,0 +1,52 @@
+package cpu
+
+// WatchdogPetValue is the magic word that must be written to 0xFF26 to reload
+// the watchdog counter. Any other value is ignored so that a runaway program
+// scribbling over MMIO is unlikely to keep the watchdog alive by accident.
+const WatchdogPetValue uint16 = 0x5A5A
+
+// tickWatchdog counts the watchdog down by one step. When the counter expires
+// the CPU is reset and true is returned so Step can skip the current cycle.
+// A timeout of 0 leaves the watchdog disarmed.
+func (c *CPU) tickWatchdog() bool {
+	if c.WatchdogTimeout == 0 {
+		return false
+	}
+	if c.watchdogCounter > 0 {
+		c.watchdogCounter--
+	}
+	if c.watchdogCounter > 0 {
+		return false
+	}
+	if c.WatchdogResets < 0xFFFF {
+		c.WatchdogResets++
+	}
+	c.Reset()
+	return true
+}
+
+// Reset puts the CPU back into its power-on control state: registers and
+// flags are cleared, PC returns to 0 and SP to the top of the stack. Memory,
+// VRAM, the VFS and mounted peripherals are left untouched. The watchdog is
+// disarmed and the timer stopped so the restarted program can configure them
+// again.
+func (c *CPU) Reset() {
+	c.Regs = [8]uint16{}
+	c.PC = 0
+	c.SP = 0xB5FE
+	c.Z, c.N, c.C, c.IE, c.V = false, false, false, false, false
+	c.Waiting = false
+	c.InterruptPending = false
+	c.interruptCause = 0
+	c.pendingLevels = 0
+	c.interruptLevels = nil
+	c.Halted = false
+	c.Fault = nil
+	c.StackOverflow = false
+	c.CallDepth = 0
+	c.subroutineDepth, c.maxSubroutineDepth = 0, 0
+	c.WatchdogTimeout = 0
+	c.watchdogCounter = 0
+	c.timerEnabled = false
+	c.timerExpired = false
+}
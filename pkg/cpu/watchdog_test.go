@@ -0,0 +1,121 @@
+package cpu
+
+import "testing"
+
+// watchdogProgram arms the watchdog with the given timeout and then spins in a
+// loop that increments R3. When pet is true the loop also writes the pet value
+// to 0xFF26 on every iteration.
+func watchdogProgram(c *CPU, timeout uint16, pet bool) {
+	words := []uint16{
+		EncodeInstruction(OpLDI, 1, 0, 0), 0xFF25, // LDI R1, 0xFF25
+		EncodeInstruction(OpLDI, 2, 0, 0), timeout, // LDI R2, timeout
+		EncodeInstruction(OpST, 1, 2, 0),          // ST [R1], R2
+		EncodeInstruction(OpLDI, 1, 0, 0), 0xFF26, // LDI R1, 0xFF26
+		EncodeInstruction(OpLDI, 2, 0, 0), WatchdogPetValue, // LDI R2, pet value
+		EncodeInstruction(OpLDI, 4, 0, 0), 1, // LDI R4, 1
+	}
+	// loop: 0x16
+	words = append(words, EncodeInstruction(OpADD, 3, 4, 0)) // ADD R3, R4
+	if pet {
+		words = append(words, EncodeInstruction(OpST, 1, 2, 0)) // ST [R1], R2
+	} else {
+		words = append(words, EncodeInstruction(OpNOP, 0, 0, 0))
+	}
+	words = append(words, EncodeInstruction(OpJMP, 0, 0, 0), 0x0016) // JMP loop
+	loadProgram(c, words...)
+}
+
+func TestWatchdog_ResetsWhenNotPetted(t *testing.T) {
+	c := NewCPU()
+	watchdogProgram(c, 20, false)
+
+	// Arming takes 3 steps; the watchdog then counts down once per step and
+	// must have fired within the next 20.
+	for i := 0; i < 3+20; i++ {
+		c.Step()
+	}
+	if c.WatchdogResets != 1 {
+		t.Fatalf("WatchdogResets = %d, want 1", c.WatchdogResets)
+	}
+	if got := c.Read16(0xFF26); got != 1 {
+		t.Errorf("0xFF26 read = %d, want 1", got)
+	}
+
+	// After the reset the program restarts from 0 and re-arms the watchdog,
+	// so it keeps firing for as long as nobody pets it.
+	for i := 0; i < 200; i++ {
+		c.Step()
+	}
+	if c.WatchdogResets < 2 {
+		t.Errorf("WatchdogResets = %d, want at least 2", c.WatchdogResets)
+	}
+}
+
+func TestWatchdog_PettingKeepsCPUAlive(t *testing.T) {
+	c := NewCPU()
+	watchdogProgram(c, 20, true)
+
+	for i := 0; i < 1000; i++ {
+		c.Step()
+	}
+	if c.WatchdogResets != 0 {
+		t.Fatalf("WatchdogResets = %d, want 0", c.WatchdogResets)
+	}
+	if c.Regs[3] < 100 {
+		t.Errorf("R3 = %d, expected the loop to keep running", c.Regs[3])
+	}
+}
+
+func TestWatchdog_IgnoresWrongPetValue(t *testing.T) {
+	c := NewCPU()
+	loadProgram(c, EncodeInstruction(OpJMP, 0, 0, 0), 0x0000)
+	c.Write16(0xFF25, 5)
+	c.Step()
+	c.Write16(0xFF26, 0x1234)
+	if got := c.Read16(0xFF25); got != 4 {
+		t.Errorf("counter = %d, want 4 (wrong pet value must be ignored)", got)
+	}
+	c.Write16(0xFF26, WatchdogPetValue)
+	if got := c.Read16(0xFF25); got != 5 {
+		t.Errorf("counter = %d, want 5 after pet", got)
+	}
+}
+
+func TestWatchdog_DisabledByDefault(t *testing.T) {
+	c := NewCPU()
+	loadProgram(c, EncodeInstruction(OpJMP, 0, 0, 0), 0x0000)
+	for i := 0; i < 100000; i++ {
+		c.Step()
+	}
+	if c.WatchdogResets != 0 {
+		t.Errorf("WatchdogResets = %d, want 0", c.WatchdogResets)
+	}
+}
+
+func TestWatchdog_Hibernate(t *testing.T) {
+	c1 := NewCPU()
+	loadProgram(c1, EncodeInstruction(OpJMP, 0, 0, 0), 0x0000)
+	c1.Write16(0xFF25, 300)
+	for i := 0; i < 10; i++ {
+		c1.Step()
+	}
+	c1.WatchdogResets = 3
+
+	data, err := c1.HibernateToBytes()
+	if err != nil {
+		t.Fatalf("HibernateToBytes: %v", err)
+	}
+	c2 := NewCPU()
+	if err := c2.RestoreFromBytes(data); err != nil {
+		t.Fatalf("RestoreFromBytes: %v", err)
+	}
+	if c2.WatchdogTimeout != 300 {
+		t.Errorf("WatchdogTimeout = %d, want 300", c2.WatchdogTimeout)
+	}
+	if got := c2.Read16(0xFF25); got != 290 {
+		t.Errorf("counter = %d, want 290", got)
+	}
+	if c2.WatchdogResets != 3 {
+		t.Errorf("WatchdogResets = %d, want 3", c2.WatchdogResets)
+	}
+}
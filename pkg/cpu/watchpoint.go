@@ -0,0 +1,59 @@
+package cpu
+
+// watchpoint is one callback registered with AddWatchpoint.
+type watchpoint struct {
+	onWrite, onRead bool
+	cb              func(addr, val uint16)
+}
+
+// AddWatchpoint calls cb whenever the program (or the host, through
+// Read16/Write16/ReadByte/WriteByte) reads or writes addr, as selected by
+// onRead and onWrite. cb receives the address and the value transferred,
+// after the access has taken effect. Several watchpoints may share an
+// address. Instruction fetches, including the operand words that follow an
+// instruction, are not reported.
+//
+// A word access touches two addresses: the watchpoint on the first sees the
+// whole word, the one on the second sees the high byte. MMIO registers and
+// expansion bus words are single addresses, so a write to 0xFF00 reports the
+// value written there. Byte accesses to the expansion bus are reported as the
+// word access they turn into.
+func (c *CPU) AddWatchpoint(addr uint16, onWrite, onRead bool, cb func(addr, val uint16)) {
+	if c.watchpoints == nil {
+		c.watchpoints = make(map[uint16][]watchpoint)
+	}
+	c.watchpoints[addr] = append(c.watchpoints[addr], watchpoint{onWrite, onRead, cb})
+}
+
+// RemoveWatchpoints removes every watchpoint on addr.
+func (c *CPU) RemoveWatchpoints(addr uint16) {
+	delete(c.watchpoints, addr)
+}
+
+// watch fires the watchpoints on addr for an access of val.
+func (c *CPU) watch(addr, val uint16, write bool) {
+	for _, w := range c.watchpoints[addr] {
+		if (write && w.onWrite) || (!write && w.onRead) {
+			w.cb(addr, val)
+		}
+	}
+}
+
+// watch16 fires the watchpoints for a word access at addr.
+func (c *CPU) watch16(addr, val uint16, write bool) {
+	if c.watchpoints == nil {
+		return
+	}
+	c.watch(addr, val, write)
+	if !isMMIO(addr) {
+		c.watch(addr+1, val>>8, write)
+	}
+}
+
+// watchByte fires the watchpoints for a byte access at addr.
+func (c *CPU) watchByte(addr uint16, val byte, write bool) {
+	if c.watchpoints == nil || (addr >= 0xFE00 && addr <= 0xFEFF) {
+		return
+	}
+	c.watch(addr, uint16(val), write)
+}
@@ -0,0 +1,110 @@
+package cpu
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type watchHit struct {
+	addr, val uint16
+}
+
+func TestWatchpoint_RAMWrite(t *testing.T) {
+	c := NewCPU()
+	loadProgram(c,
+		EncodeInstruction(OpLDI, RegA, 0, 0), 0x2000, // LDI R0, 0x2000
+		EncodeInstruction(OpLDI, RegB, 0, 0), 0x1234, // LDI R1, 0x1234
+		EncodeInstruction(OpST, RegA, RegB, 0),       // ST [R0], R1
+		EncodeInstruction(OpLD, RegC, RegA, 0),       // LD R2, [R0]
+		EncodeInstruction(OpLDI, RegB, 0, 0), 0x0056, // LDI R1, 0x56
+		EncodeInstruction(OpSTB, RegA, RegB, 0), // STB [R0], R1
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+
+	var writes, highWrites, reads []watchHit
+	c.AddWatchpoint(0x2000, true, false, func(addr, val uint16) {
+		writes = append(writes, watchHit{addr, val})
+	})
+	c.AddWatchpoint(0x2001, true, false, func(addr, val uint16) {
+		highWrites = append(highWrites, watchHit{addr, val})
+	})
+	c.AddWatchpoint(0x2000, false, true, func(addr, val uint16) {
+		reads = append(reads, watchHit{addr, val})
+	})
+	c.Run()
+
+	if want := []watchHit{{0x2000, 0x1234}, {0x2000, 0x56}}; !reflect.DeepEqual(writes, want) {
+		t.Errorf("writes = %v, want %v", writes, want)
+	}
+	if want := []watchHit{{0x2001, 0x12}}; !reflect.DeepEqual(highWrites, want) {
+		t.Errorf("high byte writes = %v, want %v", highWrites, want)
+	}
+	if want := []watchHit{{0x2000, 0x1234}}; !reflect.DeepEqual(reads, want) {
+		t.Errorf("reads = %v, want %v", reads, want)
+	}
+}
+
+func TestWatchpoint_MMIOWrite(t *testing.T) {
+	var out bytes.Buffer
+	c, err := NewCPUWithOptions(Options{Output: &out})
+	if err != nil {
+		t.Fatalf("NewCPUWithOptions: %v", err)
+	}
+	loadProgram(c,
+		EncodeInstruction(OpLDI, RegA, 0, 0), 0xFF00, // LDI R0, 0xFF00
+		EncodeInstruction(OpLDI, RegB, 0, 0), 'A', // LDI R1, 'A'
+		EncodeInstruction(OpST, RegA, RegB, 0), // ST [R0], R1
+		EncodeInstruction(OpHLT, 0, 0, 0),
+	)
+
+	var hits []watchHit
+	c.AddWatchpoint(0xFF00, true, true, func(addr, val uint16) {
+		hits = append(hits, watchHit{addr, val})
+	})
+	c.AddWatchpoint(0xFF01, true, true, func(addr, val uint16) {
+		t.Errorf("watchpoint on 0xFF01 fired with 0x%04X", val)
+	})
+	c.Run()
+
+	if want := []watchHit{{0xFF00, 'A'}}; !reflect.DeepEqual(hits, want) {
+		t.Errorf("hits = %v, want %v", hits, want)
+	}
+	if out.String() != "A" {
+		t.Errorf("output = %q, want %q", out.String(), "A")
+	}
+}
+
+func TestWatchpoint_Remove(t *testing.T) {
+	c := NewCPU()
+	fired := 0
+	c.AddWatchpoint(0x3000, true, true, func(addr, val uint16) { fired++ })
+	c.WriteByte(0x3000, 1)
+	c.RemoveWatchpoints(0x3000)
+	c.WriteByte(0x3000, 2)
+	c.ReadByte(0x3000)
+	if fired != 1 {
+		t.Errorf("watchpoint fired %d times, want 1", fired)
+	}
+}
+
+func TestWatchpoint_IgnoresFetch(t *testing.T) {
+	c := NewCPU()
+	loadProgram(c,
+		EncodeInstruction(OpLDI, RegA, 0, 0), 0x0006, // 0000: LDI R0, 6
+		EncodeInstruction(OpCALL, 0, 0, 0), 0x000A, // 0004: CALL 0x000A
+		EncodeInstruction(OpHLT, 0, 0, 0), // 0008: HLT
+		EncodeInstruction(OpRET, 0, 0, 0), // 000A: RET
+	)
+	for addr := uint16(0); addr < 0x0C; addr++ {
+		c.AddWatchpoint(addr, false, true, func(addr, val uint16) {
+			t.Errorf("read watchpoint on 0x%04X fired for a fetch", addr)
+		})
+	}
+	c.StepOver()
+	c.StepOver()
+	c.Run()
+	if !c.Halted || c.Regs[RegA] != 6 {
+		t.Errorf("Halted = %v, R0 = %d", c.Halted, c.Regs[RegA])
+	}
+}
@@ -0,0 +1,124 @@
+package peripherals
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gocpu/pkg/cpu"
+)
+
+const BlitterType = "Blitter"
+
+// Blitter copies a rectangle of bytes from one place in memory to another,
+// for example a sprite into a graphics bank. Registers (offsets within the
+// slot):
+//
+//	0x00  write 1 to start the copy; reads 0
+//	0x02  source address
+//	0x04  destination address
+//	0x06  size: width in bytes (low byte), height in rows (high byte)
+//	0x08  source stride in bytes (write-only)
+//	0x0A  destination stride in bytes (write-only)
+//
+// The stride registers share their offsets with the device name, so reading
+// them returns the name like on every peripheral. A stride of 0 means rows
+// are packed, width bytes apart. The copy runs to completion during the
+// write to 0x00, through CPU.ReadByte and CPU.WriteByte, so it sees the
+// current graphics bank and MMIO exactly as the program would.
+type Blitter struct {
+	c    *cpu.CPU
+	slot uint8
+
+	src, dst             uint16
+	size                 uint16
+	srcStride, dstStride uint16
+}
+
+func NewBlitter(c *cpu.CPU, slot uint8) *Blitter {
+	return &Blitter{
+		c:    c,
+		slot: slot,
+	}
+}
+
+func (b *Blitter) Type() string { return BlitterType }
+
+func (b *Blitter) Read16(offset uint16) uint16 {
+	if offset >= 0x08 && offset <= 0x0E {
+		return cpu.EncodePeripheralName("BLITTER", offset)
+	}
+	switch offset {
+	case 0x02:
+		return b.src
+	case 0x04:
+		return b.dst
+	case 0x06:
+		return b.size
+	}
+	return 0
+}
+
+func (b *Blitter) Write16(offset uint16, val uint16) {
+	switch offset {
+	case 0x00:
+		if val == 1 {
+			b.blit()
+		}
+	case 0x02:
+		b.src = val
+	case 0x04:
+		b.dst = val
+	case 0x06:
+		b.size = val
+	case 0x08:
+		b.srcStride = val
+	case 0x0A:
+		b.dstStride = val
+	}
+}
+
+func (b *Blitter) Step() {
+	// Synchronous peripheral, no step needed
+}
+
+func (b *Blitter) blit() {
+	width := b.size & 0xFF
+	height := b.size >> 8
+	srcStride, dstStride := b.srcStride, b.dstStride
+	if srcStride == 0 {
+		srcStride = width
+	}
+	if dstStride == 0 {
+		dstStride = width
+	}
+
+	for row := uint16(0); row < height; row++ {
+		src := b.src + row*srcStride
+		dst := b.dst + row*dstStride
+		for col := uint16(0); col < width; col++ {
+			b.c.WriteByte(dst+col, b.c.ReadByte(src+col))
+		}
+	}
+}
+
+// SaveState serialises the five registers as 10 little-endian bytes.
+func (b *Blitter) SaveState() []byte {
+	buf := make([]byte, 10)
+	for i, v := range []uint16{b.src, b.dst, b.size, b.srcStride, b.dstStride} {
+		binary.LittleEndian.PutUint16(buf[i*2:], v)
+	}
+	return buf
+}
+
+// LoadState restores the registers saved by SaveState.
+func (b *Blitter) LoadState(data []byte) error {
+	if len(data) < 10 {
+		return fmt.Errorf("Blitter.LoadState: need 10 bytes, got %d", len(data))
+	}
+	b.src = binary.LittleEndian.Uint16(data[0:])
+	b.dst = binary.LittleEndian.Uint16(data[2:])
+	b.size = binary.LittleEndian.Uint16(data[4:])
+	b.srcStride = binary.LittleEndian.Uint16(data[6:])
+	b.dstStride = binary.LittleEndian.Uint16(data[8:])
+	return nil
+}
@@ -0,0 +1,149 @@
+package peripherals
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gocpu/pkg/cpu"
+)
+
+const TonePeripheralType = "TonePeripheral"
+
+// ToneTickRate is the number of Step calls the tone generator treats as one
+// second. It matches the desktop runner, which executes 10000 instructions
+// per frame at 60 frames per second.
+const ToneTickRate = 600000
+
+// TonePeripheral is a square-wave tone generator. Registers (offsets within
+// the slot):
+//
+//	0x00  write 1 to start the tone, 0 to stop it; reads 1 while playing
+//	0x02  frequency in Hz
+//	0x04  duration in milliseconds (0 = play until stopped)
+//	0x06  volume, 0-255
+//
+// There is no audio sink in the emulator core; the host reads the waveform
+// through Sample after each Step. When a tone with a duration finishes, the
+// peripheral raises its interrupt.
+type TonePeripheral struct {
+	c    *cpu.CPU
+	slot uint8
+
+	freq     uint16
+	duration uint16
+	volume   uint16
+
+	playing bool
+	elapsed uint32 // ticks since the tone started
+}
+
+func NewTonePeripheral(c *cpu.CPU, slot uint8) *TonePeripheral {
+	return &TonePeripheral{
+		c:    c,
+		slot: slot,
+	}
+}
+
+func (t *TonePeripheral) Type() string { return TonePeripheralType }
+
+func (t *TonePeripheral) Read16(offset uint16) uint16 {
+	if offset >= 0x08 && offset <= 0x0E {
+		return cpu.EncodePeripheralName("TONE", offset)
+	}
+	switch offset {
+	case 0x00:
+		if t.playing {
+			return 1
+		}
+		return 0
+	case 0x02:
+		return t.freq
+	case 0x04:
+		return t.duration
+	case 0x06:
+		return t.volume
+	}
+	return 0
+}
+
+func (t *TonePeripheral) Write16(offset uint16, val uint16) {
+	switch offset {
+	case 0x00:
+		switch val {
+		case 1:
+			t.playing = true
+			t.elapsed = 0
+		case 0:
+			t.playing = false
+		}
+	case 0x02:
+		t.freq = val
+	case 0x04:
+		t.duration = val
+	case 0x06:
+		t.volume = val & 0xFF
+	}
+}
+
+// Step advances the waveform by one tick and stops the tone once its
+// duration has elapsed.
+func (t *TonePeripheral) Step() {
+	if !t.playing {
+		return
+	}
+	t.elapsed++
+	if t.duration != 0 && t.elapsed >= uint32(t.duration)*(ToneTickRate/1000) {
+		t.playing = false
+		t.c.TriggerPeripheralInterrupt(t.slot)
+	}
+}
+
+// Playing reports whether a tone is currently sounding.
+func (t *TonePeripheral) Playing() bool {
+	return t.playing
+}
+
+// Sample returns the current output level: +volume for the high half of
+// each period, -volume for the low half, and 0 while silent or when the
+// frequency is 0 or above half the tick rate.
+func (t *TonePeripheral) Sample() int {
+	if !t.playing || t.freq == 0 {
+		return 0
+	}
+	period := uint32(ToneTickRate / uint32(t.freq))
+	if period < 2 {
+		return 0
+	}
+	if t.elapsed%period < period/2 {
+		return int(t.volume)
+	}
+	return -int(t.volume)
+}
+
+// SaveState serialises the registers and playback position as 11 bytes:
+// frequency, duration, volume (uint16 LE), playing (1 byte), elapsed ticks
+// (uint32 LE).
+func (t *TonePeripheral) SaveState() []byte {
+	buf := make([]byte, 11)
+	binary.LittleEndian.PutUint16(buf[0:], t.freq)
+	binary.LittleEndian.PutUint16(buf[2:], t.duration)
+	binary.LittleEndian.PutUint16(buf[4:], t.volume)
+	if t.playing {
+		buf[6] = 1
+	}
+	binary.LittleEndian.PutUint32(buf[7:], t.elapsed)
+	return buf
+}
+
+// LoadState restores the state saved by SaveState.
+func (t *TonePeripheral) LoadState(data []byte) error {
+	if len(data) < 11 {
+		return fmt.Errorf("TonePeripheral.LoadState: need 11 bytes, got %d", len(data))
+	}
+	t.freq = binary.LittleEndian.Uint16(data[0:])
+	t.duration = binary.LittleEndian.Uint16(data[2:])
+	t.volume = binary.LittleEndian.Uint16(data[4:])
+	t.playing = data[6] != 0
+	t.elapsed = binary.LittleEndian.Uint32(data[7:])
+	return nil
+}
@@ -0,0 +1,82 @@
+package peripherals
+
+import (
+	"gocpu/pkg/cpu"
+	"testing"
+)
+
+func TestTonePeripheral_PlaysAndStops(t *testing.T) {
+	c := cpu.NewCPU()
+	p := NewTonePeripheral(c, 2)
+	c.MountPeripheral(2, p)
+
+	// 1 kHz for 2 ms: a 600-tick period, stopping after 1200 ticks.
+	c.Write16(0xFE22, 1000)
+	c.Write16(0xFE24, 2)
+	c.Write16(0xFE26, 100)
+	if p.Playing() || p.Sample() != 0 {
+		t.Fatal("tone playing before it was started")
+	}
+	c.Write16(0xFE20, 1)
+	if c.Read16(0xFE20) != 1 || !p.Playing() {
+		t.Fatal("tone did not start")
+	}
+
+	if s := p.Sample(); s != 100 {
+		t.Errorf("sample at tick 0 = %d, expected 100", s)
+	}
+	for i := 0; i < 300; i++ {
+		p.Step()
+	}
+	if s := p.Sample(); s != -100 {
+		t.Errorf("sample at tick 300 = %d, expected -100", s)
+	}
+
+	// Survive a save/restore mid-tone.
+	restored := NewTonePeripheral(c, 2)
+	if err := restored.LoadState(p.SaveState()); err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	c.MountPeripheral(2, restored)
+	p = restored
+
+	for i := 300; i < 1199; i++ {
+		p.Step()
+	}
+	if !p.Playing() {
+		t.Fatal("tone stopped early")
+	}
+	if c.PeripheralIntMask != 0 {
+		t.Error("interrupt raised before the tone finished")
+	}
+	p.Step()
+	if p.Playing() || p.Sample() != 0 || c.Read16(0xFE20) != 0 {
+		t.Error("tone still playing after its duration")
+	}
+	if c.PeripheralIntMask&(1<<2) == 0 {
+		t.Error("no interrupt when the tone finished")
+	}
+}
+
+func TestTonePeripheral_StopAndUntimed(t *testing.T) {
+	c := cpu.NewCPU()
+	p := NewTonePeripheral(c, 0)
+	p.Write16(0x02, 440)
+	p.Write16(0x06, 0x1FF) // volume is 8 bits
+	p.Write16(0x00, 1)
+
+	for i := 0; i < ToneTickRate; i++ {
+		p.Step()
+	}
+	if !p.Playing() {
+		t.Fatal("untimed tone stopped on its own")
+	}
+	if v := p.Read16(0x06); v != 0xFF {
+		t.Errorf("volume = 0x%X, expected 0xFF", v)
+	}
+
+	p.Write16(0x00, 0)
+	if p.Playing() || c.PeripheralIntMask != 0 {
+		t.Error("stop did not silence the tone, or raised an interrupt")
+	}
+}
@@ -0,0 +1,112 @@
+package vfs
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"sort"
+	"time"
+)
+
+// paxCreated is the PAX record holding a file's creation time, which tar
+// headers have no field for.
+const paxCreated = "SICPU.created"
+
+// ExportTar writes every file on the disk to w as a tar archive, in name
+// order. Each entry's ModTime is the file's modification time and its
+// creation time is kept in a PAX record.
+func (vd *VirtualDisk) ExportTar(w io.Writer) error {
+	vd.Mu.RLock()
+	defer vd.Mu.RUnlock()
+
+	names := make([]string, 0, len(vd.Files))
+	for name := range vd.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tar.NewWriter(w)
+	for _, name := range names {
+		entry := vd.Files[name]
+		hdr := &tar.Header{
+			Typeflag:   tar.TypeReg,
+			Name:       name,
+			Mode:       0644,
+			Size:       int64(len(entry.Data)),
+			ModTime:    entry.Modified,
+			PAXRecords: map[string]string{paxCreated: entry.Created.Format(time.RFC3339Nano)},
+			Format:     tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(entry.Data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// ImportTar adds the regular files in a tar archive to the disk, replacing
+// files of the same name. Names must be valid VFS filenames. The whole
+// archive is checked before anything is written, so on error (including
+// ErrQuotaExceeded) the disk is left unchanged.
+func (vd *VirtualDisk) ImportTar(r io.Reader) error {
+	vd.Mu.Lock()
+	defer vd.Mu.Unlock()
+
+	imported := make(map[string]*FileEntry)
+	used := vd.UsedBytes
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return errors.New("unsupported tar entry type for " + hdr.Name)
+		}
+		if !validFilename.MatchString(hdr.Name) {
+			return ErrInvalidFilename
+		}
+
+		// Account for the file this entry replaces, whether it is already on
+		// the disk or appeared earlier in the archive.
+		if prev, ok := imported[hdr.Name]; ok {
+			used -= len(prev.Data)
+		} else if existing, ok := vd.Files[hdr.Name]; ok {
+			used -= len(existing.Data)
+		}
+		if hdr.Size > int64(MaxDiskBytes-used) {
+			return ErrQuotaExceeded
+		}
+
+		data := make([]byte, hdr.Size)
+		if _, err := io.ReadFull(tr, data); err != nil {
+			return err
+		}
+		used += len(data)
+
+		created := hdr.ModTime
+		if s, ok := hdr.PAXRecords[paxCreated]; ok {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				created = t
+			}
+		}
+		imported[hdr.Name] = &FileEntry{Data: data, Created: created, Modified: hdr.ModTime}
+	}
+
+	for name, entry := range imported {
+		vd.Files[name] = entry
+		vd.DirtyFiles[name] = true
+		vd.Dirty = true
+	}
+	vd.UsedBytes = used
+	return nil
+}
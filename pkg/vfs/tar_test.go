@@ -0,0 +1,83 @@
+package vfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVirtualDisk_TarRoundTrip(t *testing.T) {
+	src := NewVirtualDisk()
+	files := map[string][]byte{
+		"readme.txt": []byte("hello"),
+		"prog.bin":   {0x00, 0x08, 0xFF},
+		".config":    {},
+	}
+	base := time.Date(2024, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	i := 0
+	for name, data := range files {
+		if err := src.Write(name, data); err != nil {
+			t.Fatalf("Write(%s) failed: %v", name, err)
+		}
+		src.Files[name].Created = base.Add(time.Duration(i) * time.Hour)
+		src.Files[name].Modified = base.Add(time.Duration(i)*time.Hour + time.Minute)
+		i++
+	}
+
+	var buf bytes.Buffer
+	if err := src.ExportTar(&buf); err != nil {
+		t.Fatalf("ExportTar failed: %v", err)
+	}
+
+	dst := NewVirtualDisk()
+	if err := dst.ImportTar(&buf); err != nil {
+		t.Fatalf("ImportTar failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(dst.List(), src.List()) {
+		t.Fatalf("List = %v, expected %v", dst.List(), src.List())
+	}
+	for name, data := range files {
+		got, err := dst.Read(name)
+		if err != nil {
+			t.Fatalf("Read(%s) failed: %v", name, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("%s = %v, expected %v", name, got, data)
+		}
+
+		wantCreated, wantModified, _ := src.GetMeta(name)
+		created, modified, _ := dst.GetMeta(name)
+		if !created.Equal(wantCreated) || !modified.Equal(wantModified) {
+			t.Errorf("%s times = %v/%v, expected %v/%v", name, created, modified, wantCreated, wantModified)
+		}
+		if !dst.DirtyFiles[name] {
+			t.Errorf("%s not marked dirty after import", name)
+		}
+	}
+	if dst.UsedBytes != src.UsedBytes {
+		t.Errorf("UsedBytes = %d, expected %d", dst.UsedBytes, src.UsedBytes)
+	}
+}
+
+func TestVirtualDisk_ImportTarQuota(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range []string{"a.bin", "b.bin"} {
+		data := make([]byte, MaxDiskBytes/2+1)
+		tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(data))})
+		tw.Write(data)
+	}
+	tw.Close()
+
+	vd := NewVirtualDisk()
+	vd.Write("keep.txt", []byte{1})
+	if err := vd.ImportTar(&buf); err != ErrQuotaExceeded {
+		t.Fatalf("ImportTar error = %v, expected ErrQuotaExceeded", err)
+	}
+	if !reflect.DeepEqual(vd.List(), []string{"keep.txt"}) || vd.UsedBytes != 1 {
+		t.Errorf("disk changed by failed import: %v, %d bytes", vd.List(), vd.UsedBytes)
+	}
+}
@@ -20,6 +20,7 @@ var (
 	ErrFileNotFound    = errors.New("file not found")
 	ErrInvalidFilename = errors.New("invalid filename")
 	ErrQuotaExceeded   = errors.New("disk quota exceeded")
+	ErrFileExists      = errors.New("file already exists")
 )
 
 type FileEntry struct {
@@ -89,6 +90,43 @@ func (vd *VirtualDisk) Write(filename string, data []byte) error {
 	return nil
 }
 
+// Append adds data to the end of a file, creating the file if it does not
+// exist. Like Write it copies data and fails with ErrQuotaExceeded, leaving
+// the file unchanged, if the disk cannot hold the extra bytes.
+func (vd *VirtualDisk) Append(filename string, data []byte) error {
+	vd.Mu.Lock()
+	defer vd.Mu.Unlock()
+
+	if !validFilename.MatchString(filename) {
+		return ErrInvalidFilename
+	}
+
+	if vd.UsedBytes+len(data) > MaxDiskBytes {
+		return ErrQuotaExceeded
+	}
+
+	entry, ok := vd.Files[filename]
+	if !ok {
+		entry = &FileEntry{
+			Created: time.Now(),
+		}
+		vd.Files[filename] = entry
+	}
+
+	// Build a fresh slice so data previously returned by Read is never shared.
+	newData := make([]byte, len(entry.Data)+len(data))
+	copy(newData, entry.Data)
+	copy(newData[len(entry.Data):], data)
+	entry.Data = newData
+	entry.Modified = time.Now()
+
+	vd.DirtyFiles[filename] = true
+	vd.UsedBytes += len(data)
+	vd.Dirty = true
+
+	return nil
+}
+
 // Read reads data from a file on the virtual disk.
 // It returns the file data if it exists, or an error if the file is not found or the filename is invalid.
 func (vd *VirtualDisk) Read(filename string) ([]byte, error) {
@@ -107,6 +145,34 @@ func (vd *VirtualDisk) Read(filename string) ([]byte, error) {
 	return entry.Data, nil
 }
 
+// ReadAt returns a copy of up to length bytes of a file, starting at byte
+// offset. It returns fewer bytes near the end of the file, and none when
+// offset is at or past the end.
+func (vd *VirtualDisk) ReadAt(filename string, offset, length int) ([]byte, error) {
+	vd.Mu.RLock()
+	defer vd.Mu.RUnlock()
+
+	if !validFilename.MatchString(filename) {
+		return nil, ErrInvalidFilename
+	}
+
+	entry, ok := vd.Files[filename]
+	if !ok {
+		return nil, ErrFileNotFound
+	}
+
+	if offset >= len(entry.Data) {
+		return []byte{}, nil
+	}
+	end := offset + length
+	if end > len(entry.Data) {
+		end = len(entry.Data)
+	}
+	data := make([]byte, end-offset)
+	copy(data, entry.Data[offset:end])
+	return data, nil
+}
+
 // Size returns the size of a file in bytes.
 // It returns an error if the file is not found or the filename is invalid.
 func (vd *VirtualDisk) Size(filename string) (int, error) {
@@ -149,6 +215,35 @@ func (vd *VirtualDisk) Delete(filename string) error {
 	return nil
 }
 
+// Rename moves a file to a new name, keeping its data and timestamps. It
+// fails with ErrFileExists rather than replacing an existing file.
+func (vd *VirtualDisk) Rename(oldName, newName string) error {
+	vd.Mu.Lock()
+	defer vd.Mu.Unlock()
+
+	if !validFilename.MatchString(oldName) || !validFilename.MatchString(newName) {
+		return ErrInvalidFilename
+	}
+
+	entry, ok := vd.Files[oldName]
+	if !ok {
+		return ErrFileNotFound
+	}
+	if _, exists := vd.Files[newName]; exists {
+		return ErrFileExists
+	}
+
+	delete(vd.Files, oldName)
+	vd.Files[newName] = entry
+
+	// Both names change on the host: the old file is removed, the new one written.
+	vd.DirtyFiles[oldName] = true
+	vd.DirtyFiles[newName] = true
+	vd.Dirty = true
+
+	return nil
+}
+
 // FreeSpace returns the number of free bytes on the disk.
 func (vd *VirtualDisk) FreeSpace() int {
 	vd.Mu.RLock()
@@ -156,6 +251,13 @@ func (vd *VirtualDisk) FreeSpace() int {
 	return MaxDiskBytes - vd.UsedBytes
 }
 
+// UsedSpace returns the number of bytes used on the disk.
+func (vd *VirtualDisk) UsedSpace() int {
+	vd.Mu.RLock()
+	defer vd.Mu.RUnlock()
+	return vd.UsedBytes
+}
+
 // List returns a sorted list of all filenames in the VFS.
 func (vd *VirtualDisk) List() []string {
 	vd.Mu.RLock()
@@ -351,6 +351,9 @@ func TestVirtualDisk_NewFeatures(t *testing.T) {
 	if vd.FreeSpace() != MaxDiskBytes-3 {
 		t.Errorf("FreeSpace after write = %d, expected %d", vd.FreeSpace(), MaxDiskBytes-3)
 	}
+	if vd.UsedSpace() != 3 {
+		t.Errorf("UsedSpace after write = %d, expected 3", vd.UsedSpace())
+	}
 
 	created, modified, err := vd.GetMeta("test.txt")
 	if err != nil {
@@ -389,3 +392,108 @@ func TestVirtualDisk_NewFeatures(t *testing.T) {
 		t.Errorf("Delete missing file error = %v, expected ErrFileNotFound", err)
 	}
 }
+
+func TestVirtualDisk_Rename(t *testing.T) {
+	vd := NewVirtualDisk()
+	vd.Write("old.txt", []byte("data"))
+	vd.Write("other.txt", []byte{1})
+	created, modified, _ := vd.GetMeta("old.txt")
+	vd.DirtyFiles = make(map[string]bool)
+
+	if err := vd.Rename("old.txt", "new.txt"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if !reflect.DeepEqual(vd.List(), []string{"new.txt", "other.txt"}) {
+		t.Errorf("List = %v", vd.List())
+	}
+	data, _ := vd.Read("new.txt")
+	if string(data) != "data" || vd.UsedBytes != 5 {
+		t.Errorf("new.txt = %q, UsedBytes = %d", data, vd.UsedBytes)
+	}
+	if c, m, _ := vd.GetMeta("new.txt"); !c.Equal(created) || !m.Equal(modified) {
+		t.Errorf("timestamps changed by rename")
+	}
+	if !vd.DirtyFiles["old.txt"] || !vd.DirtyFiles["new.txt"] {
+		t.Errorf("DirtyFiles = %v, expected both names", vd.DirtyFiles)
+	}
+
+	if err := vd.Rename("missing.txt", "x.txt"); err != ErrFileNotFound {
+		t.Errorf("missing source: err = %v, expected ErrFileNotFound", err)
+	}
+	if err := vd.Rename("new.txt", "other.txt"); err != ErrFileExists {
+		t.Errorf("existing target: err = %v, expected ErrFileExists", err)
+	}
+	if err := vd.Rename("new.txt", "bad!name"); err != ErrInvalidFilename {
+		t.Errorf("invalid target: err = %v, expected ErrInvalidFilename", err)
+	}
+	if d, _ := vd.Read("other.txt"); !reflect.DeepEqual(d, []byte{1}) {
+		t.Errorf("failed rename overwrote other.txt: %v", d)
+	}
+}
+
+func TestVirtualDisk_ReadAt(t *testing.T) {
+	vd := NewVirtualDisk()
+	vd.Write("data.bin", []byte("0123456789"))
+
+	tests := []struct {
+		offset, length int
+		want           string
+	}{
+		{0, 4, "0123"},
+		{3, 4, "3456"},
+		{8, 4, "89"}, // short read at the end
+		{10, 4, ""},  // at EOF
+		{50, 4, ""},  // past EOF
+	}
+	for _, tt := range tests {
+		got, err := vd.ReadAt("data.bin", tt.offset, tt.length)
+		if err != nil {
+			t.Fatalf("ReadAt(%d, %d) failed: %v", tt.offset, tt.length, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("ReadAt(%d, %d) = %q, expected %q", tt.offset, tt.length, got, tt.want)
+		}
+	}
+
+	got, _ := vd.ReadAt("data.bin", 0, 2)
+	got[0] = 'X'
+	if data, _ := vd.Read("data.bin"); data[0] != '0' {
+		t.Error("ReadAt returned the file's backing array")
+	}
+	if _, err := vd.ReadAt("missing.bin", 0, 1); err != ErrFileNotFound {
+		t.Errorf("missing file: err = %v, expected ErrFileNotFound", err)
+	}
+}
+
+func TestVirtualDisk_Append(t *testing.T) {
+	vd := NewVirtualDisk()
+	if err := vd.Append("log.txt", []byte("one,")); err != nil {
+		t.Fatalf("first Append failed: %v", err)
+	}
+	created, _, _ := vd.GetMeta("log.txt")
+	if err := vd.Append("log.txt", []byte("two")); err != nil {
+		t.Fatalf("second Append failed: %v", err)
+	}
+
+	data, _ := vd.Read("log.txt")
+	if string(data) != "one,two" {
+		t.Errorf("content = %q, expected %q", data, "one,two")
+	}
+	if vd.UsedBytes != 7 {
+		t.Errorf("UsedBytes = %d, expected 7", vd.UsedBytes)
+	}
+	if c, m, _ := vd.GetMeta("log.txt"); !c.Equal(created) || m.Before(c) {
+		t.Errorf("created = %v, modified = %v; expected created unchanged", c, m)
+	}
+	if !vd.DirtyFiles["log.txt"] {
+		t.Error("appended file not marked dirty")
+	}
+
+	vd.Write("filler.bin", make([]byte, MaxDiskBytes-vd.UsedBytes-2))
+	if err := vd.Append("log.txt", []byte("abc")); err != ErrQuotaExceeded {
+		t.Errorf("Append over quota: err = %v, expected ErrQuotaExceeded", err)
+	}
+	if data, _ := vd.Read("log.txt"); string(data) != "one,two" || vd.UsedBytes != MaxDiskBytes-2 {
+		t.Errorf("failed Append changed the disk: %q, %d bytes used", data, vd.UsedBytes)
+	}
+}